@@ -0,0 +1,72 @@
+// Command fakeprinter is a stand-in for a network thermal printer. It accepts
+// raw ESC/POS streams on a TCP port, like a real printer on port 9100, and
+// logs what would have been printed.
+//
+//	go run ./cmd/fakeprinter -addr :9100
+package main
+
+import (
+	"bytes"
+	"flag"
+	"io"
+	"log"
+	"net"
+)
+
+func main() {
+	addr := flag.String("addr", ":9100", "address to listen on")
+	flag.Parse()
+
+	listener, err := net.Listen("tcp", *addr)
+	if err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("fake printer listening on %s", listener.Addr())
+
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			log.Print(err)
+			continue
+		}
+
+		go func(conn net.Conn) {
+			defer conn.Close()
+
+			data, err := io.ReadAll(conn)
+			if err != nil {
+				log.Print(err)
+				return
+			}
+
+			log.Printf("received %d bytes from %s\n%s", len(data), conn.RemoteAddr(), stripControl(data))
+		}(conn)
+	}
+}
+
+// stripControl drops ESC/POS command sequences, leaving the printable text.
+func stripControl(data []byte) []byte {
+	var out bytes.Buffer
+
+	for i := 0; i < len(data); i++ {
+		switch data[i] {
+		case 0x1b:
+			if i+1 < len(data) && data[i+1] == '@' {
+				i++
+			} else {
+				i += 2
+			}
+		case 0x1d:
+			if i+1 < len(data) && data[i+1] == 'V' {
+				i += 3
+				out.WriteString("---- cut ----\n")
+			} else {
+				i += 2
+			}
+		default:
+			out.WriteByte(data[i])
+		}
+	}
+
+	return out.Bytes()
+}
@@ -0,0 +1,241 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/Hdeee1/go-restaurant-management/database"
+	"github.com/Hdeee1/go-restaurant-management/helpers"
+	"github.com/Hdeee1/go-restaurant-management/models"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type BundleSlotsRequest struct {
+	Slots []models.BundleSlot `json:"slots" validate:"required,min=1,dive"`
+}
+
+// SetBundleSlots godoc
+//
+//	@Summary		Set a bundle's slots (Admin only)
+//	@Description	Replace the slots of a BUNDLE food, each listing the foods that can fill it, e.g. one main, one side and one drink. Bundles cannot contain other bundles.
+//	@Tags			Foods
+//	@Accept			json
+//	@Produce		json
+//	@Param			food_id	path	string				true	"Bundle food ID"
+//	@Param			slots	body	BundleSlotsRequest	true	"Slots"
+//	@Security		BearerAuth
+//	@Success		200	{object}	models.Food
+//	@Failure		400	{object}	map[string]interface{}
+//	@Failure		404	{object}	map[string]interface{}
+//	@Failure		409	{object}	map[string]interface{}
+//	@Failure		500	{object}	map[string]interface{}
+//	@Router			/foods/{food_id}/slots [put]
+func SetBundleSlots() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		foodID := ctx.Param("food_id")
+
+		var req BundleSlotsRequest
+		if err := ctx.BindJSON(&req); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		if err := helpers.Validate.Struct(req); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		var bundle models.Food
+		if err := database.DB.Where("food_id = ?", foodID).First(&bundle).Error; err != nil {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "food_id not found"})
+			return
+		}
+
+		if bundle.Food_type != models.FoodBundle {
+			ctx.JSON(http.StatusConflict, gin.H{"error": "food is not a bundle"})
+			return
+		}
+
+		var optionIDs []string
+		for _, slot := range req.Slots {
+			seen := make(map[string]bool)
+			for _, option := range slot.Options {
+				if seen[*option.Food_id] {
+					ctx.JSON(http.StatusBadRequest, gin.H{"error": "food " + *option.Food_id + " is listed twice in slot " + *slot.Name})
+					return
+				}
+				seen[*option.Food_id] = true
+				optionIDs = append(optionIDs, *option.Food_id)
+			}
+		}
+
+		var foods []models.Food
+		if err := database.DB.Where("food_id IN ?", optionIDs).Find(&foods).Error; err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		found := make(map[string]models.Food, len(foods))
+		for _, food := range foods {
+			found[food.Food_id] = food
+		}
+		for _, id := range optionIDs {
+			food, ok := found[id]
+			if !ok {
+				ctx.JSON(http.StatusNotFound, gin.H{"error": "food " + id + " not found"})
+				return
+			}
+			if food.Food_type == models.FoodBundle {
+				ctx.JSON(http.StatusBadRequest, gin.H{"error": "food " + id + " is a bundle and cannot be part of another bundle"})
+				return
+			}
+		}
+
+		err := database.DB.Transaction(func(tx *gorm.DB) error {
+			var oldSlotIDs []string
+			if err := tx.Model(&models.BundleSlot{}).Where("bundle_id = ?", foodID).Pluck("slot_id", &oldSlotIDs).Error; err != nil {
+				return err
+			}
+			if len(oldSlotIDs) > 0 {
+				if err := tx.Where("slot_id IN ?", oldSlotIDs).Delete(&models.BundleOption{}).Error; err != nil {
+					return err
+				}
+				if err := tx.Where("bundle_id = ?", foodID).Delete(&models.BundleSlot{}).Error; err != nil {
+					return err
+				}
+			}
+
+			for _, slot := range req.Slots {
+				slot.ID = 0
+				slot.Slot_id = uuid.New().String()
+				slot.Bundle_id = foodID
+				for i := range slot.Options {
+					slot.Options[i].ID = 0
+					slot.Options[i].Slot_id = slot.Slot_id
+				}
+				if err := tx.Create(&slot).Error; err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		if err := database.DB.Scopes(preloadSlots).Where("food_id = ?", foodID).First(&bundle).Error; err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		ctx.JSON(http.StatusOK, bundle)
+	}
+}
+
+// preloadSlots loads bundle slots with their options in display order.
+func preloadSlots(db *gorm.DB) *gorm.DB {
+	return db.Preload("Slots", func(db *gorm.DB) *gorm.DB {
+		return db.Order("sort_order, id")
+	}).Preload("Slots.Options")
+}
+
+// bundleItems expands an ordered bundle into one order item per slot, filled
+// with the food chosen for it. A slot with a single option fills itself. The
+// bundle's price is split across the components in proportion to their own
+// prices, so reports and taxes credit each food with its share.
+func bundleItems(db *gorm.DB, bundle models.Food, item models.OrderItem) ([]models.OrderItem, error) {
+	if bundle.Price == nil {
+		return nil, &orderError{http.StatusConflict, "bundle has no price"}
+	}
+
+	var slots []models.BundleSlot
+	if err := db.Preload("Options").Where("bundle_id = ?", bundle.Food_id).Order("sort_order, id").Find(&slots).Error; err != nil {
+		return nil, err
+	}
+	if len(slots) == 0 {
+		return nil, &orderError{http.StatusConflict, "bundle has no slots"}
+	}
+
+	choices := make(map[string]string, len(item.Components))
+	for _, choice := range item.Components {
+		if _, ok := choices[choice.Slot_id]; ok {
+			return nil, &orderError{http.StatusBadRequest, "slot " + choice.Slot_id + " is chosen twice"}
+		}
+		choices[choice.Slot_id] = choice.Food_id
+	}
+
+	foodIDs := make([]string, len(slots))
+	for i, slot := range slots {
+		foodID, ok := choices[slot.Slot_id]
+		if !ok && len(slot.Options) == 1 {
+			foodID, ok = *slot.Options[0].Food_id, true
+		}
+		if !ok {
+			return nil, &orderError{http.StatusBadRequest, "choose a food for " + *slot.Name}
+		}
+		delete(choices, slot.Slot_id)
+
+		allowed := false
+		for _, option := range slot.Options {
+			if *option.Food_id == foodID {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return nil, &orderError{http.StatusBadRequest, "food " + foodID + " is not an option for " + *slot.Name}
+		}
+		foodIDs[i] = foodID
+	}
+	for slotID := range choices {
+		return nil, &orderError{http.StatusBadRequest, "slot " + slotID + " is not part of this bundle"}
+	}
+
+	var foods []models.Food
+	if err := db.Preload("Prices").Where("food_id IN ?", foodIDs).Find(&foods).Error; err != nil {
+		return nil, err
+	}
+	byID := make(map[string]models.Food, len(foods))
+	for _, food := range foods {
+		byID[food.Food_id] = food
+	}
+
+	weights := make([]float64, len(foodIDs))
+	total := 0.0
+	for i, id := range foodIDs {
+		food, ok := byID[id]
+		if !ok {
+			return nil, &orderError{http.StatusNotFound, "food_id not found"}
+		}
+		if price, ok := food.PriceIn(bundle.Price.Currency); ok && price.IsPositive() {
+			weights[i] = float64(price.Minor)
+			total += weights[i]
+		}
+	}
+	// Components without a price in the bundle's currency get no share,
+	// unless none of them has one and the price is split equally.
+	if total == 0 {
+		for i := range weights {
+			weights[i] = 1
+		}
+	}
+	shares := bundle.Price.Allocate(weights)
+
+	bundleItemID := uuid.New().String()
+	items := make([]models.OrderItem, len(slots))
+	for i := range slots {
+		items[i] = models.OrderItem{
+			Quantity:       item.Quantity,
+			Unit_price:     &shares[i],
+			Food_id:        &foodIDs[i],
+			Order_id:       item.Order_id,
+			Course:         item.Course,
+			Bundle_id:      &bundle.Food_id,
+			Bundle_item_id: &bundleItemID,
+			Slot_id:        &slots[i].Slot_id,
+		}
+	}
+
+	return items, nil
+}
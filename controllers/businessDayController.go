@@ -0,0 +1,428 @@
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/Hdeee1/go-restaurant-management/database"
+	"github.com/Hdeee1/go-restaurant-management/helpers"
+	"github.com/Hdeee1/go-restaurant-management/models"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type CloseDayRequest struct {
+	Date string `json:"date" validate:"omitempty,datetime=2006-01-02"`
+}
+
+// GetBusinessDays godoc
+//
+//	@Summary		Get closed business days
+//	@Description	Retrieve a paginated list of closed business days, newest first
+//	@Tags			Business Days
+//	@Accept			json
+//	@Produce		json
+//	@Param			page	query	int	false	"Page number"		default(1)
+//	@Param			limit	query	int	false	"Items per page"	default(10)
+//	@Security		BearerAuth
+//	@Success		200	{object}	map[string]interface{}
+//	@Failure		500	{object}	map[string]interface{}
+//	@Router			/business-days [get]
+func GetBusinessDays() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var days []models.BusinessDay
+
+		result := database.DB.Scopes(helpers.Paginate(ctx)).Order("date DESC").Find(&days)
+		if result.Error != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
+			return
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{
+			"business_days": days,
+			"page":          ctx.DefaultQuery("page", "1"),
+			"limit":         ctx.DefaultQuery("limit", "10"),
+		})
+	}
+}
+
+// GetBusinessDay godoc
+//
+//	@Summary		Get the Z report of a business day
+//	@Description	Retrieve the Z report stored when a day was closed. For a day that is still open the report is computed live. The report can also be downloaded as CSV or XLSX, one line per total.
+//	@Tags			Business Days
+//	@Accept			json
+//	@Produce		json
+//	@Produce		text/csv
+//	@Produce		application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+//	@Param			date	path	string	true	"Business date (YYYY-MM-DD)"
+//	@Param			format	query	string	false	"json, csv or xlsx"
+//	@Security		BearerAuth
+//	@Success		200	{object}	map[string]interface{}
+//	@Failure		400	{object}	map[string]interface{}
+//	@Failure		500	{object}	map[string]interface{}
+//	@Router			/business-days/{date} [get]
+func GetBusinessDay() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		date := ctx.Param("date")
+
+		format, err := helpers.ExportFormat(ctx)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		report, day, err := businessDayReport(database.DB, date)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		if format != "" {
+			header := []string{"line", "count", "amount", "currency"}
+			if err := helpers.ExportRows(ctx, format, "z-report-"+date, header, zReportRows(report)); err != nil {
+				helpers.ExportFailed(ctx, err)
+			}
+			return
+		}
+
+		response := gin.H{"date": date, "closed": day != nil, "report": report}
+		if day != nil {
+			response["closed_at"] = day.Closed_at
+			response["closed_by"] = day.Closed_by
+		}
+
+		ctx.JSON(http.StatusOK, response)
+	}
+}
+
+// CloseBusinessDay godoc
+//
+//	@Summary		Close a business day (Admin only)
+//	@Description	Lock a business day (today by default) and store its Z report. No orders can be placed on a closed day.
+//	@Tags			Business Days
+//	@Accept			json
+//	@Produce		json
+//	@Param			day	body	CloseDayRequest	false	"Day to close"
+//	@Security		BearerAuth
+//	@Success		201	{object}	map[string]interface{}
+//	@Failure		400	{object}	map[string]interface{}
+//	@Failure		409	{object}	map[string]interface{}
+//	@Failure		500	{object}	map[string]interface{}
+//	@Router			/business-days/close [post]
+func CloseBusinessDay() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var req CloseDayRequest
+		if ctx.Request.ContentLength > 0 {
+			if err := ctx.BindJSON(&req); err != nil {
+				ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+		}
+
+		if err := helpers.Validate.Struct(req); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		if req.Date == "" {
+			req.Date = businessDate(time.Now())
+		}
+
+		start, _, _ := businessDayBounds(req.Date)
+		if start.After(time.Now()) {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "cannot close a future day"})
+			return
+		}
+
+		tx := database.DB.Begin()
+
+		// Closing takes the row first so orders racing the close see it.
+		day := models.BusinessDay{
+			Business_day_id: uuid.New().String(),
+			Date:            req.Date,
+			Closed_at:       time.Now(),
+			Closed_by:       ctx.GetString("user_id"),
+		}
+
+		// The check locks, so the report below is read only once the orders
+		// holding the day open have committed.
+		var existing []models.BusinessDay
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("date = ?", req.Date).Limit(1).Find(&existing).Error; err != nil {
+			tx.Rollback()
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if len(existing) > 0 {
+			tx.Rollback()
+			ctx.JSON(http.StatusConflict, gin.H{"error": "business day " + req.Date + " is already closed"})
+			return
+		}
+
+		if err := tx.Create(&day).Error; err != nil {
+			tx.Rollback()
+			ctx.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+
+		report, err := zReport(tx, req.Date)
+		if err != nil {
+			tx.Rollback()
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		day.Report, err = json.Marshal(report)
+		if err != nil {
+			tx.Rollback()
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		if err := tx.Model(&day).Update("report", day.Report).Error; err != nil {
+			tx.Rollback()
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		if err := tx.Commit().Error; err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		ctx.JSON(http.StatusCreated, gin.H{
+			"message":         "business day closed",
+			"business_day_id": day.Business_day_id,
+			"report":          report,
+		})
+	}
+}
+
+// businessDate is the business day a time falls on.
+func businessDate(t time.Time) string {
+	return t.In(time.Local).Format("2006-01-02")
+}
+
+func businessDayBounds(date string) (time.Time, time.Time, error) {
+	start, err := time.ParseInLocation("2006-01-02", date, time.Local)
+	if err != nil {
+		return start, start, fmt.Errorf("invalid date %q", date)
+	}
+	return start, start.AddDate(0, 0, 1), nil
+}
+
+// ensureDayOpen fails with a 409 orderError when the business day of t is
+// closed. It runs in the transaction that writes to the day: the shared lock
+// it reads with keeps the day from being closed until that transaction ends.
+func ensureDayOpen(tx *gorm.DB, t time.Time) error {
+	date := businessDate(t)
+
+	var days []models.BusinessDay
+	if err := tx.Clauses(clause.Locking{Strength: "SHARE"}).Where("date = ?", date).Limit(1).Find(&days).Error; err != nil {
+		return err
+	}
+
+	if len(days) > 0 {
+		return &orderError{http.StatusConflict, "business day " + date + " is closed"}
+	}
+
+	return nil
+}
+
+// businessDayReport returns the stored Z report of a closed day, or a live one for an open day.
+func businessDayReport(db *gorm.DB, date string) (models.ZReport, *models.BusinessDay, error) {
+	var report models.ZReport
+
+	if _, _, err := businessDayBounds(date); err != nil {
+		return report, nil, err
+	}
+
+	var day models.BusinessDay
+	err := db.Where("date = ?", date).First(&day).Error
+	if err == gorm.ErrRecordNotFound {
+		report, err = zReport(db, date)
+		return report, nil, err
+	}
+	if err != nil {
+		return report, nil, err
+	}
+
+	return report, &day, json.Unmarshal(day.Report, &report)
+}
+
+// zReport totals a business day's orders, invoices and payments.
+func zReport(db *gorm.DB, date string) (models.ZReport, error) {
+	currency := models.DefaultCurrency()
+	zero := models.Zero(currency)
+
+	report := models.ZReport{
+		Date:          date,
+		Currency:      currency,
+		Generated_at:  time.Now(),
+		Gross_sales:   zero,
+		Discounts:     zero,
+		Net_sales:     zero,
+		Charges:       zero,
+		Taxes:         []models.AmountTotal{},
+		Tax_collected: zero,
+		Payments:      []models.MethodTotal{},
+		Refunds:       zero,
+		Voids:         zero,
+		Tips:          zero,
+	}
+
+	start, end, err := businessDayBounds(date)
+	if err != nil {
+		return report, err
+	}
+
+	var orders []models.Order
+	if err := db.Where("order_date >= ? AND order_date < ?", start, end).Find(&orders).Error; err != nil {
+		return report, err
+	}
+
+	for _, order := range orders {
+		if order.Status == models.OrderRejected {
+			report.Rejected_orders++
+			continue
+		}
+		report.Orders++
+		if order.Source == models.OrderSourceGuest {
+			report.Guest_orders++
+		}
+	}
+
+	// toDefault converts an amount to the report currency at the rate of a given time.
+	toDefault := func(amount models.Money, at time.Time) (models.Money, error) {
+		converted, _, err := convertMoney(db, amount, currency, at)
+		return converted, err
+	}
+
+	var invoices []models.Invoice
+	if err := db.Where("created_at >= ? AND created_at < ?", start, end).Find(&invoices).Error; err != nil {
+		return report, err
+	}
+
+	docs, err := invoiceDocuments(db, invoices)
+	if err != nil {
+		return report, err
+	}
+
+	taxes := map[string]models.Money{}
+	for _, invoice := range invoices {
+		doc := docs[invoice.Invoice_id]
+
+		rate, err := exchangeRate(db, doc.Currency, currency, invoice.CreatedAt)
+		if err != nil {
+			return report, err
+		}
+
+		report.Invoices++
+		report.Gross_sales = report.Gross_sales.Add(doc.Subtotal().Convert(rate, currency))
+		for _, discount := range doc.Discounts {
+			report.Discounts = report.Discounts.Add(discount.Amount.Convert(rate, currency))
+		}
+		for _, charge := range doc.Charges {
+			report.Charges = report.Charges.Add(charge.Amount.Convert(rate, currency))
+		}
+		for _, tax := range append(doc.Taxes, doc.Included_taxes...) {
+			amount := tax.Amount.Convert(rate, currency)
+			taxes[tax.Label] = taxes[tax.Label].Add(amount)
+			report.Tax_collected = report.Tax_collected.Add(amount)
+		}
+	}
+	report.Net_sales = report.Gross_sales.Sub(report.Discounts)
+
+	for label, amount := range taxes {
+		report.Taxes = append(report.Taxes, models.AmountTotal{Label: label, Amount: amount})
+	}
+	sort.Slice(report.Taxes, func(i, j int) bool { return report.Taxes[i].Label < report.Taxes[j].Label })
+
+	var payments []models.Payment
+	err = db.Where("created_at >= ? AND created_at < ? AND status IN ?", start, end,
+		[]string{models.PaymentCaptured, models.PaymentRefunded}).Order("method").Find(&payments).Error
+	if err != nil {
+		return report, err
+	}
+
+	byMethod := map[string]int{}
+	for _, payment := range payments {
+		key := payment.Method + "/" + payment.Amount.Currency
+		i, ok := byMethod[key]
+		if !ok {
+			i = len(report.Payments)
+			byMethod[key] = i
+			report.Payments = append(report.Payments, models.MethodTotal{Method: payment.Method, Amount: models.Zero(payment.Amount.Currency)})
+		}
+		report.Payments[i].Count++
+		report.Payments[i].Amount = report.Payments[i].Amount.Add(payment.Amount)
+
+		tip, err := toDefault(payment.Tip_amount, payment.CreatedAt)
+		if err != nil {
+			return report, err
+		}
+		report.Tips = report.Tips.Add(tip)
+	}
+
+	var refunds []models.PaymentRefund
+	if err := db.Where("status = ? AND created_at >= ? AND created_at < ?", models.RefundCompleted, start, end).Find(&refunds).Error; err != nil {
+		return report, err
+	}
+
+	for _, refund := range refunds {
+		amount, err := toDefault(refund.Amount, refund.CreatedAt)
+		if err != nil {
+			return report, err
+		}
+		report.Refund_count++
+		report.Refunds = report.Refunds.Add(amount)
+	}
+
+	var voids []models.Payment
+	if err := db.Where("voided_at >= ? AND voided_at < ? AND status = ?", start, end, models.PaymentVoided).Find(&voids).Error; err != nil {
+		return report, err
+	}
+
+	for _, void := range voids {
+		amount, err := toDefault(void.Amount, *void.Voided_at)
+		if err != nil {
+			return report, err
+		}
+		report.Void_count++
+		report.Voids = report.Voids.Add(amount)
+	}
+
+	return report, nil
+}
+
+// zReportRows lays a Z report out as export rows: counts, then amounts in the
+// report currency, then payments in the currency they were taken in.
+func zReportRows(report models.ZReport) [][]interface{} {
+	rows := [][]interface{}{
+		{"orders", report.Orders, nil, nil},
+		{"guest_orders", report.Guest_orders, nil, nil},
+		{"rejected_orders", report.Rejected_orders, nil, nil},
+		{"invoices", report.Invoices, nil, nil},
+		{"gross_sales", nil, report.Gross_sales, report.Gross_sales.Currency},
+		{"discounts", nil, report.Discounts, report.Discounts.Currency},
+		{"net_sales", nil, report.Net_sales, report.Net_sales.Currency},
+		{"charges", nil, report.Charges, report.Charges.Currency},
+	}
+	for _, tax := range report.Taxes {
+		rows = append(rows, []interface{}{"tax: " + tax.Label, nil, tax.Amount, tax.Amount.Currency})
+	}
+	rows = append(rows, []interface{}{"tax_collected", nil, report.Tax_collected, report.Tax_collected.Currency})
+	for _, payment := range report.Payments {
+		rows = append(rows, []interface{}{"payments: " + payment.Method, payment.Count, payment.Amount, payment.Amount.Currency})
+	}
+	return append(rows,
+		[]interface{}{"refunds", report.Refund_count, report.Refunds, report.Refunds.Currency},
+		[]interface{}{"voids", report.Void_count, report.Voids, report.Voids.Currency},
+		[]interface{}{"tips", nil, report.Tips, report.Tips.Currency},
+	)
+}
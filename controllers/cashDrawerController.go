@@ -0,0 +1,508 @@
+package controllers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Hdeee1/go-restaurant-management/database"
+	"github.com/Hdeee1/go-restaurant-management/helpers"
+	"github.com/Hdeee1/go-restaurant-management/models"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// EventCashDiscrepancy is emitted when a drawer is closed with a count that does not match.
+const EventCashDiscrepancy = "cash_drawer.discrepancy"
+
+// errNoCashSession is returned when cash is taken or paid out without an open drawer session.
+var errNoCashSession = errors.New("no open cash drawer session")
+
+type OpenDrawerRequest struct {
+	Drawer        string        `json:"drawer" validate:"omitempty,max=40"`
+	Opening_float *models.Money `json:"opening_float" validate:"required"`
+}
+
+type PaidOutRequest struct {
+	Amount *models.Money `json:"amount" validate:"required"`
+	Reason string        `json:"reason" validate:"required,max=255"`
+}
+
+type CloseDrawerRequest struct {
+	Counted_cash *models.Money `json:"counted_cash" validate:"required"`
+	Note         *string       `json:"note" validate:"omitempty,max=255"`
+}
+
+// GetCashSessions godoc
+//
+//	@Summary		Get cash drawer sessions
+//	@Description	Retrieve a paginated list of cash drawer sessions, newest first
+//	@Tags			Cash Drawers
+//	@Accept			json
+//	@Produce		json
+//	@Param			page	query	int		false	"Page number"		default(1)
+//	@Param			limit	query	int		false	"Items per page"	default(10)
+//	@Param			drawer	query	string	false	"Drawer name"
+//	@Param			status	query	string	false	"OPEN or CLOSED"
+//	@Security		BearerAuth
+//	@Success		200	{object}	map[string]interface{}
+//	@Failure		500	{object}	map[string]interface{}
+//	@Router			/cash-drawers/sessions [get]
+func GetCashSessions() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var sessions []models.CashDrawerSession
+
+		query := database.DB.Scopes(helpers.Paginate(ctx)).Order("opened_at DESC")
+
+		if drawer := ctx.Query("drawer"); drawer != "" {
+			query = query.Where("drawer = ?", drawer)
+		}
+
+		if status := ctx.Query("status"); status != "" {
+			query = query.Where("status = ?", strings.ToUpper(status))
+		}
+
+		if err := query.Find(&sessions).Error; err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{
+			"sessions": sessions,
+			"page":     ctx.DefaultQuery("page", "1"),
+			"limit":    ctx.DefaultQuery("limit", "10"),
+		})
+	}
+}
+
+// GetCashSession godoc
+//
+//	@Summary		Get a cash drawer session
+//	@Description	Retrieve a session with its cash movements. The expected cash of an open session is computed live.
+//	@Tags			Cash Drawers
+//	@Accept			json
+//	@Produce		json
+//	@Param			session_id	path	string	true	"Session ID"
+//	@Security		BearerAuth
+//	@Success		200	{object}	map[string]interface{}
+//	@Failure		404	{object}	map[string]interface{}
+//	@Failure		500	{object}	map[string]interface{}
+//	@Router			/cash-drawers/sessions/{session_id} [get]
+func GetCashSession() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		session, ok := findCashSession(ctx)
+		if !ok {
+			return
+		}
+
+		var movements []models.CashMovement
+		if err := database.DB.Where("session_id = ?", session.Session_id).Order("created_at").Find(&movements).Error; err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		if session.Status == models.DrawerOpen {
+			expected, err := expectedCash(database.DB, session)
+			if err != nil {
+				ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+			session.Expected_cash = expected
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{"session": session, "movements": movements})
+	}
+}
+
+// OpenCashSession godoc
+//
+//	@Summary		Open a cash drawer
+//	@Description	Start a cashier's session on a drawer with the float counted into it. Cash payments taken by the cashier are attributed to the session.
+//	@Tags			Cash Drawers
+//	@Accept			json
+//	@Produce		json
+//	@Param			session	body	OpenDrawerRequest	true	"Drawer and opening float"
+//	@Security		BearerAuth
+//	@Success		201	{object}	map[string]interface{}
+//	@Failure		400	{object}	map[string]interface{}
+//	@Failure		409	{object}	map[string]interface{}
+//	@Failure		500	{object}	map[string]interface{}
+//	@Router			/cash-drawers/sessions [post]
+func OpenCashSession() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var req OpenDrawerRequest
+
+		if err := ctx.BindJSON(&req); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		if err := helpers.Validate.Struct(req); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		if req.Opening_float.IsNegative() {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "opening_float must not be negative"})
+			return
+		}
+
+		if req.Drawer == "" {
+			req.Drawer = "MAIN"
+		}
+
+		userID := ctx.GetString("user_id")
+
+		var open []models.CashDrawerSession
+		err := database.DB.Where("status = ? AND (drawer = ? OR opened_by = ?)", models.DrawerOpen, req.Drawer, userID).Find(&open).Error
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		for _, session := range open {
+			if session.Drawer == req.Drawer {
+				ctx.JSON(http.StatusConflict, gin.H{"error": "drawer " + req.Drawer + " is already open", "session_id": session.Session_id})
+				return
+			}
+			ctx.JSON(http.StatusConflict, gin.H{"error": "you already have drawer " + session.Drawer + " open", "session_id": session.Session_id})
+			return
+		}
+
+		session := models.CashDrawerSession{
+			Session_id:    uuid.New().String(),
+			Drawer:        req.Drawer,
+			Status:        models.DrawerOpen,
+			Opened_by:     userID,
+			Opened_at:     time.Now(),
+			Opening_float: *req.Opening_float,
+			Expected_cash: *req.Opening_float,
+			Counted_cash:  models.Zero(req.Opening_float.Currency),
+			Discrepancy:   models.Zero(req.Opening_float.Currency),
+			Open_drawer:   &req.Drawer,
+			Open_user:     &userID,
+		}
+
+		// The check above gives a helpful answer; the unique open_drawer and
+		// open_user keys settle two opens racing past it.
+		err = database.DB.Create(&session).Error
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			ctx.JSON(http.StatusConflict, gin.H{"error": "drawer " + req.Drawer + " is already open, or you already have a drawer open"})
+			return
+		}
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		ctx.JSON(http.StatusCreated, gin.H{
+			"message":    "drawer opened",
+			"session_id": session.Session_id,
+		})
+	}
+}
+
+// CreatePaidOut godoc
+//
+//	@Summary		Pay cash out of a drawer
+//	@Description	Record cash taken out of an open drawer, e.g. to pay a supplier
+//	@Tags			Cash Drawers
+//	@Accept			json
+//	@Produce		json
+//	@Param			session_id	path	string			true	"Session ID"
+//	@Param			paid_out	body	PaidOutRequest	true	"Amount and reason"
+//	@Security		BearerAuth
+//	@Success		201	{object}	map[string]interface{}
+//	@Failure		400	{object}	map[string]interface{}
+//	@Failure		403	{object}	map[string]interface{}
+//	@Failure		404	{object}	map[string]interface{}
+//	@Failure		409	{object}	map[string]interface{}
+//	@Failure		500	{object}	map[string]interface{}
+//	@Router			/cash-drawers/sessions/{session_id}/paid-outs [post]
+func CreatePaidOut() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var req PaidOutRequest
+
+		if err := ctx.BindJSON(&req); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		if err := helpers.Validate.Struct(req); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		session, ok := findCashSession(ctx)
+		if !ok || !ownsCashSession(ctx, session) {
+			return
+		}
+
+		if req.Amount.Currency != session.Opening_float.Currency {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "drawer holds " + session.Opening_float.Currency})
+			return
+		}
+
+		if !req.Amount.IsPositive() {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "amount must be positive"})
+			return
+		}
+
+		movement := models.CashMovement{
+			Movement_id: uuid.New().String(),
+			Session_id:  session.Session_id,
+			Type:        models.CashPaidOut,
+			Amount:      req.Amount.Neg(),
+			Reason:      &req.Reason,
+			Recorded_by: ctx.GetString("user_id"),
+		}
+
+		err := database.DB.Transaction(func(tx *gorm.DB) error {
+			session, err := lockOpenCashSession(tx, session.Session_id)
+			if err != nil {
+				return err
+			}
+
+			expected, err := expectedCash(tx, session)
+			if err != nil {
+				return err
+			}
+
+			if req.Amount.Cmp(expected) > 0 {
+				return &orderError{http.StatusBadRequest, "amount exceeds the cash in the drawer"}
+			}
+
+			return tx.Create(&movement).Error
+		})
+		if errors.Is(err, errNoCashSession) {
+			ctx.JSON(http.StatusConflict, gin.H{"error": "drawer session is closed"})
+			return
+		}
+		if err != nil {
+			ctx.JSON(orderErrorStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+
+		ctx.JSON(http.StatusCreated, gin.H{
+			"message":     "paid out",
+			"movement_id": movement.Movement_id,
+		})
+	}
+}
+
+// CloseCashSession godoc
+//
+//	@Summary		Close a cash drawer
+//	@Description	Count out a drawer. The counted cash is compared with the float plus cash sales less refunds and paid-outs, and any discrepancy is recorded.
+//	@Tags			Cash Drawers
+//	@Accept			json
+//	@Produce		json
+//	@Param			session_id	path	string				true	"Session ID"
+//	@Param			count		body	CloseDrawerRequest	true	"Counted cash"
+//	@Security		BearerAuth
+//	@Success		200	{object}	map[string]interface{}
+//	@Failure		400	{object}	map[string]interface{}
+//	@Failure		403	{object}	map[string]interface{}
+//	@Failure		404	{object}	map[string]interface{}
+//	@Failure		409	{object}	map[string]interface{}
+//	@Failure		500	{object}	map[string]interface{}
+//	@Router			/cash-drawers/sessions/{session_id}/close [post]
+func CloseCashSession() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var req CloseDrawerRequest
+
+		if err := ctx.BindJSON(&req); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		if err := helpers.Validate.Struct(req); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		session, ok := findCashSession(ctx)
+		if !ok || !ownsCashSession(ctx, session) {
+			return
+		}
+
+		if session.Status != models.DrawerOpen {
+			ctx.JSON(http.StatusConflict, gin.H{"error": "drawer session is already closed"})
+			return
+		}
+
+		if req.Counted_cash.Currency != session.Opening_float.Currency {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "drawer holds " + session.Opening_float.Currency})
+			return
+		}
+
+		if req.Counted_cash.IsNegative() {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "counted_cash must not be negative"})
+			return
+		}
+
+		now := time.Now()
+		closedBy := ctx.GetString("user_id")
+		var expected, discrepancy models.Money
+
+		// The session row is locked while the drawer is totalled and closed.
+		// Cash movements take the same lock and re-check that the drawer is
+		// open, so nothing can be added after the count.
+		err := database.DB.Transaction(func(tx *gorm.DB) error {
+			session, err := lockOpenCashSession(tx, session.Session_id)
+			if err != nil {
+				return err
+			}
+
+			expected, err = expectedCash(tx, session)
+			if err != nil {
+				return err
+			}
+			discrepancy = req.Counted_cash.Sub(expected)
+
+			return tx.Model(&session).Updates(map[string]interface{}{
+				"status":               models.DrawerClosed,
+				"open_drawer":          nil,
+				"open_user":            nil,
+				"closed_by":            closedBy,
+				"closed_at":            now,
+				"expected_minor":       expected.Minor,
+				"expected_currency":    expected.Currency,
+				"counted_minor":        req.Counted_cash.Minor,
+				"counted_currency":     req.Counted_cash.Currency,
+				"discrepancy_minor":    discrepancy.Minor,
+				"discrepancy_currency": discrepancy.Currency,
+				"note":                 req.Note,
+			}).Error
+		})
+		if errors.Is(err, errNoCashSession) {
+			ctx.JSON(http.StatusConflict, gin.H{"error": "drawer session is already closed"})
+			return
+		}
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		if !discrepancy.IsZero() {
+			event := helpers.Event{
+				Type:         EventCashDiscrepancy,
+				Reference_id: session.Session_id,
+				Occurred_at:  now,
+				Data: map[string]interface{}{
+					"drawer":      session.Drawer,
+					"closed_by":   closedBy,
+					"expected":    expected,
+					"counted":     req.Counted_cash,
+					"discrepancy": discrepancy,
+				},
+			}
+			if err := helpers.Events.Emit(event); err != nil {
+				log.Printf("cash drawer %s: emit %s: %v", session.Session_id, event.Type, err)
+			}
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{
+			"message":       "drawer closed",
+			"session_id":    session.Session_id,
+			"expected_cash": expected,
+			"counted_cash":  req.Counted_cash,
+			"discrepancy":   discrepancy,
+		})
+	}
+}
+
+func findCashSession(ctx *gin.Context) (models.CashDrawerSession, bool) {
+	var session models.CashDrawerSession
+
+	if err := database.DB.Where("session_id = ?", ctx.Param("session_id")).First(&session).Error; err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "session_id not found"})
+		return session, false
+	}
+
+	return session, true
+}
+
+// ownsCashSession lets only the cashier who opened a drawer, or an admin, work it.
+func ownsCashSession(ctx *gin.Context, session models.CashDrawerSession) bool {
+	if session.Opened_by == ctx.GetString("user_id") || ctx.GetString("role") == "admin" {
+		return true
+	}
+
+	ctx.JSON(http.StatusForbidden, gin.H{"error": "drawer session belongs to another cashier"})
+	return false
+}
+
+// openCashSession returns the open drawer session cash is taken into: the one
+// named, or else the one the user has open.
+func openCashSession(db *gorm.DB, sessionID string, userID string) (models.CashDrawerSession, error) {
+	var session models.CashDrawerSession
+
+	query := db.Where("status = ?", models.DrawerOpen)
+	if sessionID != "" {
+		query = query.Where("session_id = ?", sessionID)
+	} else {
+		query = query.Where("opened_by = ?", userID)
+	}
+
+	err := query.First(&session).Error
+	if err == gorm.ErrRecordNotFound {
+		return session, errNoCashSession
+	}
+
+	return session, err
+}
+
+// lockOpenCashSession locks a drawer session's row for the rest of tx and
+// fails with errNoCashSession once it is closed. Every movement takes this
+// lock, and so does closing, so cash cannot land in a drawer after its count.
+func lockOpenCashSession(tx *gorm.DB, sessionID string) (models.CashDrawerSession, error) {
+	var session models.CashDrawerSession
+
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("session_id = ?", sessionID).First(&session).Error
+	if err == gorm.ErrRecordNotFound || (err == nil && session.Status != models.DrawerOpen) {
+		return session, errNoCashSession
+	}
+
+	return session, err
+}
+
+// recordCash adds a cash movement for a payment to a drawer session, which
+// must still be open. It must run inside the payment's transaction.
+func recordCash(tx *gorm.DB, session models.CashDrawerSession, kind string, amount models.Money, paymentID string, userID string) error {
+	session, err := lockOpenCashSession(tx, session.Session_id)
+	if err != nil {
+		return err
+	}
+
+	if amount.Currency != session.Opening_float.Currency {
+		return errors.New("drawer holds " + session.Opening_float.Currency)
+	}
+
+	movement := models.CashMovement{
+		Movement_id: uuid.New().String(),
+		Session_id:  session.Session_id,
+		Type:        kind,
+		Amount:      amount,
+		Payment_id:  &paymentID,
+		Recorded_by: userID,
+	}
+
+	return tx.Create(&movement).Error
+}
+
+// expectedCash is what should be in a drawer: the float plus every movement.
+func expectedCash(db *gorm.DB, session models.CashDrawerSession) (models.Money, error) {
+	var total int64
+
+	err := db.Model(&models.CashMovement{}).
+		Where("session_id = ?", session.Session_id).
+		Select("COALESCE(SUM(amount_minor), 0)").
+		Scan(&total).Error
+
+	return session.Opening_float.Add(models.Money{Minor: total}), err
+}
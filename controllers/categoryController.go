@@ -0,0 +1,527 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/Hdeee1/go-restaurant-management/database"
+	"github.com/Hdeee1/go-restaurant-management/helpers"
+	"github.com/Hdeee1/go-restaurant-management/models"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// CategoryUpdate changes a category. An empty parent_id moves the category to
+// the top level of its menu.
+type CategoryUpdate struct {
+	Name        *string `json:"name" validate:"omitempty,min=1,max=100"`
+	Description *string `json:"description" validate:"omitempty,max=500"`
+	Sort_order  *int    `json:"sort_order"`
+	Parent_id   *string `json:"parent_id"`
+}
+
+// SortPosition places a category or food in a menu. For foods, category_id
+// optionally moves the food too; an empty category_id leaves it uncategorized.
+type SortPosition struct {
+	Id          string  `json:"id" validate:"required"`
+	Sort_order  int     `json:"sort_order"`
+	Category_id *string `json:"category_id"`
+}
+
+type MenuOrderRequest struct {
+	Categories []SortPosition `json:"categories" validate:"dive"`
+	Foods      []SortPosition `json:"foods" validate:"dive"`
+}
+
+// MenuTreeCategory is a category with its foods and subcategories, in display
+// order.
+type MenuTreeCategory struct {
+	models.Category
+	Categories []*MenuTreeCategory `json:"categories"`
+	Foods      []models.Food       `json:"foods"`
+}
+
+// MenuTree is a whole menu ready for display. Foods lists the foods that are
+// not in any category.
+type MenuTree struct {
+	Menu       models.Menu         `json:"menu"`
+	Categories []*MenuTreeCategory `json:"categories"`
+	Foods      []models.Food       `json:"foods"`
+}
+
+// GetCategories godoc
+//
+//	@Summary		List a menu's categories
+//	@Description	List every category of a menu in display order. Use the menu tree for the nested view.
+//	@Tags			Categories
+//	@Produce		json
+//	@Param			menu_id	path	string	true	"Menu ID"
+//	@Security		BearerAuth
+//	@Success		200	{array}		models.Category
+//	@Failure		404	{object}	map[string]interface{}
+//	@Failure		500	{object}	map[string]interface{}
+//	@Router			/menus/{menu_id}/categories [get]
+func GetCategories() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		menuID := ctx.Param("menu_id")
+
+		var menu models.Menu
+		if err := database.DB.Where("menu_id = ?", menuID).First(&menu).Error; err != nil {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "menu_id not found"})
+			return
+		}
+
+		var categories []models.Category
+		if err := database.DB.Where("menu_id = ?", menuID).Order("sort_order, name").Find(&categories).Error; err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		ctx.JSON(http.StatusOK, categories)
+	}
+}
+
+// CreateCategory godoc
+//
+//	@Summary		Create a category (Admin only)
+//	@Description	Add a category to a menu, optionally nested under another category of the same menu
+//	@Tags			Categories
+//	@Accept			json
+//	@Produce		json
+//	@Param			menu_id		path	string			true	"Menu ID"
+//	@Param			category	body	models.Category	true	"Category"
+//	@Security		BearerAuth
+//	@Success		201	{object}	map[string]interface{}
+//	@Failure		400	{object}	map[string]interface{}
+//	@Failure		404	{object}	map[string]interface{}
+//	@Failure		500	{object}	map[string]interface{}
+//	@Router			/menus/{menu_id}/categories [post]
+func CreateCategory() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		menuID := ctx.Param("menu_id")
+
+		var category models.Category
+		if err := ctx.BindJSON(&category); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		category.Menu_id = &menuID
+
+		if err := helpers.Validate.Struct(category); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		var menu models.Menu
+		if err := database.DB.Where("menu_id = ?", menuID).First(&menu).Error; err != nil {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "menu_id not found"})
+			return
+		}
+
+		if category.Parent_id != nil && *category.Parent_id == "" {
+			category.Parent_id = nil
+		}
+		if category.Parent_id != nil {
+			if err := checkMenuCategory(database.DB, *category.Parent_id, menuID); err != nil {
+				ctx.JSON(http.StatusBadRequest, gin.H{"error": "parent_id: " + err.Error()})
+				return
+			}
+		}
+
+		category.Category_id = uuid.New().String()
+
+		if err := database.DB.Create(&category).Error; err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		ctx.JSON(http.StatusCreated, gin.H{
+			"message":     "category created",
+			"category_id": category.Category_id,
+		})
+	}
+}
+
+// UpdateCategory godoc
+//
+//	@Summary		Update a category (Admin only)
+//	@Description	Rename, reorder or move a category. An empty parent_id moves it to the top level; a category cannot be moved under itself or its own subcategories.
+//	@Tags			Categories
+//	@Accept			json
+//	@Produce		json
+//	@Param			category_id	path	string			true	"Category ID"
+//	@Param			category	body	CategoryUpdate	true	"Changes"
+//	@Security		BearerAuth
+//	@Success		200	{object}	models.Category
+//	@Failure		400	{object}	map[string]interface{}
+//	@Failure		404	{object}	map[string]interface{}
+//	@Failure		500	{object}	map[string]interface{}
+//	@Router			/categories/{category_id} [patch]
+func UpdateCategory() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		categoryID := ctx.Param("category_id")
+
+		var category models.Category
+		if err := database.DB.Where("category_id = ?", categoryID).First(&category).Error; err != nil {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "category_id not found"})
+			return
+		}
+
+		var request CategoryUpdate
+		if err := ctx.BindJSON(&request); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		if err := helpers.Validate.Struct(request); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		updates := map[string]interface{}{}
+		if request.Name != nil {
+			updates["name"] = *request.Name
+		}
+		if request.Description != nil {
+			updates["description"] = *request.Description
+		}
+		if request.Sort_order != nil {
+			updates["sort_order"] = *request.Sort_order
+		}
+		if request.Parent_id != nil {
+			if *request.Parent_id == "" {
+				updates["parent_id"] = nil
+			} else {
+				if err := checkCategoryParent(database.DB, category, *request.Parent_id); err != nil {
+					ctx.JSON(http.StatusBadRequest, gin.H{"error": "parent_id: " + err.Error()})
+					return
+				}
+				updates["parent_id"] = *request.Parent_id
+			}
+		}
+
+		if len(updates) > 0 {
+			if err := database.DB.Model(&category).Updates(updates).Error; err != nil {
+				ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+		}
+
+		if err := database.DB.Where("category_id = ?", categoryID).First(&category).Error; err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		ctx.JSON(http.StatusOK, category)
+	}
+}
+
+// DeleteCategory godoc
+//
+//	@Summary		Delete a category (Admin only)
+//	@Description	Delete an empty category. Move or delete its foods and subcategories first.
+//	@Tags			Categories
+//	@Produce		json
+//	@Param			category_id	path	string	true	"Category ID"
+//	@Security		BearerAuth
+//	@Success		200	{object}	map[string]interface{}
+//	@Failure		404	{object}	map[string]interface{}
+//	@Failure		409	{object}	map[string]interface{}
+//	@Failure		500	{object}	map[string]interface{}
+//	@Router			/categories/{category_id} [delete]
+func DeleteCategory() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		categoryID := ctx.Param("category_id")
+
+		var category models.Category
+		if err := database.DB.Where("category_id = ?", categoryID).First(&category).Error; err != nil {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "category_id not found"})
+			return
+		}
+
+		var children, foods int64
+		if err := database.DB.Model(&models.Category{}).Where("parent_id = ?", categoryID).Count(&children).Error; err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if err := database.DB.Model(&models.Food{}).Where("category_id = ?", categoryID).Count(&foods).Error; err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if children > 0 || foods > 0 {
+			ctx.JSON(http.StatusConflict, gin.H{
+				"error":         "category is not empty",
+				"subcategories": children,
+				"foods":         foods,
+			})
+			return
+		}
+
+		if err := database.DB.Delete(&category).Error; err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{"message": "category deleted"})
+	}
+}
+
+// SetMenuOrder godoc
+//
+//	@Summary		Reorder a menu (Admin only)
+//	@Description	Set the display order of categories and foods in a menu in one step, as after a drag-and-drop edit. Foods can be moved between categories at the same time.
+//	@Tags			Menus
+//	@Accept			json
+//	@Produce		json
+//	@Param			menu_id	path	string				true	"Menu ID"
+//	@Param			order	body	MenuOrderRequest	true	"New positions"
+//	@Security		BearerAuth
+//	@Success		200	{object}	map[string]interface{}
+//	@Failure		400	{object}	map[string]interface{}
+//	@Failure		404	{object}	map[string]interface{}
+//	@Failure		500	{object}	map[string]interface{}
+//	@Router			/menus/{menu_id}/order [put]
+func SetMenuOrder() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		menuID := ctx.Param("menu_id")
+
+		var request MenuOrderRequest
+		if err := ctx.BindJSON(&request); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		if err := helpers.Validate.Struct(request); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		var menu models.Menu
+		if err := database.DB.Where("menu_id = ?", menuID).First(&menu).Error; err != nil {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "menu_id not found"})
+			return
+		}
+
+		var categoryIDs, foodIDs []string
+		if err := database.DB.Model(&models.Category{}).Where("menu_id = ?", menuID).Pluck("category_id", &categoryIDs).Error; err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if err := database.DB.Model(&models.Food{}).Where("menu_id = ?", menuID).Pluck("food_id", &foodIDs).Error; err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		inMenu := make(map[string]bool, len(categoryIDs)+len(foodIDs))
+		for _, id := range categoryIDs {
+			inMenu["category:"+id] = true
+		}
+		for _, id := range foodIDs {
+			inMenu["food:"+id] = true
+		}
+
+		for _, position := range request.Categories {
+			if !inMenu["category:"+position.Id] {
+				ctx.JSON(http.StatusBadRequest, gin.H{"error": "category " + position.Id + " is not in this menu"})
+				return
+			}
+		}
+		for _, position := range request.Foods {
+			if !inMenu["food:"+position.Id] {
+				ctx.JSON(http.StatusBadRequest, gin.H{"error": "food " + position.Id + " is not in this menu"})
+				return
+			}
+			if position.Category_id != nil && *position.Category_id != "" && !inMenu["category:"+*position.Category_id] {
+				ctx.JSON(http.StatusBadRequest, gin.H{"error": "food " + position.Id + ": category " + *position.Category_id + " is not in this menu"})
+				return
+			}
+		}
+
+		err := database.DB.Transaction(func(tx *gorm.DB) error {
+			for _, position := range request.Categories {
+				err := tx.Model(&models.Category{}).Where("category_id = ?", position.Id).
+					Update("sort_order", position.Sort_order).Error
+				if err != nil {
+					return err
+				}
+			}
+
+			for _, position := range request.Foods {
+				updates := map[string]interface{}{"sort_order": position.Sort_order}
+				if position.Category_id != nil {
+					if *position.Category_id == "" {
+						updates["category_id"] = nil
+					} else {
+						updates["category_id"] = *position.Category_id
+					}
+				}
+				if err := tx.Model(&models.Food{}).Where("food_id = ?", position.Id).Updates(updates).Error; err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{"message": "menu order updated"})
+	}
+}
+
+// GetMenuTree godoc
+//
+//	@Summary		Get a menu as a nested tree
+//	@Description	Return the menu with its categories nested and every category's foods, all in display order. Foods outside any category are listed at the top level.
+//	@Tags			Menus
+//	@Produce		json
+//	@Param			menu_id	path	string	true	"Menu ID"
+//	@Security		BearerAuth
+//	@Success		200	{object}	MenuTree
+//	@Failure		404	{object}	map[string]interface{}
+//	@Failure		500	{object}	map[string]interface{}
+//	@Router			/menus/{menu_id}/tree [get]
+func GetMenuTree() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		menuID := ctx.Param("menu_id")
+
+		var tree MenuTree
+		if err := database.DB.Where("menu_id = ?", menuID).First(&tree.Menu).Error; err != nil {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "menu_id not found"})
+			return
+		}
+
+		var categories []models.Category
+		if err := database.DB.Where("menu_id = ?", menuID).Order("sort_order, name").Find(&categories).Error; err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		var foods []models.Food
+		err := database.DB.Preload("Prices").Preload("Images").Scopes(preloadSlots).Where("menu_id = ?", menuID).
+			Order("sort_order, name").Find(&foods).Error
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		tree.Categories, tree.Foods = menuTree(categories, foods)
+
+		ctx.JSON(http.StatusOK, tree)
+	}
+}
+
+// menuTree nests categories and foods, keeping the order they were loaded
+// in. Categories whose parent no longer exists, or whose parents loop back
+// to them and never reach the top level, are shown at the top level, and
+// foods whose category no longer exists are shown as uncategorized.
+func menuTree(categories []models.Category, foods []models.Food) ([]*MenuTreeCategory, []models.Food) {
+	nodes := make(map[string]*MenuTreeCategory, len(categories))
+	for _, category := range categories {
+		nodes[category.Category_id] = &MenuTreeCategory{
+			Category:   category,
+			Categories: []*MenuTreeCategory{},
+			Foods:      []models.Food{},
+		}
+	}
+
+	roots := []*MenuTreeCategory{}
+	for _, category := range categories {
+		node := nodes[category.Category_id]
+		if category.Parent_id != nil {
+			if parent, ok := nodes[*category.Parent_id]; ok && !inParentCycle(nodes, category) {
+				parent.Categories = append(parent.Categories, node)
+				continue
+			}
+		}
+		roots = append(roots, node)
+	}
+
+	uncategorized := []models.Food{}
+	for _, food := range foods {
+		if food.Category_id != nil {
+			if node, ok := nodes[*food.Category_id]; ok {
+				node.Foods = append(node.Foods, food)
+				continue
+			}
+		}
+		uncategorized = append(uncategorized, food)
+	}
+
+	return roots, uncategorized
+}
+
+// inParentCycle reports whether following a category's parents leads back to
+// the category itself.
+func inParentCycle(nodes map[string]*MenuTreeCategory, category models.Category) bool {
+	parentID := category.Parent_id
+	for steps := 0; parentID != nil && steps < len(nodes); steps++ {
+		if *parentID == category.Category_id {
+			return true
+		}
+		parent, ok := nodes[*parentID]
+		if !ok {
+			return false
+		}
+		parentID = parent.Category.Parent_id
+	}
+	return false
+}
+
+// checkMenuCategory reports whether a category exists in a menu.
+func checkMenuCategory(db *gorm.DB, categoryID, menuID string) error {
+	var category models.Category
+	err := db.Where("category_id = ?", categoryID).First(&category).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return errors.New("category not found")
+	}
+	if err != nil {
+		return err
+	}
+	if category.Menu_id == nil || *category.Menu_id != menuID {
+		return errors.New("category belongs to another menu")
+	}
+	return nil
+}
+
+// checkCategoryParent reports whether a category can be moved under parentID:
+// the parent must be in the same menu and must not be the category itself or
+// one of its subcategories.
+func checkCategoryParent(db *gorm.DB, category models.Category, parentID string) error {
+	if err := checkMenuCategory(db, parentID, *category.Menu_id); err != nil {
+		return err
+	}
+
+	var categories []models.Category
+	if err := db.Where("menu_id = ?", *category.Menu_id).Find(&categories).Error; err != nil {
+		return err
+	}
+	parents := make(map[string]*string, len(categories))
+	for _, c := range categories {
+		parents[c.Category_id] = c.Parent_id
+	}
+
+	// Walk up from the new parent; reaching the category means a cycle. The
+	// step limit stops the walk on data that already contains a cycle.
+	current := &parentID
+	for steps := 0; current != nil && steps <= len(categories); steps++ {
+		if *current == category.Category_id {
+			return errors.New("category cannot be nested under itself")
+		}
+		current = parents[*current]
+	}
+	return nil
+}
+
+// foodCategoryError checks that a food's category, if it has one, is in the
+// food's menu.
+func foodCategoryError(db *gorm.DB, categoryID *string, menuID string) error {
+	if categoryID == nil || *categoryID == "" {
+		return nil
+	}
+	if err := checkMenuCategory(db, *categoryID, menuID); err != nil {
+		return errors.New("category_id: " + err.Error())
+	}
+	return nil
+}
@@ -0,0 +1,142 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Hdeee1/go-restaurant-management/database"
+	"github.com/Hdeee1/go-restaurant-management/helpers"
+	"github.com/Hdeee1/go-restaurant-management/models"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// errNoExchangeRate is returned when no rate converts between two currencies.
+var errNoExchangeRate = errors.New("no exchange rate")
+
+// GetExchangeRates godoc
+//
+//	@Summary		Get exchange rates
+//	@Description	Retrieve exchange rates, newest first, optionally for one currency pair
+//	@Tags			Exchange Rates
+//	@Accept			json
+//	@Produce		json
+//	@Param			base	query	string	false	"Base currency"
+//	@Param			quote	query	string	false	"Quote currency"
+//	@Param			page	query	int		false	"Page number"		default(1)
+//	@Param			limit	query	int		false	"Items per page"	default(10)
+//	@Security		BearerAuth
+//	@Success		200	{object}	map[string]interface{}
+//	@Failure		500	{object}	map[string]interface{}
+//	@Router			/exchange-rates [get]
+func GetExchangeRates() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var rates []models.ExchangeRate
+
+		query := database.DB.Scopes(helpers.Paginate(ctx)).Order("effective_from DESC")
+
+		if base := ctx.Query("base"); base != "" {
+			query = query.Where("base_currency = ?", strings.ToUpper(base))
+		}
+
+		if quote := ctx.Query("quote"); quote != "" {
+			query = query.Where("quote_currency = ?", strings.ToUpper(quote))
+		}
+
+		if err := query.Find(&rates).Error; err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{
+			"exchange_rates": rates,
+			"page":           ctx.DefaultQuery("page", "1"),
+			"limit":          ctx.DefaultQuery("limit", "10"),
+		})
+	}
+}
+
+// CreateExchangeRate godoc
+//
+//	@Summary		Create an exchange rate (Admin only)
+//	@Description	Add a rate for a currency pair. It applies from effective_from until a newer rate for the pair takes effect.
+//	@Tags			Exchange Rates
+//	@Accept			json
+//	@Produce		json
+//	@Param			exchange_rate	body	models.ExchangeRate	true	"Exchange rate"
+//	@Security		BearerAuth
+//	@Success		201	{object}	map[string]interface{}
+//	@Failure		400	{object}	map[string]interface{}
+//	@Failure		500	{object}	map[string]interface{}
+//	@Router			/exchange-rates [post]
+func CreateExchangeRate() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var rate models.ExchangeRate
+
+		if err := ctx.BindJSON(&rate); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		if err := helpers.Validate.Struct(rate); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		rate.Exchange_rate_id = uuid.New().String()
+
+		if err := database.DB.Create(&rate).Error; err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		ctx.JSON(http.StatusCreated, gin.H{
+			"message":          "exchange rate created",
+			"exchange_rate_id": rate.Exchange_rate_id,
+		})
+	}
+}
+
+// exchangeRate finds the rate in effect at a time for converting from one
+// currency to another, using the inverse of the opposite pair if needed.
+func exchangeRate(db *gorm.DB, from string, to string, at time.Time) (float64, error) {
+	if from == to {
+		return 1, nil
+	}
+
+	var rate models.ExchangeRate
+
+	err := db.Where("base_currency = ? AND quote_currency = ? AND effective_from <= ?", from, to, at).
+		Order("effective_from DESC").First(&rate).Error
+	if err == nil {
+		return *rate.Rate, nil
+	}
+	if err != gorm.ErrRecordNotFound {
+		return 0, err
+	}
+
+	err = db.Where("base_currency = ? AND quote_currency = ? AND effective_from <= ?", to, from, at).
+		Order("effective_from DESC").First(&rate).Error
+	if err == nil {
+		return 1 / *rate.Rate, nil
+	}
+	if err != gorm.ErrRecordNotFound {
+		return 0, err
+	}
+
+	return 0, fmt.Errorf("%w from %s to %s", errNoExchangeRate, from, to)
+}
+
+// convertMoney converts an amount at the rate in effect at a time and returns the rate used.
+func convertMoney(db *gorm.DB, amount models.Money, currency string, at time.Time) (models.Money, float64, error) {
+	rate, err := exchangeRate(db, amount.Currency, currency, at)
+	if err != nil {
+		return models.Money{}, 0, err
+	}
+
+	return amount.Convert(rate, currency), rate, nil
+}
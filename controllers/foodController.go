@@ -1,13 +1,18 @@
 package controllers
 
 import (
+	"errors"
+	"io"
+	"log"
 	"net/http"
+	"strings"
 
 	"github.com/Hdeee1/go-restaurant-management/database"
 	"github.com/Hdeee1/go-restaurant-management/helpers"
 	"github.com/Hdeee1/go-restaurant-management/models"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
 // GetFoods godoc
@@ -17,8 +22,9 @@ import (
 //	@Tags			Foods
 //	@Accept			json
 //	@Produce		json
-//	@Param			page	query		int	false	"Page number"
-//	@Param			limit	query		int	false	"Limit"
+//	@Param			page	query		int		false	"Page number"
+//	@Param			limit	query		int		false	"Limit"
+//	@Param			format	query		string	false	"json, csv or xlsx; csv and xlsx export every food"
 //	@Success		200		{object}	map[string]interface{}
 //	@Failure		500		{object}	map[string]interface{}
 //	@Failure		401		{object}	map[string]interface{}
@@ -28,7 +34,29 @@ func GetFoods() gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		var foods []models.Food
 
-		result := database.DB.Scopes(helpers.Paginate(ctx)).Find(&foods)
+		format, err := helpers.ExportFormat(ctx)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		if format != "" {
+			header := []string{"food_id", "name", "menu_id", "price", "currency", "station", "tax_category_id", "food_image"}
+			err := helpers.ExportQuery(ctx, format, "foods", header, database.DB, func(food models.Food) ([]interface{}, error) {
+				var currency string
+				if food.Price != nil {
+					currency = food.Price.Currency
+				}
+				return []interface{}{food.Food_id, food.Name, food.Menu_id, food.Price, currency, food.Station,
+					food.Tax_category_id, food.Food_image}, nil
+			})
+			if err != nil {
+				helpers.ExportFailed(ctx, err)
+			}
+			return
+		}
+
+		result := database.DB.Scopes(helpers.Paginate(ctx)).Preload("Prices").Preload("Images").Find(&foods)
 		if result.Error != nil {
 			ctx.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
 			return
@@ -60,7 +88,7 @@ func GetFood() gin.HandlerFunc {
 
 		var food models.Food
 
-		if err := database.DB.Where("food_id = ?", foodID).First(&food).Error; err != nil {
+		if err := database.DB.Preload("Prices").Preload("Images").Scopes(preloadSlots).Where("food_id = ?", foodID).First(&food).Error; err != nil {
 			ctx.JSON(http.StatusNotFound, gin.H{"error": "food_id not found"})
 			return
 		}
@@ -72,7 +100,7 @@ func GetFood() gin.HandlerFunc {
 // AddFood godoc
 //
 //	@Summary		Add a new food
-//	@Description	Add a new food
+//	@Description	Add a new food. Its price is in the default currency; prices in other currencies are set with PUT /foods/{food_id}/prices.
 //	@Tags			Foods
 //	@Accept			json
 //	@Produce		json
@@ -96,7 +124,14 @@ func AddFood() gin.HandlerFunc {
 			return
 		}
 
+		if err := basePriceError(*food.Price); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
 		food.Food_id = uuid.New().String()
+		// A bundle's slots are set once it exists.
+		food.Slots = nil
 
 		var menu models.Menu
 		err := database.DB.Where("menu_id = ?", *food.Menu_id).First(&menu).Error
@@ -105,7 +140,21 @@ func AddFood() gin.HandlerFunc {
 			return
 		}
 
-		if err := database.DB.Create(&food).Error; err != nil {
+		if food.Category_id != nil && *food.Category_id == "" {
+			food.Category_id = nil
+		}
+		if err := foodCategoryError(database.DB, food.Category_id, *food.Menu_id); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		err = database.DB.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Create(&food).Error; err != nil {
+				return err
+			}
+			return recordPriceChange(tx, food, true, food.Price.Currency, nil, food.Price, ctx.GetString("user_id"))
+		})
+		if err != nil {
 			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
@@ -120,7 +169,7 @@ func AddFood() gin.HandlerFunc {
 // UpdateFood godoc
 //
 //	@Summary		Update a food
-//	@Description	Update a food
+//	@Description	Update a food. Its price cannot be negative and is in the default currency. The food_type of a bundle with slots, or of a food offered in a bundle slot, cannot change.
 //	@Tags			Foods
 //	@Accept			json
 //	@Produce		json
@@ -129,6 +178,7 @@ func AddFood() gin.HandlerFunc {
 //	@Security		BearerAuth
 //	@Success		200		{object}	models.Food
 //	@Failure		400		{object}	map[string]interface{}
+//	@Failure		409		{object}	map[string]interface{}
 //	@Failure		500		{object}	map[string]interface{}
 //	@Router			/foods/{food_id} [put]
 func UpdateFood() gin.HandlerFunc {
@@ -148,7 +198,69 @@ func UpdateFood() gin.HandlerFunc {
 			return
 		}
 
-		if err := database.DB.Model(&food).Updates(updateData).Error; err != nil {
+		if updateData.Price != nil {
+			if err := basePriceError(*updateData.Price); err != nil {
+				ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+		}
+
+		if updateData.Food_type != "" && updateData.Food_type != food.Food_type {
+			if updateData.Food_type != models.FoodItem && updateData.Food_type != models.FoodBundle {
+				ctx.JSON(http.StatusBadRequest, gin.H{"error": "food_type must be ITEM or BUNDLE"})
+				return
+			}
+			if err := foodTypeLockedError(database.DB, food.Food_id); err != nil {
+				ctx.JSON(orderErrorStatus(err), gin.H{"error": err.Error()})
+				return
+			}
+		}
+
+		// Currency prices, images and bundle slots are managed through their
+		// own endpoints.
+		updateData.Prices = nil
+		updateData.Images = nil
+		updateData.Slots = nil
+
+		// An empty category_id takes the food out of its category, as does
+		// moving it to another menu without naming a category there.
+		clearCategory := false
+		if updateData.Category_id != nil && *updateData.Category_id == "" {
+			updateData.Category_id = nil
+			clearCategory = true
+		} else if updateData.Category_id == nil && updateData.Menu_id != nil && food.Menu_id != nil && *updateData.Menu_id != *food.Menu_id {
+			clearCategory = true
+		}
+		if updateData.Category_id != nil {
+			menuID := food.Menu_id
+			if updateData.Menu_id != nil {
+				menuID = updateData.Menu_id
+			}
+			if err := foodCategoryError(database.DB, updateData.Category_id, *menuID); err != nil {
+				ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+		}
+
+		// A new base price is recorded in the food's price history.
+		oldPrice := food.Price
+		priceChanged := updateData.Price != nil && (oldPrice == nil || *oldPrice != *updateData.Price)
+
+		err := database.DB.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Model(&food).Updates(updateData).Error; err != nil {
+				return err
+			}
+			if clearCategory {
+				if err := tx.Model(&food).Update("category_id", nil).Error; err != nil {
+					return err
+				}
+			}
+			if !priceChanged {
+				return nil
+			}
+			return recordPriceChange(tx, food, true, updateData.Price.Currency, oldPrice, updateData.Price, ctx.GetString("user_id"))
+		})
+		if err != nil {
 			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
@@ -159,3 +271,338 @@ func UpdateFood() gin.HandlerFunc {
 		})
 	}
 }
+
+// SetFoodPrice godoc
+//
+//	@Summary		Set a food's price in a currency (Admin only)
+//	@Description	Create or replace the food's price in the currency of the given price
+//	@Tags			Foods
+//	@Accept			json
+//	@Produce		json
+//	@Param			food_id	path	string				true	"Food ID"
+//	@Param			price	body	models.FoodPrice	true	"Price"
+//	@Security		BearerAuth
+//	@Success		200	{object}	map[string]interface{}
+//	@Failure		400	{object}	map[string]interface{}
+//	@Failure		404	{object}	map[string]interface{}
+//	@Failure		500	{object}	map[string]interface{}
+//	@Router			/foods/{food_id}/prices [put]
+func SetFoodPrice() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		foodID := ctx.Param("food_id")
+
+		var price models.FoodPrice
+
+		if err := ctx.BindJSON(&price); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		if err := helpers.Validate.Struct(price); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		if price.Price.IsNegative() {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "price must not be negative"})
+			return
+		}
+
+		var food models.Food
+		if err := database.DB.Where("food_id = ?", foodID).First(&food).Error; err != nil {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "food_id not found"})
+			return
+		}
+
+		err := database.DB.Transaction(func(tx *gorm.DB) error {
+			old, err := helpers.SetFoodPrice(tx, food, false, price.Price.Currency, price.Price)
+			if err != nil {
+				return err
+			}
+			if old != nil && *old == *price.Price {
+				return nil
+			}
+			return recordPriceChange(tx, food, false, price.Price.Currency, old, price.Price, ctx.GetString("user_id"))
+		})
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{
+			"message":  "price set",
+			"food_id":  foodID,
+			"currency": price.Price.Currency,
+		})
+	}
+}
+
+// DeleteFoodPrice godoc
+//
+//	@Summary		Remove a food's price in a currency (Admin only)
+//	@Description	Remove a currency price; invoices in that currency then convert the base price
+//	@Tags			Foods
+//	@Accept			json
+//	@Produce		json
+//	@Param			food_id		path	string	true	"Food ID"
+//	@Param			currency	path	string	true	"Currency code"
+//	@Security		BearerAuth
+//	@Success		200	{object}	map[string]interface{}
+//	@Failure		404	{object}	map[string]interface{}
+//	@Failure		500	{object}	map[string]interface{}
+//	@Router			/foods/{food_id}/prices/{currency} [delete]
+func DeleteFoodPrice() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		foodID := ctx.Param("food_id")
+		currency := strings.ToUpper(ctx.Param("currency"))
+
+		var food models.Food
+		if err := database.DB.Where("food_id = ?", foodID).First(&food).Error; err != nil {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "food_id not found"})
+			return
+		}
+
+		var old *models.Money
+		err := database.DB.Transaction(func(tx *gorm.DB) error {
+			var err error
+			old, err = helpers.SetFoodPrice(tx, food, false, currency, nil)
+			if err != nil || old == nil {
+				return err
+			}
+			return recordPriceChange(tx, food, false, currency, old, nil, ctx.GetString("user_id"))
+		})
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		if old == nil {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "price not found"})
+			return
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{
+			"message":  "price removed",
+			"food_id":  foodID,
+			"currency": currency,
+		})
+	}
+}
+
+// UploadFoodImage godoc
+//
+//	@Summary		Upload a food's image (Admin only)
+//	@Description	Upload a JPEG, PNG, GIF or WebP image as the multipart field "image". The type is checked from the content, not the file name. The image is resized to a thumbnail, medium and large size, and the large one's URL becomes the food's food_image. Any previous image is removed.
+//	@Tags			Foods
+//	@Accept			mpfd
+//	@Produce		json
+//	@Param			food_id	path		string	true	"Food ID"
+//	@Param			image	formData	file	true	"Image file"
+//	@Security		BearerAuth
+//	@Success		200	{object}	map[string]interface{}
+//	@Failure		400	{object}	map[string]interface{}
+//	@Failure		404	{object}	map[string]interface{}
+//	@Failure		413	{object}	map[string]interface{}
+//	@Failure		415	{object}	map[string]interface{}
+//	@Failure		500	{object}	map[string]interface{}
+//	@Router			/foods/{food_id}/image [put]
+func UploadFoodImage() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		foodID := ctx.Param("food_id")
+
+		var food models.Food
+		if err := database.DB.Where("food_id = ?", foodID).First(&food).Error; err != nil {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "food_id not found"})
+			return
+		}
+
+		ctx.Request.Body = http.MaxBytesReader(ctx.Writer, ctx.Request.Body, maxImageSize)
+
+		header, err := ctx.FormFile("image")
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			ctx.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "image must be at most 10 MB"})
+			return
+		}
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "image is required"})
+			return
+		}
+
+		file, err := header.Open()
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		data, err := io.ReadAll(file)
+		file.Close()
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		if _, err := helpers.ImageContentType(data); err != nil {
+			ctx.JSON(http.StatusUnsupportedMediaType, gin.H{"error": err.Error()})
+			return
+		}
+
+		resized, err := helpers.ResizeImage(data)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		// Every upload gets new keys so cached copies of the old image never linger.
+		version := uuid.New().String()
+		images := make([]models.FoodImage, 0, len(resized))
+		for _, rendition := range resized {
+			key := "foods/" + foodID + "/" + version + "-" + rendition.Size + rendition.Extension
+
+			url, err := helpers.Images.Save(key, rendition.Content_type, rendition.Data)
+			if err != nil {
+				removeImages(images)
+				ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+
+			images = append(images, models.FoodImage{
+				Food_id:      foodID,
+				Size:         rendition.Size,
+				Url:          url,
+				Storage_key:  key,
+				Content_type: rendition.Content_type,
+				Width:        rendition.Width,
+				Height:       rendition.Height,
+			})
+		}
+
+		previous, err := replaceFoodImages(database.DB, food, images)
+		if err != nil {
+			removeImages(images)
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		removeImages(previous)
+
+		ctx.JSON(http.StatusOK, gin.H{
+			"message":    "image uploaded",
+			"food_id":    foodID,
+			"food_image": images[len(images)-1].Url,
+			"images":     images,
+		})
+	}
+}
+
+// DeleteFoodImage godoc
+//
+//	@Summary		Remove a food's image (Admin only)
+//	@Description	Remove the food's uploaded image in every size and clear its food_image
+//	@Tags			Foods
+//	@Accept			json
+//	@Produce		json
+//	@Param			food_id	path	string	true	"Food ID"
+//	@Security		BearerAuth
+//	@Success		200	{object}	map[string]interface{}
+//	@Failure		404	{object}	map[string]interface{}
+//	@Failure		500	{object}	map[string]interface{}
+//	@Router			/foods/{food_id}/image [delete]
+func DeleteFoodImage() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		foodID := ctx.Param("food_id")
+
+		var food models.Food
+		if err := database.DB.Where("food_id = ?", foodID).First(&food).Error; err != nil {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "food_id not found"})
+			return
+		}
+
+		previous, err := replaceFoodImages(database.DB, food, nil)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		removeImages(previous)
+
+		ctx.JSON(http.StatusOK, gin.H{
+			"message": "image removed",
+			"food_id": foodID,
+		})
+	}
+}
+
+// maxImageSize caps the size of an uploaded image.
+const maxImageSize = 10 << 20
+
+// replaceFoodImages swaps a food's image records for new ones and points its
+// food_image at the largest, returning the records it replaced.
+func replaceFoodImages(db *gorm.DB, food models.Food, images []models.FoodImage) ([]models.FoodImage, error) {
+	var previous []models.FoodImage
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("food_id = ?", food.Food_id).Find(&previous).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Unscoped().Where("food_id = ?", food.Food_id).Delete(&models.FoodImage{}).Error; err != nil {
+			return err
+		}
+
+		var url *string
+		if len(images) > 0 {
+			if err := tx.Create(&images).Error; err != nil {
+				return err
+			}
+			url = &images[len(images)-1].Url
+		}
+
+		return tx.Model(&food).Update("food_image", url).Error
+	})
+
+	return previous, err
+}
+
+// removeImages deletes stored images that are no longer referenced. Failures
+// only leave orphaned files behind, so they are logged rather than reported.
+func removeImages(images []models.FoodImage) {
+	for _, image := range images {
+		if err := helpers.Images.Delete(image.Storage_key); err != nil {
+			log.Printf("image %s: delete: %v", image.Storage_key, err)
+		}
+	}
+}
+
+// foodTypeLockedError stops a food from changing food_type while bundles
+// depend on it: a bundle's slots would be left on an item, and an item offered
+// in a slot would make that bundle nest another bundle.
+func foodTypeLockedError(db *gorm.DB, foodID string) error {
+	var slots int64
+	if err := db.Model(&models.BundleSlot{}).Where("bundle_id = ?", foodID).Count(&slots).Error; err != nil {
+		return err
+	}
+	if slots > 0 {
+		return &orderError{http.StatusConflict, "food_type cannot change while the bundle has slots"}
+	}
+
+	var options int64
+	if err := db.Model(&models.BundleOption{}).Where("food_id = ?", foodID).Count(&options).Error; err != nil {
+		return err
+	}
+	if options > 0 {
+		return &orderError{http.StatusConflict, "food_type cannot change while the food is offered in a bundle"}
+	}
+
+	return nil
+}
+
+// basePriceError checks a food's base price. Order lines copy it and invoice
+// totals add them up, so every base price is in the default currency; other
+// currencies are set as extra prices.
+func basePriceError(price models.Money) error {
+	if price.IsNegative() {
+		return errors.New("price must not be negative")
+	}
+	if currency := models.DefaultCurrency(); price.Currency != currency {
+		return errors.New("price must be in " + currency + "; set prices in other currencies with PUT /foods/{food_id}/prices")
+	}
+	return nil
+}
@@ -0,0 +1,336 @@
+package controllers
+
+import (
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/Hdeee1/go-restaurant-management/database"
+	"github.com/Hdeee1/go-restaurant-management/helpers"
+	"github.com/Hdeee1/go-restaurant-management/models"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type GuestOrderRequest struct {
+	Order_items []GuestOrderItem `json:"order_items" validate:"required,min=1,dive"`
+}
+
+// GuestOrderItem is what a guest picks for one item. The price comes from the
+// menu, so it cannot be sent.
+type GuestOrderItem struct {
+	Food_id    *string               `json:"food_id" validate:"required"`
+	Quantity   *string               `json:"quantity" validate:"required,eq=S|eq=M|eq=L"`
+	Course     *string               `json:"course" validate:"omitempty,eq=STARTER|eq=MAIN|eq=DESSERT"`
+	Components []models.BundleChoice `json:"components,omitempty" validate:"dive"`
+}
+
+// guestBaseURL is the guest ordering page.
+func guestBaseURL() string {
+	base := os.Getenv("GUEST_ORDER_URL")
+	if base == "" {
+		port := os.Getenv("PORT")
+		if port == "" {
+			port = "8080"
+		}
+		base = "http://localhost:" + port + "/guest/menus"
+	}
+
+	return base
+}
+
+// guestTableURL is the address printed in a table's QR code. It only names the
+// table, so a printed code stays valid from one party to the next; the
+// ordering page exchanges it for a guest token once the party is seated.
+func guestTableURL(tableID string) string {
+	return guestBaseURL() + "#table=" + tableID
+}
+
+// guestOrderURL is the ordering page with a guest token already issued. The
+// token rides in the fragment, which browsers never send to a server, and the
+// ordering page passes it on as a Bearer token.
+func guestOrderURL(token string) string {
+	return guestBaseURL() + "#token=" + token
+}
+
+// activeMenus limits a menu query to menus running right now.
+func activeMenus(db *gorm.DB) *gorm.DB {
+	now := time.Now()
+	return db.Where("(start_date IS NULL OR start_date <= ?) AND (end_date IS NULL OR end_date >= ?)", now, now)
+}
+
+// GetTableGuestToken godoc
+//
+//	@Summary		Get a table's guest ordering token (Admin only)
+//	@Description	Generate a signed guest token and ordering URL for a table, e.g. to hand a link to a seated party. The token expires after GUEST_TOKEN_HOURS and is revoked when the table is released.
+//	@Tags			Guests
+//	@Accept			json
+//	@Produce		json
+//	@Param			table_id	path	string	true	"Table ID"
+//	@Security		BearerAuth
+//	@Success		200	{object}	map[string]interface{}
+//	@Failure		404	{object}	map[string]interface{}
+//	@Failure		500	{object}	map[string]interface{}
+//	@Router			/tables/{table_id}/guest-token [get]
+func GetTableGuestToken() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		tableID := ctx.Param("table_id")
+
+		var table models.Table
+
+		if err := database.DB.Where("table_id = ?", tableID).First(&table).Error; err != nil {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "table_id not found"})
+			return
+		}
+
+		token, err := helpers.GenerateGuestToken(table.Table_id, table.Guest_token_version)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
+			return
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{
+			"table_id": table.Table_id,
+			"token":    token,
+			"url":      guestOrderURL(token),
+		})
+	}
+}
+
+// GetTableQRCode godoc
+//
+//	@Summary		Get a table's QR code (Admin only)
+//	@Description	Render the QR code guests scan to order at a table, as PNG or SVG. The code holds a stable link to the table, so it only needs printing once; guests get a token from POST /guest/tables/{table_id}/session when they scan it.
+//	@Tags			Guests
+//	@Produce		png
+//	@Produce		image/svg+xml
+//	@Param			table_id	path	string	true	"Table ID"
+//	@Param			format		query	string	false	"png or svg"		default(png)
+//	@Param			size		query	int		false	"Size in pixels"	default(256)
+//	@Security		BearerAuth
+//	@Success		200	{file}		binary
+//	@Failure		400	{object}	map[string]interface{}
+//	@Failure		404	{object}	map[string]interface{}
+//	@Failure		500	{object}	map[string]interface{}
+//	@Router			/tables/{table_id}/qr [get]
+func GetTableQRCode() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		tableID := ctx.Param("table_id")
+
+		size, _ := strconv.Atoi(ctx.DefaultQuery("size", "256"))
+		if size < 64 || size > 2048 {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "size must be between 64 and 2048"})
+			return
+		}
+
+		format := ctx.DefaultQuery("format", "png")
+		if format != "png" && format != "svg" {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "format must be png or svg"})
+			return
+		}
+
+		var table models.Table
+
+		if err := database.DB.Where("table_id = ?", tableID).First(&table).Error; err != nil {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "table_id not found"})
+			return
+		}
+
+		url := guestTableURL(table.Table_id)
+
+		if format == "svg" {
+			image, err := helpers.QRCodeSVG(url, size)
+			if err != nil {
+				ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+			ctx.Data(http.StatusOK, "image/svg+xml", image)
+			return
+		}
+
+		image, err := helpers.QRCodePNG(url, size)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		ctx.Data(http.StatusOK, "image/png", image)
+	}
+}
+
+// CreateGuestSession godoc
+//
+//	@Summary		Start ordering at a table (guest)
+//	@Description	Exchange a scanned table QR code for a guest token. A token is only issued while a party is seated at the table, and is revoked when the table is released.
+//	@Tags			Guests
+//	@Accept			json
+//	@Produce		json
+//	@Param			table_id	path	string	true	"Table ID"
+//	@Success		201	{object}	map[string]interface{}
+//	@Failure		404	{object}	map[string]interface{}
+//	@Failure		409	{object}	map[string]interface{}
+//	@Failure		500	{object}	map[string]interface{}
+//	@Router			/guest/tables/{table_id}/session [post]
+func CreateGuestSession() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		tableID := ctx.Param("table_id")
+
+		var table models.Table
+
+		if err := database.DB.Where("table_id = ?", tableID).First(&table).Error; err != nil {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "table_id not found"})
+			return
+		}
+
+		switch table.Status {
+		case models.TableSeated, models.TableOrdering, models.TableAwaitingPayment:
+		case models.TableMerged:
+			ctx.JSON(http.StatusConflict, gin.H{"error": "please ask staff to take your order"})
+			return
+		default:
+			ctx.JSON(http.StatusConflict, gin.H{"error": "please wait for staff to seat you"})
+			return
+		}
+
+		token, err := helpers.GenerateGuestToken(table.Table_id, table.Guest_token_version)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
+			return
+		}
+
+		ctx.JSON(http.StatusCreated, gin.H{
+			"table_id": table.Table_id,
+			"token":    token,
+		})
+	}
+}
+
+// GetGuestMenus godoc
+//
+//	@Summary		Get active menus (guest)
+//	@Description	Retrieve the menus currently running. Requires a table's guest token.
+//	@Tags			Guests
+//	@Accept			json
+//	@Produce		json
+//	@Param			Authorization	header	string	true	"Bearer guest token"
+//	@Success		200	{object}	map[string]interface{}
+//	@Failure		401	{object}	map[string]interface{}
+//	@Failure		500	{object}	map[string]interface{}
+//	@Router			/guest/menus [get]
+func GetGuestMenus() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var menus []models.Menu
+
+		if err := activeMenus(database.DB).Find(&menus).Error; err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{
+			"menus":    menus,
+			"table_id": ctx.GetString("table_id"),
+		})
+	}
+}
+
+// GetGuestFoods godoc
+//
+//	@Summary		Get orderable foods (guest)
+//	@Description	Retrieve the foods on currently running menus, optionally for one menu. Requires a table's guest token.
+//	@Tags			Guests
+//	@Accept			json
+//	@Produce		json
+//	@Param			Authorization	header	string	true	"Bearer guest token"
+//	@Param			menu_id	query	string	false	"Menu ID"
+//	@Success		200	{object}	map[string]interface{}
+//	@Failure		401	{object}	map[string]interface{}
+//	@Failure		500	{object}	map[string]interface{}
+//	@Router			/guest/foods [get]
+func GetGuestFoods() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var foods []models.Food
+
+		menuIDs := activeMenus(database.DB.Model(&models.Menu{})).Select("menu_id")
+		if menuID := ctx.Query("menu_id"); menuID != "" {
+			menuIDs = menuIDs.Where("menu_id = ?", menuID)
+		}
+
+		if err := database.DB.Scopes(preloadSlots).Where("menu_id IN (?)", menuIDs).Find(&foods).Error; err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{"foods": foods})
+	}
+}
+
+// CreateGuestOrder godoc
+//
+//	@Summary		Place an order (guest)
+//	@Description	Place an order at the table the guest token was issued for. Only foods on running menus can be ordered, and the order waits for staff approval before it reaches the kitchen.
+//	@Tags			Guests
+//	@Accept			json
+//	@Produce		json
+//	@Param			Authorization	header	string				true	"Bearer guest token"
+//	@Param			order	body	GuestOrderRequest	true	"Order items"
+//	@Success		201	{object}	map[string]interface{}
+//	@Failure		400	{object}	map[string]interface{}
+//	@Failure		401	{object}	map[string]interface{}
+//	@Failure		404	{object}	map[string]interface{}
+//	@Failure		409	{object}	map[string]interface{}
+//	@Failure		500	{object}	map[string]interface{}
+//	@Router			/guest/orders [post]
+func CreateGuestOrder() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var req GuestOrderRequest
+
+		if err := ctx.BindJSON(&req); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		if err := helpers.Validate.Struct(req); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		var table models.Table
+		if err := database.DB.Where("table_id = ?", ctx.GetString("table_id")).First(&table).Error; err != nil {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "table_id not found"})
+			return
+		}
+
+		if table.Status == models.TableMerged {
+			ctx.JSON(http.StatusConflict, gin.H{"error": "please ask staff to take your order"})
+			return
+		}
+
+		menuIDs := activeMenus(database.DB.Model(&models.Menu{})).Select("menu_id")
+		items := make([]models.OrderItem, len(req.Order_items))
+		for i, item := range req.Order_items {
+			var food models.Food
+			if err := database.DB.Where("food_id = ? AND menu_id IN (?)", *item.Food_id, menuIDs).First(&food).Error; err != nil {
+				ctx.JSON(http.StatusNotFound, gin.H{"error": "food_id not found"})
+				return
+			}
+
+			items[i] = models.OrderItem{
+				Food_id:    item.Food_id,
+				Quantity:   item.Quantity,
+				Course:     item.Course,
+				Components: item.Components,
+			}
+		}
+
+		order, err := placeOrder(table, items, models.ServiceDineIn, models.OrderPendingApproval, models.OrderSourceGuest)
+		if err != nil {
+			ctx.JSON(orderErrorStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+
+		ctx.JSON(http.StatusCreated, gin.H{
+			"message":  "order sent, waiting for staff approval",
+			"order_id": order.Order_id,
+		})
+	}
+}
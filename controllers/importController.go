@@ -0,0 +1,490 @@
+package controllers
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Hdeee1/go-restaurant-management/database"
+	"github.com/Hdeee1/go-restaurant-management/helpers"
+	"github.com/Hdeee1/go-restaurant-management/models"
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// maxImportSize caps the size of an uploaded import.
+const maxImportSize = 10 << 20
+
+// MenuImportRow is a menu to create or update, identified by its external code.
+type MenuImportRow struct {
+	External_code string     `json:"external_code" validate:"required,max=64"`
+	Name          string     `json:"name" validate:"required"`
+	Category      string     `json:"category" validate:"required"`
+	Start_date    *time.Time `json:"start_date"`
+	End_date      *time.Time `json:"end_date"`
+
+	row string
+}
+
+// FoodImportRow is a food to create or update, identified by its external
+// code. Its menu is given by the menu's external code or its menu_id.
+type FoodImportRow struct {
+	External_code   string        `json:"external_code" validate:"required,max=64"`
+	Menu_code       string        `json:"menu_code" validate:"required_without=Menu_id"`
+	Menu_id         string        `json:"menu_id"`
+	Name            string        `json:"name"`
+	Price           *models.Money `json:"price"`
+	Food_image      string        `json:"food_image"`
+	Station         *string       `json:"station"`
+	Tax_category_id *string       `json:"tax_category_id"`
+
+	row string
+}
+
+type MenuImport struct {
+	Menus []MenuImportRow `json:"menus"`
+	Foods []FoodImportRow `json:"foods"`
+}
+
+// ImportError is a problem with one row of an import.
+type ImportError struct {
+	Row   string `json:"row"`
+	Field string `json:"field,omitempty"`
+	Error string `json:"error"`
+}
+
+type ImportCount struct {
+	Created int `json:"created"`
+	Updated int `json:"updated"`
+}
+
+// menuImportColumns are the columns of a CSV import. Every row is a food with
+// its menu; rows without a food_code only define a menu.
+var menuImportColumns = []string{
+	"menu_code", "menu_name", "menu_category", "menu_start_date", "menu_end_date",
+	"food_code", "food_name", "price", "currency", "food_image", "station", "tax_category_id",
+}
+
+// ImportMenus godoc
+//
+//	@Summary		Import menus and foods (Admin only)
+//	@Description	Create or update menus and foods in bulk from JSON ({"menus": [...], "foods": [...]}) or CSV with the columns menu_code, menu_name, menu_category, menu_start_date, menu_end_date, food_code, food_name, price, currency, food_image, station and tax_category_id. The file may be sent as the body or as the multipart field "file". Rows are matched on their external code. Every row is validated first; if any row fails nothing is saved. With dry_run=true the import is checked and counted but not saved.
+//	@Tags			Menus
+//	@Accept			json,text/csv,multipart/form-data
+//	@Produce		json
+//	@Param			dry_run	query		bool		false	"Validate and count without saving"
+//	@Param			import	body		MenuImport	false	"Menus and foods"
+//	@Param			file	formData	file		false	"CSV or JSON file"
+//	@Security		BearerAuth
+//	@Success		200	{object}	map[string]interface{}
+//	@Failure		400	{object}	map[string]interface{}
+//	@Failure		422	{object}	map[string]interface{}
+//	@Failure		500	{object}	map[string]interface{}
+//	@Router			/menus/import [post]
+func ImportMenus() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		dryRun := ctx.Query("dry_run") == "true"
+
+		ctx.Request.Body = http.MaxBytesReader(ctx.Writer, ctx.Request.Body, maxImportSize)
+
+		data, err := readImport(ctx)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		errs := validateImport(data)
+		if len(errs) > 0 {
+			ctx.JSON(http.StatusUnprocessableEntity, gin.H{"error": "import has invalid rows", "errors": errs})
+			return
+		}
+
+		tx := database.DB.Begin()
+
+		menus, foods, errs, err := applyImport(tx, data, ctx.GetString("user_id"))
+		if err != nil {
+			tx.Rollback()
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		if len(errs) > 0 {
+			tx.Rollback()
+			ctx.JSON(http.StatusUnprocessableEntity, gin.H{"error": "import has invalid rows", "errors": errs})
+			return
+		}
+
+		if dryRun {
+			tx.Rollback()
+		} else if err := tx.Commit().Error; err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		message := "import complete"
+		if dryRun {
+			message = "dry run: nothing was saved"
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{
+			"message": message,
+			"dry_run": dryRun,
+			"menus":   menus,
+			"foods":   foods,
+		})
+	}
+}
+
+// readImport reads an import from the body or an uploaded file, as CSV or JSON
+// depending on its content type or file extension.
+func readImport(ctx *gin.Context) (MenuImport, error) {
+	var data MenuImport
+
+	body := io.Reader(ctx.Request.Body)
+	contentType, _, _ := mime.ParseMediaType(ctx.GetHeader("Content-Type"))
+
+	if contentType == "multipart/form-data" {
+		header, err := ctx.FormFile("file")
+		if err != nil {
+			return data, errors.New("file is required")
+		}
+		file, err := header.Open()
+		if err != nil {
+			return data, err
+		}
+		defer file.Close()
+
+		body = file
+		contentType = header.Header.Get("Content-Type")
+		if strings.EqualFold(filepath.Ext(header.Filename), ".csv") {
+			contentType = "text/csv"
+		}
+	}
+
+	if contentType == "text/csv" || ctx.Query("format") == "csv" {
+		return readImportCSV(body)
+	}
+
+	decoder := json.NewDecoder(body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&data); err != nil {
+		return data, err
+	}
+
+	for i := range data.Menus {
+		data.Menus[i].row = fmt.Sprintf("menus[%d]", i)
+	}
+	for i := range data.Foods {
+		data.Foods[i].row = fmt.Sprintf("foods[%d]", i)
+	}
+
+	return data, nil
+}
+
+func readImportCSV(body io.Reader) (MenuImport, error) {
+	var data MenuImport
+
+	reader := csv.NewReader(body)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return data, errors.New("csv: missing header row")
+	}
+
+	columns := map[string]int{}
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for name := range columns {
+		if !slices.Contains(menuImportColumns, name) {
+			return data, fmt.Errorf("csv: unknown column %q", name)
+		}
+	}
+
+	menus := map[string]int{}
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return data, err
+		}
+
+		value := func(column string) string {
+			if i, ok := columns[column]; ok && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+		optional := func(column string) *string {
+			if v := value(column); v != "" {
+				return &v
+			}
+			return nil
+		}
+		row := fmt.Sprintf("line %d", line)
+
+		if value("menu_name") != "" || value("menu_category") != "" {
+			menu := MenuImportRow{
+				External_code: value("menu_code"),
+				Name:          value("menu_name"),
+				Category:      value("menu_category"),
+				row:           row,
+			}
+			for column, date := range map[string]**time.Time{"menu_start_date": &menu.Start_date, "menu_end_date": &menu.End_date} {
+				if v := value(column); v != "" {
+					t, _, err := parseReportTime(v)
+					if err != nil {
+						return data, fmt.Errorf("%s: invalid %s %q", row, column, v)
+					}
+					*date = &t
+				}
+			}
+
+			// A menu is usually repeated on every row of its foods.
+			if i, ok := menus[menu.External_code]; ok {
+				previous := data.Menus[i]
+				if previous.Name != menu.Name || previous.Category != menu.Category {
+					return data, fmt.Errorf("%s: menu %q differs from its definition on %s", row, menu.External_code, previous.row)
+				}
+			} else {
+				menus[menu.External_code] = len(data.Menus)
+				data.Menus = append(data.Menus, menu)
+			}
+		}
+
+		if value("food_code") == "" && value("food_name") == "" {
+			continue
+		}
+
+		food := FoodImportRow{
+			External_code:   value("food_code"),
+			Menu_code:       value("menu_code"),
+			Name:            value("food_name"),
+			Food_image:      value("food_image"),
+			Station:         optional("station"),
+			Tax_category_id: optional("tax_category_id"),
+			row:             row,
+		}
+		if v := value("price"); v != "" {
+			price, err := models.ParseMoney(v, value("currency"))
+			if err != nil {
+				return data, fmt.Errorf("%s: %v", row, err)
+			}
+			food.Price = &price
+		}
+		data.Foods = append(data.Foods, food)
+	}
+
+	return data, nil
+}
+
+// validateImport checks every row on its own and that no external code is used twice.
+func validateImport(data MenuImport) []ImportError {
+	errs := []ImportError{}
+
+	menuCodes := map[string]string{}
+	for _, menu := range data.Menus {
+		errs = append(errs, importValidationErrors(menu.row, menu)...)
+		if row, ok := menuCodes[menu.External_code]; ok && menu.External_code != "" {
+			errs = append(errs, ImportError{Row: menu.row, Field: "external_code", Error: "duplicate of " + row})
+		}
+		menuCodes[menu.External_code] = menu.row
+	}
+
+	foodCodes := map[string]string{}
+	for _, food := range data.Foods {
+		errs = append(errs, importValidationErrors(food.row, food)...)
+		if row, ok := foodCodes[food.External_code]; ok && food.External_code != "" {
+			errs = append(errs, ImportError{Row: food.row, Field: "external_code", Error: "duplicate of " + row})
+		}
+		foodCodes[food.External_code] = food.row
+	}
+
+	return errs
+}
+
+// applyImport upserts the menus and then the foods of an import on their
+// external codes. Rows that do not make valid records are reported rather
+// than saved. Price changes go into the foods' price history.
+func applyImport(tx *gorm.DB, data MenuImport, userID string) (ImportCount, ImportCount, []ImportError, error) {
+	var menuCount, foodCount ImportCount
+	errs := []ImportError{}
+
+	menuIDs := map[string]string{}
+	for _, row := range data.Menus {
+		code := row.External_code
+
+		var menu models.Menu
+		err := tx.Where("external_code = ?", code).First(&menu).Error
+		if err != nil && err != gorm.ErrRecordNotFound {
+			return menuCount, foodCount, errs, err
+		}
+		exists := err == nil
+		if !exists {
+			menu.Menu_id = uuid.New().String()
+			menu.External_code = &code
+		}
+
+		menu.Name = row.Name
+		menu.Category = row.Category
+		menu.Start_date = row.Start_date
+		menu.End_date = row.End_date
+
+		if rowErrs := importValidationErrors(row.row, menu); len(rowErrs) > 0 {
+			errs = append(errs, rowErrs...)
+			continue
+		}
+		if menu.Start_date != nil && menu.End_date != nil && menu.End_date.Before(*menu.Start_date) {
+			errs = append(errs, ImportError{Row: row.row, Field: "end_date", Error: "end_date must be after start_date"})
+			continue
+		}
+
+		if exists {
+			err = tx.Model(&menu).Select("name", "category", "start_date", "end_date").Updates(&menu).Error
+			menuCount.Updated++
+		} else {
+			err = tx.Create(&menu).Error
+			menuCount.Created++
+		}
+		if err != nil {
+			return menuCount, foodCount, errs, err
+		}
+
+		menuIDs[code] = menu.Menu_id
+	}
+
+	for _, row := range data.Foods {
+		code := row.External_code
+
+		menuID, err := importMenuID(tx, row, menuIDs)
+		if err != nil {
+			errs = append(errs, ImportError{Row: row.row, Field: "menu_code", Error: err.Error()})
+			continue
+		}
+
+		if row.Tax_category_id != nil {
+			var count int64
+			if err := tx.Model(&models.TaxCategory{}).Where("tax_category_id = ?", *row.Tax_category_id).Count(&count).Error; err != nil {
+				return menuCount, foodCount, errs, err
+			}
+			if count == 0 {
+				errs = append(errs, ImportError{Row: row.row, Field: "tax_category_id", Error: "tax_category_id not found"})
+				continue
+			}
+		}
+
+		var food models.Food
+		err = tx.Where("external_code = ?", code).First(&food).Error
+		if err != nil && err != gorm.ErrRecordNotFound {
+			return menuCount, foodCount, errs, err
+		}
+		exists := err == nil
+		if !exists {
+			food.Food_id = uuid.New().String()
+			food.External_code = &code
+		}
+
+		oldPrice := food.Price
+
+		food.Name = &row.Name
+		food.Price = row.Price
+		food.Food_image = &row.Food_image
+		food.Menu_id = &menuID
+		food.Station = row.Station
+		food.Tax_category_id = row.Tax_category_id
+		if row.Food_image == "" {
+			food.Food_image = nil
+		}
+
+		if rowErrs := importValidationErrors(row.row, food); len(rowErrs) > 0 {
+			errs = append(errs, rowErrs...)
+			continue
+		}
+		if food.Price.IsNegative() {
+			errs = append(errs, ImportError{Row: row.row, Field: "price", Error: "price must not be negative"})
+			continue
+		}
+
+		if exists {
+			err = tx.Model(&food).Select("name", "price_minor", "price_currency", "food_image", "menu_id", "station", "tax_category_id").Updates(&food).Error
+			foodCount.Updated++
+		} else {
+			err = tx.Create(&food).Error
+			foodCount.Created++
+		}
+		if err != nil {
+			return menuCount, foodCount, errs, err
+		}
+
+		if oldPrice == nil || *oldPrice != *food.Price {
+			if err := recordPriceChange(tx, food, true, food.Price.Currency, oldPrice, food.Price, userID); err != nil {
+				return menuCount, foodCount, errs, err
+			}
+		}
+	}
+
+	return menuCount, foodCount, errs, nil
+}
+
+// importMenuID finds the menu of an imported food: one from the same import,
+// an existing menu with the code, or the menu_id given.
+func importMenuID(tx *gorm.DB, row FoodImportRow, imported map[string]string) (string, error) {
+	if row.Menu_code != "" {
+		if id, ok := imported[row.Menu_code]; ok {
+			return id, nil
+		}
+
+		var menu models.Menu
+		if err := tx.Where("external_code = ?", row.Menu_code).First(&menu).Error; err != nil {
+			return "", errors.New("menu " + row.Menu_code + " not found")
+		}
+		return menu.Menu_id, nil
+	}
+
+	var menu models.Menu
+	if err := tx.Where("menu_id = ?", row.Menu_id).First(&menu).Error; err != nil {
+		return "", errors.New("menu_id not found")
+	}
+	return menu.Menu_id, nil
+}
+
+// importValidationErrors runs helpers.Validate on a row and reports each failed field.
+func importValidationErrors(row string, value interface{}) []ImportError {
+	err := helpers.Validate.Struct(value)
+	if err == nil {
+		return nil
+	}
+
+	var fieldErrs validator.ValidationErrors
+	if !errors.As(err, &fieldErrs) {
+		return []ImportError{{Row: row, Error: err.Error()}}
+	}
+
+	errs := make([]ImportError, 0, len(fieldErrs))
+	for _, fieldErr := range fieldErrs {
+		rule := fieldErr.Tag()
+		if fieldErr.Param() != "" {
+			rule += "=" + fieldErr.Param()
+		}
+		errs = append(errs, ImportError{
+			Row:   row,
+			Field: strings.ToLower(fieldErr.Field()),
+			Error: "failed the " + rule + " rule",
+		})
+	}
+
+	return errs
+}
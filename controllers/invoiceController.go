@@ -1,15 +1,31 @@
 package controllers
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
+	"strings"
+	"time"
 
 	"github.com/Hdeee1/go-restaurant-management/database"
 	"github.com/Hdeee1/go-restaurant-management/helpers"
 	"github.com/Hdeee1/go-restaurant-management/models"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+// InvoiceUpdate lists what can change on an issued invoice. Its number,
+// branch and order, and the overdue and receipt bookkeeping, cannot.
+type InvoiceUpdate struct {
+	Payment_method   *string    `json:"payment_method" validate:"omitempty,eq=CARD|eq=CASH"`
+	Payment_status   *string    `json:"payment_status" validate:"omitempty,eq=PENDING|eq=PAID"`
+	Payment_due_date *time.Time `json:"payment_due_date"`
+	Currency         *string    `json:"currency" validate:"omitempty,len=3,uppercase"`
+	Gratuity_rate    *float64   `json:"gratuity_rate" validate:"omitempty,gte=0,lte=1"`
+}
+
 // GetInvoices godoc
 //
 //	@Summary		Get all invoices
@@ -17,6 +33,10 @@ import (
 //	@Tags			Invoices
 //	@Accept			json
 //	@Produce		json
+//	@Param			number	query	string	false	"Invoice number or part of it, e.g. 2026-000123"
+//	@Param			branch	query	string	false	"Branch code"
+//	@Param			overdue	query	bool	false	"Only PENDING invoices past their due date"
+//	@Param			format	query	string	false	"json, csv or xlsx; csv and xlsx export every matching invoice"
 //	@Param			page	query	int	false	"Page number"		default(1)
 //	@Param			limit	query	int	false	"Items per page"	default(10)
 //	@Security		BearerAuth
@@ -27,7 +47,50 @@ func GetInvoices() gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		var invoices []models.Invoice
 
-		result := database.DB.Scopes(helpers.Paginate(ctx)).Find(&invoices)
+		format, err := helpers.ExportFormat(ctx)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		query := database.DB
+
+		if number := ctx.Query("number"); number != "" {
+			query = query.Where("invoice_number LIKE ?", "%"+number+"%")
+		}
+
+		if branch := ctx.Query("branch"); branch != "" {
+			query = query.Where("branch = ?", strings.ToUpper(branch))
+		}
+
+		if ctx.Query("overdue") == "true" {
+			query = query.Scopes(helpers.OverdueInvoices(time.Now()))
+		}
+
+		if format != "" {
+			header := []string{"invoice_id", "invoice_number", "branch", "order_id", "currency", "total", "paid", "balance",
+				"payment_method", "payment_status", "state", "payment_due_date", "created_at"}
+			err := helpers.ExportQueryBatches(ctx, format, "invoices", header, query, func(invoices []models.Invoice) ([][]interface{}, error) {
+				docs, err := invoiceDocuments(database.DB, invoices)
+				if err != nil {
+					return nil, err
+				}
+				rows := make([][]interface{}, len(invoices))
+				for i, invoice := range invoices {
+					doc := docs[invoice.Invoice_id]
+					rows[i] = []interface{}{invoice.Invoice_id, invoice.Invoice_number, invoice.Branch, invoice.Order_id, doc.Currency,
+						doc.Total(), doc.Paid(), doc.Balance(), invoice.Payment_method, invoice.Payment_status, invoice.State,
+						invoice.Payment_due_date, invoice.CreatedAt}
+				}
+				return rows, nil
+			})
+			if err != nil {
+				helpers.ExportFailed(ctx, err)
+			}
+			return
+		}
+
+		result := query.Scopes(helpers.Paginate(ctx)).Order("id").Find(&invoices)
 		if result.Error != nil {
 			ctx.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
 			return
@@ -96,15 +159,78 @@ func CreateInvoice() gin.HandlerFunc {
 		}
 
 		invoice.Invoice_id = uuid.New().String()
+		invoice.Invoice_number = nil
+		invoice.Overdue_at = nil
+		invoice.Reminders_sent = 0
+		invoice.Last_reminder_at = nil
+		invoice.Receipt_status = nil
+		invoice.Receipt_sent_at = nil
+		invoice.Receipt_error = nil
+		invoice.Branch = strings.ToUpper(invoice.Branch)
+		if invoice.Branch == "" {
+			invoice.Branch = models.DefaultBranch()
+		}
+
+		if invoice.Currency == "" {
+			invoice.Currency = models.DefaultCurrency()
+		}
+
+		if _, err := exchangeRate(database.DB, models.DefaultCurrency(), invoice.Currency, time.Now()); errors.Is(err, errNoExchangeRate) {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		} else if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		if invoice.Gratuity_rate == nil {
+			rate, err := autoGratuityRate(database.DB, invoice.Order_id)
+			if err != nil {
+				ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+			invoice.Gratuity_rate = rate
+		}
+
+		year := time.Now().Year()
+		if err := ensureInvoiceSequence(database.DB, invoice.Branch, year); err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		tx := database.DB.Begin()
+
+		// The number is taken inside the transaction creating the invoice, so
+		// a failed invoice gives its number back and the sequence has no gaps.
+		number, err := nextInvoiceNumber(tx, invoice.Branch, year)
+		if err != nil {
+			tx.Rollback()
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		invoice.Invoice_number = &number
 
-		if err := database.DB.Create(&invoice).Error; err != nil {
+		if err := tx.Create(&invoice).Error; err != nil {
+			tx.Rollback()
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		if err := syncTableWithInvoice(tx, invoice); err != nil {
+			tx.Rollback()
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		if err := tx.Commit().Error; err != nil {
 			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
 
 		ctx.JSON(http.StatusCreated, gin.H{
-			"message":    "invoice created",
-			"invoice_id": invoice.Invoice_id,
+			"message":        "invoice created",
+			"invoice_id":     invoice.Invoice_id,
+			"invoice_number": number,
 		})
 	}
 }
@@ -117,11 +243,12 @@ func CreateInvoice() gin.HandlerFunc {
 //	@Accept			json
 //	@Produce		json
 //	@Param			invoice_id	path	string			true	"Invoice ID"
-//	@Param			invoice		body	models.Invoice	true	"Invoice object"
+//	@Param			invoice		body	InvoiceUpdate	true	"Changes"
 //	@Security		BearerAuth
 //	@Success		200	{object}	map[string]interface{}
 //	@Failure		400	{object}	map[string]interface{}
 //	@Failure		404	{object}	map[string]interface{}
+//	@Failure		409	{object}	map[string]interface{}
 //	@Failure		500	{object}	map[string]interface{}
 //	@Router			/invoices/{invoice_id} [put]
 func UpdateInvoice() gin.HandlerFunc {
@@ -135,16 +262,63 @@ func UpdateInvoice() gin.HandlerFunc {
 			return
 		}
 
-		var updateData models.Invoice
+		var updateData InvoiceUpdate
 		if err := ctx.BindJSON(&updateData); err != nil {
 			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 			return
 		}
 
-		if err := database.DB.Model(&invoice).Updates(updateData).Error; err != nil {
+		if err := helpers.Validate.Struct(updateData); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		updates := map[string]interface{}{}
+		if updateData.Payment_method != nil {
+			updates["payment_method"] = *updateData.Payment_method
+		}
+		if updateData.Payment_status != nil {
+			updates["payment_status"] = *updateData.Payment_status
+		}
+		if updateData.Payment_due_date != nil {
+			updates["payment_due_date"] = *updateData.Payment_due_date
+		}
+		if updateData.Currency != nil {
+			updates["currency"] = *updateData.Currency
+		}
+		if updateData.Gratuity_rate != nil {
+			updates["gratuity_rate"] = *updateData.Gratuity_rate
+		}
+
+		if updateData.Currency != nil && *updateData.Currency != invoice.Currency {
+			var payments int64
+			database.DB.Model(&models.Payment{}).Where("invoice_id = ?", invoice.Invoice_id).Count(&payments)
+			if payments > 0 {
+				ctx.JSON(http.StatusConflict, gin.H{"error": "cannot change the currency of an invoice with payments"})
+				return
+			}
+		}
+
+		tx := database.DB.Begin()
+
+		if len(updates) > 0 {
+			if err := tx.Model(&invoice).Updates(updates).Error; err != nil {
+				tx.Rollback()
+				ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+		}
+
+		if updateData.Payment_status != nil {
+			invoice.Payment_status = updateData.Payment_status
+		}
+
+		if err := syncTableWithInvoice(tx, invoice); err != nil {
+			tx.Rollback()
 			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
+		tx.Commit()
 
 		ctx.JSON(http.StatusOK, gin.H{
 			"message":    "invoice updated",
@@ -152,3 +326,433 @@ func UpdateInvoice() gin.HandlerFunc {
 		})
 	}
 }
+
+// GetInvoicePDF godoc
+//
+//	@Summary		Download an invoice as PDF
+//	@Description	Render a branded invoice PDF with restaurant details, line items, taxes, discounts, payments and balance
+//	@Tags			Invoices
+//	@Produce		application/pdf
+//	@Param			invoice_id	path	string	true	"Invoice ID"
+//	@Security		BearerAuth
+//	@Success		200	{file}		binary
+//	@Failure		404	{object}	map[string]interface{}
+//	@Failure		500	{object}	map[string]interface{}
+//	@Router			/invoices/{invoice_id}/pdf [get]
+func GetInvoicePDF() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		invoice_id := ctx.Param("invoice_id")
+
+		doc, err := invoiceDocument(database.DB, invoice_id)
+		if err == gorm.ErrRecordNotFound {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "invoice_id not found"})
+			return
+		}
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		pdf, err := doc.PDF()
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		ctx.Header("Content-Disposition", `inline; filename="invoice-`+doc.Number()+`.pdf"`)
+		ctx.Data(http.StatusOK, "application/pdf", pdf)
+	}
+}
+
+type ReceiptEmailRequest struct {
+	Email string `json:"email" validate:"omitempty,email"`
+}
+
+// EmailInvoiceReceipt godoc
+//
+//	@Summary		Email a receipt
+//	@Description	Send or resend the receipt for an invoice, with the invoice PDF attached. Without an email the receipt is resent to the last address used. The delivery status is recorded on the invoice.
+//	@Tags			Invoices
+//	@Accept			json
+//	@Produce		json
+//	@Param			invoice_id	path	string				true	"Invoice ID"
+//	@Param			recipient	body	ReceiptEmailRequest	false	"Recipient"
+//	@Security		BearerAuth
+//	@Success		200	{object}	map[string]interface{}
+//	@Failure		400	{object}	map[string]interface{}
+//	@Failure		404	{object}	map[string]interface{}
+//	@Failure		500	{object}	map[string]interface{}
+//	@Failure		502	{object}	map[string]interface{}
+//	@Router			/invoices/{invoice_id}/email [post]
+func EmailInvoiceReceipt() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		invoice_id := ctx.Param("invoice_id")
+
+		var req ReceiptEmailRequest
+		if ctx.Request.ContentLength > 0 {
+			if err := ctx.BindJSON(&req); err != nil {
+				ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+		}
+
+		if err := helpers.Validate.Struct(req); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		var invoice models.Invoice
+
+		if err := database.DB.Where("invoice_id = ?", invoice_id).First(&invoice).Error; err != nil {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "invoice_id not found"})
+			return
+		}
+
+		to := req.Email
+		if to == "" && invoice.Receipt_email != nil {
+			to = *invoice.Receipt_email
+		}
+
+		if to == "" {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "email is required"})
+			return
+		}
+
+		doc, err := invoiceDocument(database.DB, invoice.Invoice_id)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		msg, err := helpers.ReceiptEmail(to, doc)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		sendErr := helpers.Mail.Send(msg)
+
+		delivery := map[string]interface{}{
+			"receipt_email":   to,
+			"receipt_status":  models.ReceiptSent,
+			"receipt_sent_at": time.Now(),
+			"receipt_error":   nil,
+		}
+		if sendErr != nil {
+			delivery["receipt_status"] = models.ReceiptFailed
+			delivery["receipt_sent_at"] = nil
+			delivery["receipt_error"] = sendErr.Error()
+		}
+
+		if err := database.DB.Model(&invoice).Updates(delivery).Error; err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		if sendErr != nil {
+			ctx.JSON(http.StatusBadGateway, gin.H{"error": "failed to send receipt: " + sendErr.Error()})
+			return
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{
+			"message":    "receipt sent",
+			"invoice_id": invoice.Invoice_id,
+			"email":      to,
+		})
+	}
+}
+
+// invoiceSource is everything an invoice document is built from.
+type invoiceSource struct {
+	invoice  models.Invoice
+	order    models.Order
+	table    models.Table
+	foods    map[string]models.Food
+	rates    []models.TaxRate
+	payments []models.Payment
+}
+
+// invoiceDocument gathers an invoice with its order lines, adjustments and payments.
+func invoiceDocument(db *gorm.DB, invoiceID string) (helpers.InvoiceDocument, error) {
+	var invoice models.Invoice
+	if err := db.Where("invoice_id = ?", invoiceID).First(&invoice).Error; err != nil {
+		return helpers.RestaurantInvoiceDocument(), err
+	}
+
+	docs, err := invoiceDocuments(db, []models.Invoice{invoice})
+	if err != nil {
+		return helpers.RestaurantInvoiceDocument(), err
+	}
+
+	return docs[invoice.Invoice_id], nil
+}
+
+// invoiceDocuments builds the documents of several invoices at once, loading
+// their orders, foods, tax rates and payments a query each.
+func invoiceDocuments(db *gorm.DB, invoices []models.Invoice) (map[string]helpers.InvoiceDocument, error) {
+	docs := make(map[string]helpers.InvoiceDocument, len(invoices))
+	if len(invoices) == 0 {
+		return docs, nil
+	}
+
+	invoiceIDs := make([]string, len(invoices))
+	orderIDs := make([]string, len(invoices))
+	earliest, latest := invoices[0].CreatedAt, invoices[0].CreatedAt
+	for i, invoice := range invoices {
+		invoiceIDs[i] = invoice.Invoice_id
+		orderIDs[i] = invoice.Order_id
+		if invoice.CreatedAt.Before(earliest) {
+			earliest = invoice.CreatedAt
+		}
+		if invoice.CreatedAt.After(latest) {
+			latest = invoice.CreatedAt
+		}
+	}
+
+	var orders []models.Order
+	if err := db.Preload("OrderItems").Where("order_id IN ?", orderIDs).Find(&orders).Error; err != nil {
+		return nil, err
+	}
+	ordersByID := make(map[string]models.Order, len(orders))
+	var items []models.OrderItem
+	var tableIDs []string
+	for _, order := range orders {
+		ordersByID[order.Order_id] = order
+		items = append(items, order.OrderItems...)
+		if order.Table_id != nil {
+			tableIDs = append(tableIDs, *order.Table_id)
+		}
+	}
+
+	var tables []models.Table
+	if len(tableIDs) > 0 {
+		if err := db.Unscoped().Where("table_id IN ?", tableIDs).Find(&tables).Error; err != nil {
+			return nil, err
+		}
+	}
+	tablesByID := make(map[string]models.Table, len(tables))
+	for _, table := range tables {
+		tablesByID[table.Table_id] = table
+	}
+
+	foods, err := foodsByID(db, items)
+	if err != nil {
+		return nil, err
+	}
+
+	// Every rate in effect at some point between the first and last invoice;
+	// each invoice picks those in effect when it was issued.
+	var rates []models.TaxRate
+	err = db.Where("effective_from <= ? AND (effective_to IS NULL OR effective_to > ?)", latest, earliest).Order("id").Find(&rates).Error
+	if err != nil {
+		return nil, err
+	}
+
+	payments, err := capturedPayments(db, invoiceIDs...)
+	if err != nil {
+		return nil, err
+	}
+	paymentsByInvoice := map[string][]models.Payment{}
+	for _, payment := range payments {
+		paymentsByInvoice[payment.Invoice_id] = append(paymentsByInvoice[payment.Invoice_id], payment)
+	}
+
+	for _, invoice := range invoices {
+		order, ok := ordersByID[invoice.Order_id]
+		if !ok {
+			return nil, fmt.Errorf("order %s of invoice %s: %w", invoice.Order_id, invoice.Invoice_id, gorm.ErrRecordNotFound)
+		}
+
+		source := invoiceSource{
+			invoice:  invoice,
+			order:    order,
+			foods:    foods,
+			rates:    rates,
+			payments: paymentsByInvoice[invoice.Invoice_id],
+		}
+		if order.Table_id != nil {
+			source.table = tablesByID[*order.Table_id]
+		}
+
+		doc, err := buildInvoiceDocument(db, source)
+		if err != nil {
+			return nil, err
+		}
+		docs[invoice.Invoice_id] = doc
+	}
+
+	return docs, nil
+}
+
+// buildInvoiceDocument lays out an invoice from its loaded source. Only lines
+// priced in another currency still need the database, for exchange rates.
+func buildInvoiceDocument(db *gorm.DB, source invoiceSource) (helpers.InvoiceDocument, error) {
+	doc := helpers.RestaurantInvoiceDocument()
+	invoice, order, table, foods := source.invoice, source.order, source.table, source.foods
+
+	doc.Invoice_id = invoice.Invoice_id
+	if invoice.Invoice_number != nil {
+		doc.Invoice_number = *invoice.Invoice_number
+	}
+	doc.Order_id = order.Order_id
+	doc.Table_number = table.Table_number
+	doc.Issued_at = invoice.CreatedAt
+	if invoice.Currency != "" {
+		doc.Currency = invoice.Currency
+		doc.Tip = models.Zero(invoice.Currency)
+	}
+	doc.Due_date = invoice.Payment_due_date
+	if invoice.Payment_method != nil {
+		doc.Payment_method = *invoice.Payment_method
+	}
+	if invoice.Payment_status != nil {
+		doc.Payment_status = *invoice.Payment_status
+	}
+
+	serviceType := order.Service_type
+	if serviceType == "" {
+		serviceType = models.ServiceDineIn
+	}
+
+	rules := taxRules(source.rates, serviceType, invoice.CreatedAt)
+
+	var taxable []helpers.TaxableLine
+	for _, item := range order.OrderItems {
+		line := helpers.ReceiptLine{Name: *item.Food_id, Quantity: *item.Quantity}
+		if food := foods[*item.Food_id]; food.Name != nil {
+			line.Name = *food.Name
+		}
+		// A bundle component carries its share of the bundle's price, not
+		// the food's own price, so it is only ever converted.
+		priced := foods[*item.Food_id]
+		if item.Bundle_id != nil {
+			priced = models.Food{}
+			if bundle := foods[*item.Bundle_id]; bundle.Name != nil {
+				line.Name = *bundle.Name + ": " + line.Name
+			}
+		}
+		if item.Unit_price != nil {
+			var err error
+			line.Price, err = linePrice(db, *item.Unit_price, priced, doc.Currency, invoice.CreatedAt)
+			if err != nil {
+				return doc, err
+			}
+		}
+		doc.Lines = append(doc.Lines, line)
+
+		if categoryID := foods[*item.Food_id].Tax_category_id; categoryID != nil {
+			taxable = append(taxable, helpers.TaxableLine{Price: line.Price, Rules: rules[*categoryID]})
+		}
+	}
+
+	doc.Included_taxes, doc.Taxes = helpers.ComputeTaxes(taxable, doc.Currency)
+
+	if invoice.Gratuity_rate != nil && *invoice.Gratuity_rate > 0 {
+		doc.Charges = append(doc.Charges, helpers.AmountLine{
+			Label:  fmt.Sprintf("Gratuity %g%%", *invoice.Gratuity_rate*100),
+			Amount: doc.Subtotal().MulRate(*invoice.Gratuity_rate),
+		})
+	}
+
+	payments := source.payments
+	for _, payment := range payments {
+		if !payment.Settled().SameCurrency(models.Zero(doc.Currency)) {
+			return doc, fmt.Errorf("payment %s is settled in %s, not %s", payment.Payment_id, payment.Settled().Currency, doc.Currency)
+		}
+		doc.Payments = append(doc.Payments, helpers.AmountLine{
+			Label:  "Paid by " + payment.Method,
+			Amount: payment.Settled(),
+		})
+		if payment.Exchange_rate != nil {
+			doc.Tip = doc.Tip.Add(payment.Tip_amount.Convert(*payment.Exchange_rate, doc.Currency))
+		} else {
+			doc.Tip = doc.Tip.Add(payment.Tip_amount)
+		}
+	}
+
+	// Invoices marked PAID before payments were recorded are settled in full.
+	if len(payments) == 0 && doc.Payment_status == "PAID" {
+		label := "Paid"
+		if doc.Payment_method != "" {
+			label = "Paid by " + doc.Payment_method
+		}
+		doc.Payments = append(doc.Payments, helpers.AmountLine{Label: label, Amount: doc.Total()})
+	}
+
+	return doc, nil
+}
+
+// ensureInvoiceSequence creates a branch's sequence row for a year if it is
+// missing. It runs on its own, outside the invoice transaction: two
+// transactions that both lock the missing row and then insert it deadlock.
+func ensureInvoiceSequence(db *gorm.DB, branch string, year int) error {
+	sequence := models.InvoiceSequence{Branch: branch, Year: year}
+	return db.Clauses(clause.Insert{Modifier: "IGNORE"}).Create(&sequence).Error
+}
+
+// nextInvoiceNumber takes the next number of a branch's yearly sequence,
+// whose row ensureInvoiceSequence created. The row stays locked until tx
+// ends, so concurrent invoices queue up and a rolled back invoice leaves no
+// gap.
+func nextInvoiceNumber(tx *gorm.DB, branch string, year int) (string, error) {
+	var sequence models.InvoiceSequence
+
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("branch = ? AND year = ?", branch, year).First(&sequence).Error
+	if err != nil {
+		return "", err
+	}
+
+	sequence.Last_number++
+	if err := tx.Model(&sequence).Update("last_number", sequence.Last_number).Error; err != nil {
+		return "", err
+	}
+
+	return models.InvoiceNumber(branch, year, sequence.Last_number), nil
+}
+
+// linePrice prices an order item in the invoice currency: the food's own price
+// in that currency if it has one, otherwise the ordered price converted at the
+// rate in effect when the invoice was issued.
+func linePrice(db *gorm.DB, unitPrice models.Money, food models.Food, currency string, at time.Time) (models.Money, error) {
+	if unitPrice.Currency == currency {
+		return unitPrice, nil
+	}
+
+	if price, ok := food.PriceIn(currency); ok {
+		return price, nil
+	}
+
+	price, _, err := convertMoney(db, unitPrice, currency, at)
+	return price, err
+}
+
+// autoGratuityRate returns the automatic gratuity for the party seated at an
+// order's table, or nil when the party is below the configured size.
+func autoGratuityRate(db *gorm.DB, orderID string) (*float64, error) {
+	partySize, rate := helpers.AutoGratuity()
+	if partySize <= 0 {
+		return nil, nil
+	}
+
+	var order models.Order
+	if err := db.Where("order_id = ?", orderID).First(&order).Error; err != nil || order.Table_id == nil {
+		return nil, nil
+	}
+
+	var table models.Table
+	if err := db.Where("table_id = ?", *order.Table_id).First(&table).Error; err != nil {
+		return nil, err
+	}
+
+	guests := 0
+	if table.Party_size != nil {
+		guests = *table.Party_size
+	}
+
+	if guests < partySize {
+		return nil, nil
+	}
+
+	return &rate, nil
+}
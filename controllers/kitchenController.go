@@ -0,0 +1,81 @@
+package controllers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/Hdeee1/go-restaurant-management/database"
+	"github.com/Hdeee1/go-restaurant-management/models"
+	"github.com/gin-gonic/gin"
+)
+
+type KitchenItem struct {
+	Order_item_id string     `json:"order_item_id"`
+	Order_id      string     `json:"order_id"`
+	Table_id      *string    `json:"table_id"`
+	Table_number  *int       `json:"table_number"`
+	Food_id       string     `json:"food_id"`
+	Food_name     *string    `json:"food_name"`
+	Quantity      *string    `json:"quantity"`
+	Course        *string    `json:"course"`
+	Fire_status   string     `json:"fire_status"`
+	Fired_at      *time.Time `json:"fired_at"`
+
+	Bundle_id      *string `json:"bundle_id"`
+	Bundle_name    *string `json:"bundle_name"`
+	Bundle_item_id *string `json:"bundle_item_id"`
+}
+
+// GetKitchenFeed godoc
+//
+//	@Summary		Get the kitchen feed
+//	@Description	Retrieve items fired to the kitchen for open orders, oldest first. Held items are only listed when include_held is set. Bundle components name the bundle they were ordered in.
+//	@Tags			Kitchen
+//	@Accept			json
+//	@Produce		json
+//	@Param			since			query	string	false	"Only items fired after this RFC3339 time"
+//	@Param			include_held	query	bool	false	"Also list items still held"
+//	@Security		BearerAuth
+//	@Success		200	{object}	map[string]interface{}
+//	@Failure		400	{object}	map[string]interface{}
+//	@Failure		500	{object}	map[string]interface{}
+//	@Router			/kitchen/feed [get]
+func GetKitchenFeed() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var items []KitchenItem
+
+		query := database.DB.Model(&models.OrderItem{}).
+			Select("order_items.order_item_id, order_items.order_id, orders.table_id, tables.table_number, "+
+				"order_items.food_id, foods.name AS food_name, order_items.quantity, order_items.course, "+
+				"order_items.fire_status, order_items.fired_at, "+
+				"order_items.bundle_id, bundles.name AS bundle_name, order_items.bundle_item_id").
+			Joins("JOIN orders ON orders.order_id = order_items.order_id AND orders.deleted_at IS NULL").
+			Joins("LEFT JOIN tables ON tables.table_id = orders.table_id AND tables.deleted_at IS NULL").
+			Joins("LEFT JOIN foods ON foods.food_id = order_items.food_id AND foods.deleted_at IS NULL").
+			Joins("LEFT JOIN foods AS bundles ON bundles.food_id = order_items.bundle_id AND bundles.deleted_at IS NULL").
+			Where("orders.status = ?", models.OrderOpen)
+
+		if ctx.Query("include_held") != "true" {
+			query = query.Where("order_items.fire_status = ?", models.ItemFired)
+		}
+
+		if since := ctx.Query("since"); since != "" {
+			sinceTime, err := time.Parse(time.RFC3339, since)
+			if err != nil {
+				ctx.JSON(http.StatusBadRequest, gin.H{"error": "since must be an RFC3339 time"})
+				return
+			}
+			query = query.Where("order_items.fired_at > ?", sinceTime)
+		}
+
+		if err := query.Order("order_items.fired_at, order_items.id").Scan(&items).Error; err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{
+			"items":       items,
+			"server_time": time.Now(),
+		})
+	}
+}
@@ -1,6 +1,7 @@
 package controllers
 
 import (
+	"errors"
 	"net/http"
 	"time"
 
@@ -9,11 +10,40 @@ import (
 	"github.com/Hdeee1/go-restaurant-management/models"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type OrderRequest struct {
-	Table_id    string             `json:"table_id" validate:"required"`
-	Order_items []models.OrderItem `json:"order_items" validate:"required"`
+	Table_id     string             `json:"table_id" validate:"required"`
+	Service_type string             `json:"service_type" validate:"omitempty,eq=DINE_IN|eq=TAKEOUT"`
+	Order_items  []models.OrderItem `json:"order_items" validate:"required"`
+}
+
+// orderError is a failed order placement together with the HTTP status to report it with.
+type orderError struct {
+	status  int
+	message string
+}
+
+func (e *orderError) Error() string {
+	return e.message
+}
+
+func orderErrorStatus(err error) int {
+	if oerr, ok := err.(*orderError); ok {
+		return oerr.status
+	}
+
+	return http.StatusInternalServerError
+}
+
+type FireRequest struct {
+	Course string `json:"course" validate:"omitempty,eq=STARTER|eq=MAIN|eq=DESSERT"`
+}
+
+type TransferRequest struct {
+	Table_id string `json:"table_id" validate:"required"`
 }
 
 // GetOrders godoc
@@ -25,6 +55,9 @@ type OrderRequest struct {
 //	@Produce		json
 //	@Param			page	query	int	false	"Page number"		default(1)
 //	@Param			limit	query	int	false	"Items per page"	default(10)
+//	@Param			mine	query	bool	false	"Only orders at tables in the caller's current sections"
+//	@Param			status	query	string	false	"Order status, e.g. PENDING_APPROVAL"
+//	@Param			format	query	string	false	"json, csv or xlsx; csv and xlsx export every matching order"
 //	@Security		BearerAuth
 //	@Success		200	{object}	map[string]interface{}
 //	@Failure		500	{object}	map[string]interface{}
@@ -33,7 +66,40 @@ func GetOrders() gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		var orders []models.Order
 
-		result := database.DB.Scopes(helpers.Paginate(ctx)).Preload("OrderItems").Find(&orders)
+		format, err := helpers.ExportFormat(ctx)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		query := database.DB.Preload("OrderItems")
+
+		if ctx.Query("mine") == "true" {
+			tableIDs, err := assignedTableIDs(database.DB, ctx.GetString("user_id"), time.Now())
+			if err != nil {
+				ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+			query = query.Where("table_id IN ?", tableIDs)
+		}
+
+		if status := ctx.Query("status"); status != "" {
+			query = query.Where("status = ?", status)
+		}
+
+		if format != "" {
+			header := []string{"order_id", "order_date", "table_id", "status", "source", "service_type", "kitchen_status", "items"}
+			err := helpers.ExportQuery(ctx, format, "orders", header, query, func(order models.Order) ([]interface{}, error) {
+				return []interface{}{order.Order_id, order.Order_date, order.Table_id, order.Status, order.Source,
+					order.Service_type, order.Kitchen_status, len(order.OrderItems)}, nil
+			})
+			if err != nil {
+				helpers.ExportFailed(ctx, err)
+			}
+			return
+		}
+
+		result := query.Scopes(helpers.Paginate(ctx)).Find(&orders)
 		if result.Error != nil {
 			ctx.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
 			return
@@ -77,7 +143,7 @@ func GetOrder() gin.HandlerFunc {
 // CreateOrder godoc
 //
 //	@Summary		Create a new order
-//	@Description	Create a new order with order items. Automatically validates food items and calculates prices.
+//	@Description	Create a new order with order items. Automatically validates food items and calculates prices. A bundle is ordered with its components, one food per slot; it is stored as one item per component sharing a bundle_item_id.
 //	@Tags			Orders
 //	@Accept			json
 //	@Produce		json
@@ -86,11 +152,11 @@ func GetOrder() gin.HandlerFunc {
 //	@Success		201	{object}	map[string]interface{}
 //	@Failure		400	{object}	map[string]interface{}
 //	@Failure		404	{object}	map[string]interface{}
+//	@Failure		409	{object}	map[string]interface{}
 //	@Failure		500	{object}	map[string]interface{}
 //	@Router			/orders [post]
 func CreateOrder() gin.HandlerFunc {
 	return func(ctx *gin.Context) {
-		var order models.Order
 		var req OrderRequest
 
 		if err := ctx.BindJSON(&req); err != nil {
@@ -103,43 +169,29 @@ func CreateOrder() gin.HandlerFunc {
 			return
 		}
 
-		tx := database.DB.Begin()
-
-		order.Order_id = uuid.New().String()
-		order.Order_date = time.Now()
-		order.Table_id = &req.Table_id
-
-		if err := tx.Create(&order).Error; err != nil {
-			tx.Rollback()
-			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		var table models.Table
+		if err := database.DB.Where("table_id = ?", req.Table_id).First(&table).Error; err != nil {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "table_id not found"})
 			return
 		}
 
-		for _, item := range req.Order_items {
-			var food models.Food
-			if err := database.DB.Where("food_id = ?", item.Food_id).First(&food).Error; err != nil {
-				tx.Rollback()
-				ctx.JSON(http.StatusNotFound, gin.H{"error": "food_id not found"})
-				return
-			}
-
-			item.Unit_price = food.Price
-			item.Order_item_id = uuid.New().String()
-			item.Order_id = order.Order_id
+		if table.Status == models.TableMerged {
+			ctx.JSON(http.StatusConflict, gin.H{"error": mergedMessage(table)})
+			return
+		}
 
-			if item.Food_id == nil || item.Quantity == nil {
-				tx.Rollback()
-				ctx.JSON(http.StatusBadRequest, gin.H{"error": "Incomplete data item"})
-				return
-			}
+		if !takesOrders(table.Status) {
+			ctx.JSON(http.StatusConflict, gin.H{"error": "table is " + table.Status})
+			return
+		}
 
-			if err := tx.Create(&item).Error; err != nil {
-				tx.Rollback()
-				ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save order item"})
-				return
-			}
+		order, err := placeOrder(table, req.Order_items, req.Service_type, models.OrderOpen, models.OrderSourceStaff)
+		if err != nil {
+			ctx.JSON(orderErrorStatus(err), gin.H{"error": err.Error()})
+			return
 		}
-		tx.Commit()
+
+		printFiredItems(order.Order_id, firedItemIDs(order.OrderItems))
 
 		ctx.JSON(http.StatusCreated, gin.H{
 			"message":  "order created",
@@ -151,7 +203,7 @@ func CreateOrder() gin.HandlerFunc {
 // CreateOrderItem godoc
 //
 //	@Summary		Create a new order item
-//	@Description	Add a new item to an existing order
+//	@Description	Add a new item to an open, uninvoiced order. Items are priced from the menu; a bundle is added as one item per component, priced from the bundle.
 //	@Tags			Orders
 //	@Accept			json
 //	@Produce		json
@@ -159,6 +211,8 @@ func CreateOrder() gin.HandlerFunc {
 //	@Security		BearerAuth
 //	@Success		201	{object}	map[string]interface{}
 //	@Failure		400	{object}	map[string]interface{}
+//	@Failure		404	{object}	map[string]interface{}
+//	@Failure		409	{object}	map[string]interface{}
 //	@Failure		500	{object}	map[string]interface{}
 //	@Router			/orderitems [post]
 func CreateOrderItem() gin.HandlerFunc {
@@ -175,16 +229,60 @@ func CreateOrderItem() gin.HandlerFunc {
 			return
 		}
 
-		orderItem.Order_item_id = uuid.New().String()
+		var food models.Food
+		if err := database.DB.Where("food_id = ?", *orderItem.Food_id).First(&food).Error; err != nil {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "food_id not found"})
+			return
+		}
 
-		if err := database.DB.Create(&orderItem).Error; err != nil {
-			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		// Items are priced from the menu, and bundles are split into their
+		// components, the same way placeOrder does it.
+		items, err := orderLines(database.DB, food, orderItem)
+		if err != nil {
+			ctx.JSON(orderErrorStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+
+		now := time.Now()
+		itemIDs := make([]string, len(items))
+		for i := range items {
+			items[i].Order_item_id = uuid.New().String()
+			items[i].Fire_status = models.ItemHeld
+			items[i].Fired_at = nil
+
+			if items[i].FiresImmediately() {
+				items[i].Fire_status = models.ItemFired
+				items[i].Fired_at = &now
+			}
+			itemIDs[i] = items[i].Order_item_id
+		}
+
+		err = database.DB.Transaction(func(tx *gorm.DB) error {
+			if err := ensureDayOpen(tx, now); err != nil {
+				return err
+			}
+			if err := acceptsItems(tx, orderItem.Order_id); err != nil {
+				return err
+			}
+			return tx.Create(&items).Error
+		})
+		if err != nil {
+			ctx.JSON(orderErrorStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+
+		if food.Food_type == models.FoodBundle {
+			ctx.JSON(http.StatusCreated, gin.H{
+				"message":        "order item created",
+				"bundle_item_id": items[0].Bundle_item_id,
+				"order_item_ids": itemIDs,
+			})
 			return
 		}
 
 		ctx.JSON(http.StatusCreated, gin.H{
 			"message":       "order item created",
-			"order_item_id": orderItem.Order_item_id,
+			"order_item_id": items[0].Order_item_id,
 		})
 	}
 }
@@ -247,3 +345,511 @@ func UpdateOrder() gin.HandlerFunc {
 		})
 	}
 }
+
+// TransferOrder godoc
+//
+//	@Summary		Transfer an order to another table
+//	@Description	Move an unpaid order and its party to another available or reserved table. A table with a party already seated is refused. The old table is left needing cleaning.
+//	@Tags			Orders
+//	@Accept			json
+//	@Produce		json
+//	@Param			order_id	path	string			true	"Order ID"
+//	@Param			transfer	body	TransferRequest	true	"Target table"
+//	@Security		BearerAuth
+//	@Success		200	{object}	map[string]interface{}
+//	@Failure		400	{object}	map[string]interface{}
+//	@Failure		404	{object}	map[string]interface{}
+//	@Failure		409	{object}	map[string]interface{}
+//	@Failure		500	{object}	map[string]interface{}
+//	@Router			/orders/{order_id}/transfer [post]
+func TransferOrder() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		order_id := ctx.Param("order_id")
+
+		var req TransferRequest
+		if err := ctx.BindJSON(&req); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		if err := helpers.Validate.Struct(req); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		var order models.Order
+
+		if err := database.DB.Where("order_id = ?", order_id).First(&order).Error; err != nil {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "order_id not found"})
+			return
+		}
+
+		var invoice models.Invoice
+
+		err := database.DB.Where("order_id = ?", order_id).First(&invoice).Error
+		if err == nil {
+			if invoice.Payment_status != nil && *invoice.Payment_status == "PAID" {
+				ctx.JSON(http.StatusBadRequest, gin.H{"error": "order already paid"})
+				return
+			}
+		}
+
+		if order.Table_id != nil && *order.Table_id == req.Table_id {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "order is already at this table"})
+			return
+		}
+
+		var target models.Table
+
+		if err := database.DB.Where("table_id = ?", req.Table_id).First(&target).Error; err != nil {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "table_id not found"})
+			return
+		}
+
+		if target.Status != models.TableAvailable && target.Status != models.TableReserved {
+			ctx.JSON(http.StatusConflict, gin.H{"error": "table is " + target.Status})
+			return
+		}
+
+		var source models.Table
+		if order.Table_id != nil {
+			database.DB.Where("table_id = ?", *order.Table_id).First(&source)
+		}
+
+		status := models.TableOrdering
+		if source.Status == models.TableAwaitingPayment {
+			status = models.TableAwaitingPayment
+		}
+
+		now := time.Now()
+		seatedAt := source.Seated_at
+		if seatedAt == nil {
+			seatedAt = &now
+		}
+
+		tx := database.DB.Begin()
+
+		if err := tx.Model(&order).Update("table_id", target.Table_id).Error; err != nil {
+			tx.Rollback()
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		err = updateTable(tx, target, map[string]interface{}{
+			"status":     status,
+			"party_size": source.Party_size,
+			"seated_at":  seatedAt,
+		})
+		if err != nil {
+			tx.Rollback()
+			ctx.JSON(orderErrorStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+
+		if source.Table_id != "" {
+			if err := setTableStatus(tx, source, models.TableNeedsCleaning); err != nil {
+				tx.Rollback()
+				ctx.JSON(orderErrorStatus(err), gin.H{"error": err.Error()})
+				return
+			}
+		}
+		tx.Commit()
+
+		ctx.JSON(http.StatusOK, gin.H{
+			"message":  "order transferred",
+			"order_id": order.Order_id,
+			"table_id": target.Table_id,
+		})
+	}
+}
+
+// ApproveOrder godoc
+//
+//	@Summary		Approve a guest order
+//	@Description	Approve an order placed by a guest so it reaches the kitchen
+//	@Tags			Orders
+//	@Accept			json
+//	@Produce		json
+//	@Param			order_id	path	string	true	"Order ID"
+//	@Security		BearerAuth
+//	@Success		200	{object}	map[string]interface{}
+//	@Failure		404	{object}	map[string]interface{}
+//	@Failure		409	{object}	map[string]interface{}
+//	@Failure		500	{object}	map[string]interface{}
+//	@Router			/orders/{order_id}/approve [post]
+func ApproveOrder() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		order_id := ctx.Param("order_id")
+
+		var order models.Order
+
+		if err := database.DB.Where("order_id = ?", order_id).First(&order).Error; err != nil {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "order_id not found"})
+			return
+		}
+
+		if order.Status != models.OrderPendingApproval {
+			ctx.JSON(http.StatusConflict, gin.H{"error": "order is " + order.Status})
+			return
+		}
+
+		var table models.Table
+		if order.Table_id != nil {
+			if err := database.DB.Where("table_id = ?", *order.Table_id).First(&table).Error; err != nil {
+				ctx.JSON(http.StatusNotFound, gin.H{"error": "table_id not found"})
+				return
+			}
+
+			if !takesOrders(table.Status) {
+				ctx.JSON(http.StatusConflict, gin.H{"error": "table is " + table.Status})
+				return
+			}
+		}
+
+		tx := database.DB.Begin()
+
+		if err := tx.Model(&order).Update("status", models.OrderOpen).Error; err != nil {
+			tx.Rollback()
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		if order.Table_id != nil {
+			if err := setTableStatus(tx, table, models.TableOrdering); err != nil {
+				tx.Rollback()
+				ctx.JSON(orderErrorStatus(err), gin.H{"error": err.Error()})
+				return
+			}
+		}
+
+		var itemIDs []string
+		err := tx.Model(&models.OrderItem{}).
+			Where("order_id = ? AND fire_status = ?", order.Order_id, models.ItemHeld).
+			Where("course IS NULL OR course = ?", models.CourseStarter).
+			Pluck("order_item_id", &itemIDs).Error
+		if err != nil {
+			tx.Rollback()
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		itemIDs, err = fireItems(tx, itemIDs)
+		if err != nil {
+			tx.Rollback()
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		tx.Commit()
+
+		printFiredItems(order.Order_id, itemIDs)
+
+		ctx.JSON(http.StatusOK, gin.H{
+			"message":  "order approved",
+			"order_id": order.Order_id,
+		})
+	}
+}
+
+// RejectOrder godoc
+//
+//	@Summary		Reject a guest order
+//	@Description	Reject an order placed by a guest so it never reaches the kitchen
+//	@Tags			Orders
+//	@Accept			json
+//	@Produce		json
+//	@Param			order_id	path	string	true	"Order ID"
+//	@Security		BearerAuth
+//	@Success		200	{object}	map[string]interface{}
+//	@Failure		404	{object}	map[string]interface{}
+//	@Failure		409	{object}	map[string]interface{}
+//	@Failure		500	{object}	map[string]interface{}
+//	@Router			/orders/{order_id}/reject [post]
+func RejectOrder() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		order_id := ctx.Param("order_id")
+
+		var order models.Order
+
+		if err := database.DB.Where("order_id = ?", order_id).First(&order).Error; err != nil {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "order_id not found"})
+			return
+		}
+
+		if order.Status != models.OrderPendingApproval {
+			ctx.JSON(http.StatusConflict, gin.H{"error": "order is " + order.Status})
+			return
+		}
+
+		if err := database.DB.Model(&order).Update("status", models.OrderRejected).Error; err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{
+			"message":  "order rejected",
+			"order_id": order.Order_id,
+		})
+	}
+}
+
+// FireCourse godoc
+//
+//	@Summary		Fire a course to the kitchen
+//	@Description	Send the held items of a course to the kitchen. Without a course, the next held course in serving order is fired.
+//	@Tags			Orders
+//	@Accept			json
+//	@Produce		json
+//	@Param			order_id	path	string		true	"Order ID"
+//	@Param			fire		body	FireRequest	false	"Course to fire"
+//	@Security		BearerAuth
+//	@Success		200	{object}	map[string]interface{}
+//	@Failure		400	{object}	map[string]interface{}
+//	@Failure		404	{object}	map[string]interface{}
+//	@Failure		409	{object}	map[string]interface{}
+//	@Failure		500	{object}	map[string]interface{}
+//	@Router			/orders/{order_id}/fire [post]
+func FireCourse() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		order_id := ctx.Param("order_id")
+
+		var req FireRequest
+		if ctx.Request.ContentLength > 0 {
+			if err := ctx.BindJSON(&req); err != nil {
+				ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+		}
+
+		if err := helpers.Validate.Struct(req); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		var order models.Order
+
+		if err := database.DB.Preload("OrderItems").Where("order_id = ?", order_id).First(&order).Error; err != nil {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "order_id not found"})
+			return
+		}
+
+		if order.Status != models.OrderOpen {
+			ctx.JSON(http.StatusConflict, gin.H{"error": "order is " + order.Status})
+			return
+		}
+
+		course := req.Course
+		if course == "" {
+			course = nextHeldCourse(order.OrderItems)
+		}
+
+		if course == "" {
+			ctx.JSON(http.StatusConflict, gin.H{"error": "nothing left to fire"})
+			return
+		}
+
+		var itemIDs []string
+		for _, item := range order.OrderItems {
+			if item.Fire_status == models.ItemHeld && item.Course != nil && *item.Course == course {
+				itemIDs = append(itemIDs, item.Order_item_id)
+			}
+		}
+
+		if len(itemIDs) == 0 {
+			ctx.JSON(http.StatusConflict, gin.H{"error": "no held items in course " + course})
+			return
+		}
+
+		itemIDs, err := fireItems(database.DB, itemIDs)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		if len(itemIDs) == 0 {
+			ctx.JSON(http.StatusConflict, gin.H{"error": "course " + course + " was already fired"})
+			return
+		}
+
+		printFiredItems(order.Order_id, itemIDs)
+
+		ctx.JSON(http.StatusOK, gin.H{
+			"message":  "course fired",
+			"order_id": order.Order_id,
+			"course":   course,
+			"fired":    len(itemIDs),
+		})
+	}
+}
+
+// fireItems sends held order items to the kitchen and returns the ids of the
+// items it fired. Items that were fired concurrently are left out, so their
+// tickets are not printed twice.
+func fireItems(db *gorm.DB, itemIDs []string) ([]string, error) {
+	if len(itemIDs) == 0 {
+		return nil, nil
+	}
+
+	var fired []string
+	err := db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&models.OrderItem{}).
+			Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("order_item_id IN ? AND fire_status = ?", itemIDs, models.ItemHeld).
+			Pluck("order_item_id", &fired).Error
+		if err != nil || len(fired) == 0 {
+			return err
+		}
+
+		return tx.Model(&models.OrderItem{}).
+			Where("order_item_id IN ? AND fire_status = ?", fired, models.ItemHeld).
+			Updates(map[string]interface{}{"fire_status": models.ItemFired, "fired_at": time.Now()}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return fired, nil
+}
+
+func firedItemIDs(items []models.OrderItem) []string {
+	var itemIDs []string
+	for _, item := range items {
+		if item.Fire_status == models.ItemFired {
+			itemIDs = append(itemIDs, item.Order_item_id)
+		}
+	}
+	return itemIDs
+}
+
+// nextHeldCourse returns the earliest course that still has held items.
+func nextHeldCourse(items []models.OrderItem) string {
+	for _, course := range models.Courses {
+		for _, item := range items {
+			if item.Fire_status == models.ItemHeld && item.Course != nil && *item.Course == course {
+				return course
+			}
+		}
+	}
+
+	return ""
+}
+
+// orderLines prices an ordered food. A bundle becomes one item per component.
+func orderLines(db *gorm.DB, food models.Food, item models.OrderItem) ([]models.OrderItem, error) {
+	if food.Food_type == models.FoodBundle {
+		return bundleItems(db, food, item)
+	}
+
+	item.Unit_price = food.Price
+	item.Components = nil
+	return []models.OrderItem{item}, nil
+}
+
+// acceptsItems locks the order and checks that items can still be added to
+// it: the order must be open and not yet invoiced.
+func acceptsItems(tx *gorm.DB, orderID string) error {
+	var order models.Order
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("order_id = ?", orderID).First(&order).Error; err != nil {
+		return &orderError{http.StatusNotFound, "order_id not found"}
+	}
+
+	switch order.Status {
+	case models.OrderPendingApproval:
+		return &orderError{http.StatusConflict, "order is awaiting approval"}
+	case models.OrderRejected:
+		return &orderError{http.StatusConflict, "order was rejected"}
+	}
+
+	var invoice models.Invoice
+	err := tx.Where("order_id = ?", orderID).First(&invoice).Error
+	if err == nil {
+		if invoice.Payment_status != nil && *invoice.Payment_status == models.InvoicePaid {
+			return &orderError{http.StatusConflict, "order already paid"}
+		}
+		return &orderError{http.StatusConflict, "order already invoiced"}
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+
+	return nil
+}
+
+// placeOrder creates an order with its items against a table in a single transaction.
+// Only OPEN orders move the table into the ORDERING state and fire their first course;
+// orders awaiting approval leave the table alone and hold every item.
+func placeOrder(table models.Table, items []models.OrderItem, serviceType string, status string, source string) (models.Order, error) {
+	var order models.Order
+
+	order.Order_id = uuid.New().String()
+	order.Order_date = time.Now()
+
+	tx := database.DB.Begin()
+
+	if err := ensureDayOpen(tx, order.Order_date); err != nil {
+		tx.Rollback()
+		return order, err
+	}
+
+	order.Table_id = &table.Table_id
+	order.Status = status
+	order.Source = source
+	order.Service_type = serviceType
+	if serviceType == "" {
+		order.Service_type = models.ServiceDineIn
+	}
+
+	if err := tx.Create(&order).Error; err != nil {
+		tx.Rollback()
+		return order, err
+	}
+
+	for _, requested := range items {
+		// Prices and order ids are filled in here, so only what the client
+		// chooses is validated.
+		if err := helpers.Validate.StructPartial(requested, "Quantity", "Food_id", "Course"); err != nil {
+			tx.Rollback()
+			return order, &orderError{http.StatusBadRequest, err.Error()}
+		}
+
+		var food models.Food
+		if err := database.DB.Where("food_id = ?", requested.Food_id).First(&food).Error; err != nil {
+			tx.Rollback()
+			return order, &orderError{http.StatusNotFound, "food_id not found"}
+		}
+
+		lines, err := orderLines(database.DB, food, requested)
+		if err != nil {
+			tx.Rollback()
+			return order, err
+		}
+
+		for _, item := range lines {
+			item.Order_item_id = uuid.New().String()
+			item.Order_id = order.Order_id
+			item.Fire_status = models.ItemHeld
+			item.Fired_at = nil
+
+			if status == models.OrderOpen && item.FiresImmediately() {
+				firedAt := order.Order_date
+				item.Fire_status = models.ItemFired
+				item.Fired_at = &firedAt
+			}
+
+			if err := tx.Create(&item).Error; err != nil {
+				tx.Rollback()
+				return order, &orderError{http.StatusInternalServerError, "Failed to save order item"}
+			}
+			order.OrderItems = append(order.OrderItems, item)
+		}
+	}
+
+	if status == models.OrderOpen {
+		if err := setTableStatus(tx, table, models.TableOrdering); err != nil {
+			tx.Rollback()
+			return order, err
+		}
+	}
+
+	return order, tx.Commit().Error
+}
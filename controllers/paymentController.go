@@ -0,0 +1,882 @@
+package controllers
+
+import (
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/Hdeee1/go-restaurant-management/database"
+	"github.com/Hdeee1/go-restaurant-management/helpers"
+	"github.com/Hdeee1/go-restaurant-management/models"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type PaymentRequest struct {
+	Amount          *models.Money `json:"amount"`
+	Method          string        `json:"method" validate:"required,eq=CARD|eq=CASH"`
+	Tip             *models.Money `json:"tip"`
+	Card_token      string        `json:"card_token"`
+	Capture         *bool         `json:"capture"`
+	Cash_session_id string        `json:"cash_session_id"`
+}
+
+type RefundRequest struct {
+	Amount *models.Money `json:"amount"`
+}
+
+// GetInvoicePayments godoc
+//
+//	@Summary		Get payments of an invoice
+//	@Description	Retrieve every payment attempt made against an invoice
+//	@Tags			Payments
+//	@Accept			json
+//	@Produce		json
+//	@Param			invoice_id	path	string	true	"Invoice ID"
+//	@Security		BearerAuth
+//	@Success		200	{object}	map[string]interface{}
+//	@Failure		500	{object}	map[string]interface{}
+//	@Router			/invoices/{invoice_id}/payments [get]
+func GetInvoicePayments() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		invoice_id := ctx.Param("invoice_id")
+
+		var payments []models.Payment
+
+		if err := database.DB.Where("invoice_id = ?", invoice_id).Order("created_at").Find(&payments).Error; err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{"payments": payments})
+	}
+}
+
+// CreatePayment godoc
+//
+//	@Summary		Pay an invoice
+//	@Description	Take a payment against an invoice. Card payments go through the payment provider and are captured immediately unless capture is false. Without an amount the outstanding balance is charged; card payments awaiting capture hold their amount of the balance. A tip is charged on top and does not count towards the balance. An amount in another currency is converted at the current exchange rate. Cash goes into the cash drawer session given, which must be the caller's own unless they are an admin, or else the one the caller has open.
+//	@Tags			Payments
+//	@Accept			json
+//	@Produce		json
+//	@Param			invoice_id	path	string			true	"Invoice ID"
+//	@Param			payment		body	PaymentRequest	true	"Payment"
+//	@Security		BearerAuth
+//	@Success		201	{object}	map[string]interface{}
+//	@Failure		400	{object}	map[string]interface{}
+//	@Failure		402	{object}	map[string]interface{}
+//	@Failure		403	{object}	map[string]interface{}
+//	@Failure		404	{object}	map[string]interface{}
+//	@Failure		409	{object}	map[string]interface{}
+//	@Failure		500	{object}	map[string]interface{}
+//	@Failure		502	{object}	map[string]interface{}
+//	@Router			/invoices/{invoice_id}/payments [post]
+func CreatePayment() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		invoice_id := ctx.Param("invoice_id")
+
+		var req PaymentRequest
+
+		if err := ctx.BindJSON(&req); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		if err := helpers.Validate.Struct(req); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		var invoice models.Invoice
+		if err := database.DB.Where("invoice_id = ?", invoice_id).First(&invoice).Error; err != nil {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "invoice_id not found"})
+			return
+		}
+
+		userID := ctx.GetString("user_id")
+
+		// Cash only goes into the caller's own drawer, unless an admin
+		// names another.
+		if req.Method == "CASH" && req.Cash_session_id != "" {
+			var session models.CashDrawerSession
+			if err := database.DB.Where("session_id = ?", req.Cash_session_id).First(&session).Error; err != nil {
+				ctx.JSON(http.StatusNotFound, gin.H{"error": "cash_session_id not found"})
+				return
+			}
+			if !ownsCashSession(ctx, session) {
+				return
+			}
+		}
+
+		var payment models.Payment
+
+		// The invoice row stays locked while the balance is checked and the
+		// payment recorded, so concurrent payments cannot take the same
+		// balance twice. Payments not yet captured hold their amount.
+		err := database.DB.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("invoice_id = ?", invoice_id).First(&invoice).Error; err != nil {
+				return err
+			}
+
+			doc, err := invoiceDocument(tx, invoice_id)
+			if err != nil {
+				return err
+			}
+
+			due := doc.Balance()
+			if !due.IsPositive() {
+				return &orderError{http.StatusBadRequest, "invoice already paid"}
+			}
+
+			held, err := heldAmount(tx, invoice_id, due.Currency)
+			if err != nil {
+				return err
+			}
+
+			balance := due.Sub(held)
+			if !balance.IsPositive() {
+				return &orderError{http.StatusConflict, "the balance is held by payments awaiting capture"}
+			}
+
+			amount := balance
+			if req.Amount != nil {
+				amount = *req.Amount
+			}
+
+			tip := models.Zero(amount.Currency)
+			if req.Tip != nil {
+				tip = *req.Tip
+			}
+
+			if tip.Currency != amount.Currency {
+				return &orderError{http.StatusBadRequest, "tip must be in " + amount.Currency}
+			}
+
+			if !amount.IsPositive() || tip.IsNegative() {
+				return &orderError{http.StatusBadRequest, "amount must be positive"}
+			}
+
+			payment = models.Payment{
+				Payment_id:      uuid.New().String(),
+				Invoice_id:      doc.Invoice_id,
+				Amount:          amount,
+				Refunded_amount: models.Zero(amount.Currency),
+				Tip_amount:      tip,
+				Settled_amount:  amount,
+				Method:          req.Method,
+				Status:          models.PaymentCaptured,
+			}
+
+			// Payments in another currency settle the invoice at today's rate,
+			// which is kept with the payment.
+			if amount.Currency != balance.Currency {
+				settled, rate, err := convertMoney(tx, amount, balance.Currency, time.Now())
+				if errors.Is(err, errNoExchangeRate) {
+					return &orderError{http.StatusBadRequest, err.Error()}
+				}
+				if err != nil {
+					return err
+				}
+				payment.Settled_amount = settled
+				payment.Exchange_rate = &rate
+
+				// Paying off the balance in another currency rarely converts to
+				// the exact minor unit; absorb anything below one unit of the
+				// paid currency.
+				if settled.Cmp(balance) > 0 && amount.Sub(models.Money{Minor: 1}).Convert(rate, balance.Currency).Cmp(balance) < 0 {
+					payment.Settled_amount = balance
+				}
+			}
+
+			if payment.Settled_amount.Cmp(balance) > 0 {
+				return &orderError{http.StatusBadRequest, "amount exceeds the balance due"}
+			}
+
+			// Card payments are recorded as PENDING before the provider is
+			// called, so a charge never goes unrecorded.
+			if req.Method == "CARD" {
+				provider := helpers.Payments.Name()
+				payment.Provider = &provider
+				payment.Status = models.PaymentPending
+				return tx.Create(&payment).Error
+			}
+
+			drawer, err := openCashSession(tx, req.Cash_session_id, userID)
+			if err != nil {
+				return err
+			}
+			if amount.Currency != drawer.Opening_float.Currency {
+				return &orderError{http.StatusBadRequest, "drawer holds " + drawer.Opening_float.Currency}
+			}
+			payment.Cash_session_id = &drawer.Session_id
+
+			if err := tx.Create(&payment).Error; err != nil {
+				return err
+			}
+			return recordCash(tx, drawer, models.CashSale, amount.Add(tip), payment.Payment_id, userID)
+		})
+		if errors.Is(err, errNoCashSession) {
+			ctx.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		if err != nil {
+			ctx.JSON(orderErrorStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+
+		if req.Method == "CARD" {
+			providerErr := authorizeCard(&payment, req)
+
+			// Only a payment still PENDING takes the outcome; a void or
+			// webhook that landed during the provider call wins.
+			result := database.DB.Model(&payment).Where("status = ?", models.PaymentPending).Updates(map[string]interface{}{
+				"status":                  payment.Status,
+				"provider_transaction_id": payment.Provider_transaction_id,
+				"failure_reason":          payment.Failure_reason,
+			})
+			if result.Error != nil {
+				// The payment stays PENDING and holds the balance until it is
+				// reconciled with the provider.
+				log.Printf("payment %s: record %s from provider: %v", payment.Payment_id, payment.Status, result.Error)
+				ctx.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error(), "payment_id": payment.Payment_id})
+				return
+			}
+			if result.RowsAffected == 0 {
+				log.Printf("payment %s: provider returned %s after the payment changed", payment.Payment_id, payment.Status)
+				ctx.JSON(http.StatusConflict, gin.H{"error": "payment changed while the provider was called", "payment_id": payment.Payment_id})
+				return
+			}
+
+			if errors.Is(providerErr, helpers.ErrPaymentDeclined) {
+				ctx.JSON(http.StatusPaymentRequired, gin.H{"error": providerErr.Error(), "payment_id": payment.Payment_id})
+				return
+			}
+			if providerErr != nil {
+				ctx.JSON(http.StatusBadGateway, gin.H{"error": providerErr.Error(), "payment_id": payment.Payment_id})
+				return
+			}
+		}
+
+		if err := settleInvoice(database.DB, payment.Invoice_id); err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		ctx.JSON(http.StatusCreated, gin.H{
+			"message":    "payment " + payment.Status,
+			"payment_id": payment.Payment_id,
+			"status":     payment.Status,
+		})
+	}
+}
+
+// authorizeCard runs a card payment through the provider and records the outcome on the payment.
+func authorizeCard(payment *models.Payment, req PaymentRequest) error {
+	provider := helpers.Payments.Name()
+	payment.Provider = &provider
+
+	result, err := helpers.Payments.Authorize(helpers.PaymentRequest{
+		Amount:     payment.Amount.Add(payment.Tip_amount),
+		Reference:  payment.Payment_id,
+		Card_token: req.Card_token,
+	})
+	if err != nil {
+		return failPayment(payment, err)
+	}
+
+	payment.Provider_transaction_id = &result.Transaction_id
+	payment.Status = models.PaymentAuthorized
+	if result.Pending {
+		payment.Status = models.PaymentPending
+		return nil
+	}
+
+	if req.Capture != nil && !*req.Capture {
+		return nil
+	}
+
+	if _, err := helpers.Payments.Capture(result.Transaction_id, payment.Amount.Add(payment.Tip_amount)); err != nil {
+		return failPayment(payment, err)
+	}
+	payment.Status = models.PaymentCaptured
+
+	return nil
+}
+
+func failPayment(payment *models.Payment, err error) error {
+	reason := err.Error()
+	payment.Status = models.PaymentFailed
+	payment.Failure_reason = &reason
+	return err
+}
+
+// CapturePayment godoc
+//
+//	@Summary		Capture an authorized payment
+//	@Description	Capture a card payment that was only authorized. The invoice must still owe at least the payment.
+//	@Tags			Payments
+//	@Accept			json
+//	@Produce		json
+//	@Param			payment_id	path	string	true	"Payment ID"
+//	@Security		BearerAuth
+//	@Success		200	{object}	map[string]interface{}
+//	@Failure		404	{object}	map[string]interface{}
+//	@Failure		409	{object}	map[string]interface{}
+//	@Failure		500	{object}	map[string]interface{}
+//	@Failure		502	{object}	map[string]interface{}
+//	@Router			/payments/{payment_id}/capture [post]
+func CapturePayment() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		payment, ok := findPayment(ctx)
+		if !ok {
+			return
+		}
+
+		// Capture under the invoice lock and only if the invoice still owes
+		// at least the payment, e.g. after other payments settled it.
+		err := database.DB.Transaction(func(tx *gorm.DB) error {
+			var invoice models.Invoice
+			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("invoice_id = ?", payment.Invoice_id).First(&invoice).Error; err != nil {
+				return err
+			}
+
+			if err := tx.Where("payment_id = ?", payment.Payment_id).First(&payment).Error; err != nil {
+				return err
+			}
+
+			if payment.Status != models.PaymentAuthorized {
+				return &orderError{http.StatusConflict, "payment is " + payment.Status}
+			}
+
+			doc, err := invoiceDocument(tx, payment.Invoice_id)
+			if err != nil {
+				return err
+			}
+
+			if payment.Settled_amount.Cmp(doc.Balance()) > 0 {
+				return &orderError{http.StatusConflict, "payment exceeds the balance due; void it instead"}
+			}
+
+			if _, err := helpers.Payments.Capture(*payment.Provider_transaction_id, payment.Amount.Add(payment.Tip_amount)); err != nil {
+				return &orderError{http.StatusBadGateway, err.Error()}
+			}
+
+			return tx.Model(&payment).Update("status", models.PaymentCaptured).Error
+		})
+		if err != nil {
+			ctx.JSON(orderErrorStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+
+		if err := settleInvoice(database.DB, payment.Invoice_id); err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{
+			"message":    "payment captured",
+			"payment_id": payment.Payment_id,
+		})
+	}
+}
+
+// VoidPayment godoc
+//
+//	@Summary		Void a payment
+//	@Description	Release a card authorization that has not been captured
+//	@Tags			Payments
+//	@Accept			json
+//	@Produce		json
+//	@Param			payment_id	path	string	true	"Payment ID"
+//	@Security		BearerAuth
+//	@Success		200	{object}	map[string]interface{}
+//	@Failure		404	{object}	map[string]interface{}
+//	@Failure		409	{object}	map[string]interface{}
+//	@Failure		500	{object}	map[string]interface{}
+//	@Failure		502	{object}	map[string]interface{}
+//	@Router			/payments/{payment_id}/void [post]
+func VoidPayment() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		payment, ok := findPayment(ctx)
+		if !ok {
+			return
+		}
+
+		if payment.Status != models.PaymentAuthorized && payment.Status != models.PaymentPending {
+			ctx.JSON(http.StatusConflict, gin.H{"error": "payment is " + payment.Status})
+			return
+		}
+
+		// A payment that never reached the provider has nothing to release.
+		if payment.Provider_transaction_id != nil {
+			if _, err := helpers.Payments.Void(*payment.Provider_transaction_id); err != nil {
+				ctx.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+				return
+			}
+		}
+
+		result := database.DB.Model(&payment).Where("status = ?", payment.Status).Updates(map[string]interface{}{
+			"status":    models.PaymentVoided,
+			"voided_at": time.Now(),
+		})
+		if result.Error != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
+			return
+		}
+		if result.RowsAffected == 0 {
+			ctx.JSON(http.StatusConflict, gin.H{"error": "payment changed while it was being voided"})
+			return
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{
+			"message":    "payment voided",
+			"payment_id": payment.Payment_id,
+		})
+	}
+}
+
+// RefundPayment godoc
+//
+//	@Summary		Refund a payment (Admin only)
+//	@Description	Refund all or part of a captured payment. Card refunds are reserved as PENDING, then go through the payment provider; cash refunds are paid from an open cash drawer.
+//	@Tags			Payments
+//	@Accept			json
+//	@Produce		json
+//	@Param			payment_id	path	string			true	"Payment ID"
+//	@Param			refund		body	RefundRequest	false	"Refund"
+//	@Security		BearerAuth
+//	@Success		200	{object}	map[string]interface{}
+//	@Failure		400	{object}	map[string]interface{}
+//	@Failure		404	{object}	map[string]interface{}
+//	@Failure		409	{object}	map[string]interface{}
+//	@Failure		500	{object}	map[string]interface{}
+//	@Failure		502	{object}	map[string]interface{}
+//	@Router			/payments/{payment_id}/refund [post]
+func RefundPayment() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var req RefundRequest
+		if ctx.Request.ContentLength > 0 {
+			if err := ctx.BindJSON(&req); err != nil {
+				ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+		}
+
+		if err := helpers.Validate.Struct(req); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		payment, ok := findPayment(ctx)
+		if !ok {
+			return
+		}
+
+		userID := ctx.GetString("user_id")
+
+		// Cash is handed back from the refunder's drawer, or else from the
+		// drawer the payment went into if that is still open.
+		var drawer models.CashDrawerSession
+		if payment.Method == "CASH" {
+			var err error
+			drawer, err = openCashSession(database.DB, "", userID)
+			if errors.Is(err, errNoCashSession) && payment.Cash_session_id != nil {
+				drawer, err = openCashSession(database.DB, *payment.Cash_session_id, "")
+			}
+			if errors.Is(err, errNoCashSession) {
+				ctx.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+				return
+			}
+			if err != nil {
+				ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+		}
+
+		// The refund is checked against what is left to refund under the
+		// payment lock. A card refund is reserved as PENDING before the
+		// provider is called, so a concurrent refund cannot take the same
+		// amount; a cash refund is recorded and paid out at once.
+		var refund models.PaymentRefund
+		err := database.DB.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("payment_id = ?", payment.Payment_id).First(&payment).Error; err != nil {
+				return err
+			}
+
+			if payment.Status != models.PaymentCaptured {
+				return &orderError{http.StatusConflict, "payment is " + payment.Status}
+			}
+
+			var pending int64
+			err := tx.Model(&models.PaymentRefund{}).
+				Where("payment_id = ? AND status = ?", payment.Payment_id, models.RefundPending).
+				Select("COALESCE(SUM(amount_minor), 0)").
+				Scan(&pending).Error
+			if err != nil {
+				return err
+			}
+
+			refundable := payment.Amount.Sub(payment.Refunded_amount).Sub(models.Money{Minor: pending})
+			amount := refundable
+			if req.Amount != nil {
+				amount = *req.Amount
+			}
+
+			if amount.Currency != refundable.Currency {
+				return &orderError{http.StatusBadRequest, "refund must be in " + refundable.Currency}
+			}
+			if !amount.IsPositive() {
+				return &orderError{http.StatusBadRequest, "amount must be positive"}
+			}
+			if amount.Cmp(refundable) > 0 {
+				return &orderError{http.StatusBadRequest, "amount exceeds the refundable amount"}
+			}
+
+			refund = models.PaymentRefund{
+				Refund_id:  uuid.New().String(),
+				Payment_id: payment.Payment_id,
+				Invoice_id: payment.Invoice_id,
+				Method:     payment.Method,
+				Amount:     amount,
+				Status:     models.RefundPending,
+			}
+
+			if payment.Provider_transaction_id != nil {
+				return tx.Create(&refund).Error
+			}
+
+			if payment.Method == "CASH" && amount.Currency != drawer.Opening_float.Currency {
+				return &orderError{http.StatusBadRequest, "drawer holds " + drawer.Opening_float.Currency}
+			}
+
+			refunded, err := applyRefund(tx, &payment, refund)
+			if err != nil {
+				return err
+			}
+			if payment.Method != "CASH" {
+				return nil
+			}
+			return recordCash(tx, drawer, models.CashRefund, refunded.Neg(), payment.Payment_id, userID)
+		})
+		if errors.Is(err, errNoCashSession) {
+			ctx.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		if err != nil {
+			ctx.JSON(orderErrorStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+
+		if payment.Provider_transaction_id != nil {
+			if status, err := refundWithProvider(&payment, refund); err != nil {
+				ctx.JSON(status, gin.H{"error": err.Error(), "refund_id": refund.Refund_id})
+				return
+			}
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{
+			"message":    "payment refunded",
+			"payment_id": payment.Payment_id,
+			"refunded":   refund.Amount,
+		})
+	}
+}
+
+// refundWithProvider sends a reserved PENDING refund to the provider and
+// completes it, or marks it FAILED when the provider refuses it. It returns
+// the HTTP status to report on failure.
+func refundWithProvider(payment *models.Payment, refund models.PaymentRefund) (int, error) {
+	result, err := helpers.Payments.Refund(*payment.Provider_transaction_id, refund.Amount, refund.Refund_id)
+	if err != nil {
+		if err := database.DB.Model(&refund).Where("status = ?", models.RefundPending).Update("status", models.RefundFailed).Error; err != nil {
+			log.Printf("refund %s: mark failed: %v", refund.Refund_id, err)
+		}
+		return http.StatusBadGateway, err
+	}
+	refund.Provider_refund_id = &result.Transaction_id
+
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		refunded, err := applyRefund(tx, payment, refund)
+		if err != nil || refunded.IsPositive() {
+			return err
+		}
+
+		// Nothing was applied. That is only fine when the provider already
+		// reported this very refund by webhook; the reservation is dropped.
+		recorded, err := refundRecorded(tx, result.Transaction_id)
+		if err != nil {
+			return err
+		}
+		if !recorded {
+			return &orderError{http.StatusConflict, "payment is " + payment.Status}
+		}
+		return tx.Delete(&refund).Error
+	})
+	if err != nil {
+		// The provider has refunded the money; the PENDING refund stays so it
+		// can be reconciled.
+		log.Printf("refund %s: record provider refund %s: %v", refund.Refund_id, result.Transaction_id, err)
+		return orderErrorStatus(err), err
+	}
+
+	return http.StatusOK, nil
+}
+
+// PaymentWebhook godoc
+//
+//	@Summary		Payment provider webhook
+//	@Description	Receive asynchronous payment updates from the provider. The raw body must be signed with the webhook secret in the X-Signature header. Each event_id is applied once; retried events are acknowledged without effect.
+//	@Tags			Payments
+//	@Accept			json
+//	@Produce		json
+//	@Param			X-Signature	header	string	true	"Hex HMAC-SHA256 of the body"
+//	@Success		200	{object}	map[string]interface{}
+//	@Failure		400	{object}	map[string]interface{}
+//	@Failure		401	{object}	map[string]interface{}
+//	@Failure		404	{object}	map[string]interface{}
+//	@Failure		500	{object}	map[string]interface{}
+//	@Router			/payments/webhook [post]
+func PaymentWebhook() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		payload, err := io.ReadAll(io.LimitReader(ctx.Request.Body, 1<<20))
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		event, err := helpers.Payments.ParseWebhook(payload, ctx.GetHeader("X-Signature"))
+		if err != nil {
+			ctx.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+
+		if event.Event_id == "" {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "event_id is required"})
+			return
+		}
+
+		var payment models.Payment
+
+		if err := database.DB.Where("provider_transaction_id = ?", event.Transaction_id).First(&payment).Error; err != nil {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "transaction_id not found"})
+			return
+		}
+
+		switch event.Type {
+		case helpers.WebhookPaymentCaptured, helpers.WebhookPaymentFailed:
+		case helpers.WebhookPaymentRefunded:
+			if event.Amount.Currency != payment.Amount.Currency {
+				ctx.JSON(http.StatusBadRequest, gin.H{"error": "refund must be in " + payment.Amount.Currency})
+				return
+			}
+			if !event.Amount.IsPositive() {
+				ctx.JSON(http.StatusBadRequest, gin.H{"error": "refund amount must be positive"})
+				return
+			}
+		default:
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "unknown event type " + event.Type})
+			return
+		}
+
+		// The event is recorded in the same transaction it is applied in, so
+		// a retried event is skipped and a failed one can be retried.
+		duplicate := false
+		err = database.DB.Transaction(func(tx *gorm.DB) error {
+			seen := models.PaymentEvent{
+				Provider:       helpers.Payments.Name(),
+				Event_id:       event.Event_id,
+				Type:           event.Type,
+				Transaction_id: event.Transaction_id,
+			}
+			result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&seen)
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				duplicate = true
+				return nil
+			}
+
+			open := []string{models.PaymentPending, models.PaymentAuthorized}
+			switch event.Type {
+			case helpers.WebhookPaymentCaptured:
+				err := tx.Model(&payment).Where("status IN ?", open).Update("status", models.PaymentCaptured).Error
+				if err != nil {
+					return err
+				}
+			case helpers.WebhookPaymentFailed:
+				err := tx.Model(&payment).Where("status IN ?", open).Updates(map[string]interface{}{
+					"status":         models.PaymentFailed,
+					"failure_reason": event.Reason,
+				}).Error
+				if err != nil {
+					return err
+				}
+			case helpers.WebhookPaymentRefunded:
+				refund := models.PaymentRefund{Refund_id: uuid.New().String(), Amount: event.Amount}
+				if event.Refund_id != "" {
+					refund.Provider_refund_id = &event.Refund_id
+				}
+				if _, err := applyRefund(tx, &payment, refund); err != nil {
+					return err
+				}
+			}
+
+			return settleInvoice(tx, payment.Invoice_id)
+		})
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		if duplicate {
+			ctx.JSON(http.StatusOK, gin.H{"message": "event already processed"})
+			return
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{"message": "event processed"})
+	}
+}
+
+func findPayment(ctx *gin.Context) (models.Payment, bool) {
+	var payment models.Payment
+
+	if err := database.DB.Where("payment_id = ?", ctx.Param("payment_id")).First(&payment).Error; err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "payment_id not found"})
+		return payment, false
+	}
+
+	return payment, true
+}
+
+// applyRefund records a refund against a captured payment and returns the
+// amount refunded, which is zero when it was skipped. The payment row is
+// locked and re-read so concurrent refunds add up, and a provider refund that
+// is already recorded is skipped. A new refund row is created, or a PENDING
+// one reserved by RefundPayment is completed.
+func applyRefund(db *gorm.DB, payment *models.Payment, refund models.PaymentRefund) (models.Money, error) {
+	skipped := models.Zero(refund.Amount.Currency)
+
+	if err := db.Clauses(clause.Locking{Strength: "UPDATE"}).Where("payment_id = ?", payment.Payment_id).First(payment).Error; err != nil {
+		return skipped, err
+	}
+
+	if refund.Provider_refund_id != nil {
+		recorded, err := refundRecorded(db, *refund.Provider_refund_id)
+		if err != nil || recorded {
+			return skipped, err
+		}
+	}
+
+	if payment.Status != models.PaymentCaptured {
+		return skipped, nil
+	}
+
+	refunded := payment.Refunded_amount.Add(refund.Amount)
+	status := models.PaymentCaptured
+	if refunded.Cmp(payment.Amount) >= 0 {
+		refunded = payment.Amount
+		status = models.PaymentRefunded
+	}
+
+	refund.Payment_id = payment.Payment_id
+	refund.Invoice_id = payment.Invoice_id
+	refund.Method = payment.Method
+	refund.Amount = refunded.Sub(payment.Refunded_amount)
+	refund.Status = models.RefundCompleted
+
+	if err := db.Save(&refund).Error; err != nil {
+		return skipped, err
+	}
+
+	err := db.Model(payment).Updates(map[string]interface{}{
+		"refunded_minor":    refunded.Minor,
+		"refunded_currency": refunded.Currency,
+		"status":            status,
+	}).Error
+	if err != nil {
+		return skipped, err
+	}
+	payment.Refunded_amount = refunded
+	payment.Status = status
+
+	return refund.Amount, settleInvoice(db, payment.Invoice_id)
+}
+
+// refundRecorded reports whether a provider refund has been recorded.
+func refundRecorded(db *gorm.DB, providerRefundID string) (bool, error) {
+	var recorded int64
+	err := db.Model(&models.PaymentRefund{}).Where("provider_refund_id = ?", providerRefundID).Count(&recorded).Error
+	return recorded > 0, err
+}
+
+// heldAmount is what payments awaiting capture hold of an invoice's balance,
+// in the invoice's currency.
+func heldAmount(db *gorm.DB, invoiceID string, currency string) (models.Money, error) {
+	var total int64
+
+	err := db.Model(&models.Payment{}).
+		Where("invoice_id = ? AND status IN ?", invoiceID, []string{models.PaymentPending, models.PaymentAuthorized}).
+		Select("COALESCE(SUM(settled_minor), 0)").
+		Scan(&total).Error
+
+	return models.Money{Minor: total, Currency: currency}, err
+}
+
+// capturedPayments returns the payments that moved money for invoices.
+func capturedPayments(db *gorm.DB, invoiceIDs ...string) ([]models.Payment, error) {
+	var payments []models.Payment
+
+	err := db.Where("invoice_id IN ? AND status IN ?", invoiceIDs, []string{models.PaymentCaptured, models.PaymentRefunded}).
+		Order("created_at").Find(&payments).Error
+
+	return payments, err
+}
+
+// settleInvoice marks an invoice PAID once its captured payments cover the
+// total, or back to PENDING after refunds, and updates the table accordingly.
+func settleInvoice(db *gorm.DB, invoiceID string) error {
+	doc, err := invoiceDocument(db, invoiceID)
+	if err != nil {
+		return err
+	}
+
+	payments, err := capturedPayments(db, invoiceID)
+	if err != nil {
+		return err
+	}
+
+	if len(payments) == 0 {
+		return nil
+	}
+
+	status := "PENDING"
+	if !doc.Balance().IsPositive() {
+		status = "PAID"
+	}
+	method := payments[len(payments)-1].Method
+
+	var invoice models.Invoice
+	if err := db.Where("invoice_id = ?", invoiceID).First(&invoice).Error; err != nil {
+		return err
+	}
+
+	if invoice.Payment_status != nil && *invoice.Payment_status == status {
+		return nil
+	}
+
+	err = db.Model(&invoice).Updates(map[string]interface{}{
+		"payment_status": status,
+		"payment_method": method,
+	}).Error
+	if err != nil {
+		return err
+	}
+
+	invoice.Payment_status = &status
+	return syncTableWithInvoice(db, invoice)
+}
@@ -0,0 +1,291 @@
+package controllers
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Hdeee1/go-restaurant-management/database"
+	"github.com/Hdeee1/go-restaurant-management/helpers"
+	"github.com/Hdeee1/go-restaurant-management/models"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type PriceChangeRequest struct {
+	Price        *models.Money `json:"price" validate:"required"`
+	Effective_at *time.Time    `json:"effective_at" validate:"required"`
+	Base         *bool         `json:"base"`
+	Reason       *string       `json:"reason" validate:"omitempty,max=255"`
+}
+
+// PricePeriod is a stretch of time a food had one price. To is nil for the
+// price that is current, or last scheduled.
+type PricePeriod struct {
+	Base      bool          `json:"base"`
+	Currency  string        `json:"currency"`
+	Price     *models.Money `json:"price"`
+	From      time.Time     `json:"from"`
+	To        *time.Time    `json:"to"`
+	Scheduled bool          `json:"scheduled"`
+}
+
+// GetPriceChanges godoc
+//
+//	@Summary		Get a food's price history
+//	@Description	Retrieve every recorded and scheduled price change of a food, and its price timeline: one period per price, for the base price and each currency price
+//	@Tags			Foods
+//	@Accept			json
+//	@Produce		json
+//	@Param			food_id		path	string	true	"Food ID"
+//	@Param			currency	query	string	false	"Only prices in this currency"
+//	@Param			from		query	string	false	"Only periods after this date (YYYY-MM-DD or RFC3339)"
+//	@Param			to			query	string	false	"Only periods before this date, inclusive (YYYY-MM-DD or RFC3339)"
+//	@Security		BearerAuth
+//	@Success		200	{object}	map[string]interface{}
+//	@Failure		400	{object}	map[string]interface{}
+//	@Failure		404	{object}	map[string]interface{}
+//	@Failure		500	{object}	map[string]interface{}
+//	@Router			/foods/{food_id}/price-changes [get]
+func GetPriceChanges() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		foodID := ctx.Param("food_id")
+
+		var food models.Food
+		if err := database.DB.Where("food_id = ?", foodID).First(&food).Error; err != nil {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "food_id not found"})
+			return
+		}
+
+		var from, to *time.Time
+		for param, bound := range map[string]**time.Time{"from": &from, "to": &to} {
+			value := ctx.Query(param)
+			if value == "" {
+				continue
+			}
+			t, isDate, err := parseReportTime(value)
+			if err != nil {
+				ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid " + param + ": " + err.Error()})
+				return
+			}
+			if param == "to" && isDate {
+				t = t.AddDate(0, 0, 1)
+			}
+			*bound = &t
+		}
+
+		query := database.DB.Where("food_id = ? AND status <> ?", foodID, models.PriceChangeCancelled)
+		if currency := ctx.Query("currency"); currency != "" {
+			query = query.Where("currency = ?", strings.ToUpper(currency))
+		}
+
+		var changes []models.PriceChange
+		if err := query.Order("effective_at, id").Find(&changes).Error; err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		timeline := []PricePeriod{}
+		for _, period := range priceTimeline(food, changes) {
+			if from != nil && period.To != nil && !period.To.After(*from) {
+				continue
+			}
+			if to != nil && !period.From.Before(*to) {
+				continue
+			}
+			timeline = append(timeline, period)
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{
+			"food_id":  foodID,
+			"changes":  changes,
+			"timeline": timeline,
+		})
+	}
+}
+
+// SchedulePriceChange godoc
+//
+//	@Summary		Schedule a price change (Admin only)
+//	@Description	Schedule a food's price to change at a future time. The change applies to the base price when base is true, or when it is omitted and the price is in the base price's currency; otherwise to the food's price in that currency.
+//	@Tags			Foods
+//	@Accept			json
+//	@Produce		json
+//	@Param			food_id	path	string				true	"Food ID"
+//	@Param			change	body	PriceChangeRequest	true	"New price and when it takes effect"
+//	@Security		BearerAuth
+//	@Success		201	{object}	map[string]interface{}
+//	@Failure		400	{object}	map[string]interface{}
+//	@Failure		404	{object}	map[string]interface{}
+//	@Failure		500	{object}	map[string]interface{}
+//	@Router			/foods/{food_id}/price-changes [post]
+func SchedulePriceChange() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		foodID := ctx.Param("food_id")
+
+		var req PriceChangeRequest
+
+		if err := ctx.BindJSON(&req); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		if err := helpers.Validate.Struct(req); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		if req.Price.IsNegative() {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "price must not be negative"})
+			return
+		}
+
+		if !req.Effective_at.After(time.Now()) {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "effective_at must be in the future"})
+			return
+		}
+
+		var food models.Food
+		if err := database.DB.Where("food_id = ?", foodID).First(&food).Error; err != nil {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "food_id not found"})
+			return
+		}
+
+		base := food.Price == nil || food.Price.Currency == req.Price.Currency
+		if req.Base != nil {
+			base = *req.Base
+		}
+
+		change := models.PriceChange{
+			Price_change_id: uuid.New().String(),
+			Food_id:         foodID,
+			Base:            base,
+			Currency:        req.Price.Currency,
+			New_price:       req.Price,
+			Effective_at:    *req.Effective_at,
+			Status:          models.PriceChangeScheduled,
+			Changed_by:      ctx.GetString("user_id"),
+			Reason:          req.Reason,
+		}
+
+		if err := database.DB.Create(&change).Error; err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		ctx.JSON(http.StatusCreated, gin.H{
+			"message":         "price change scheduled",
+			"price_change_id": change.Price_change_id,
+			"effective_at":    change.Effective_at,
+		})
+	}
+}
+
+// CancelPriceChange godoc
+//
+//	@Summary		Cancel a scheduled price change (Admin only)
+//	@Description	Cancel a price change that has not taken effect yet
+//	@Tags			Foods
+//	@Accept			json
+//	@Produce		json
+//	@Param			food_id			path	string	true	"Food ID"
+//	@Param			price_change_id	path	string	true	"Price change ID"
+//	@Security		BearerAuth
+//	@Success		200	{object}	map[string]interface{}
+//	@Failure		404	{object}	map[string]interface{}
+//	@Failure		409	{object}	map[string]interface{}
+//	@Failure		500	{object}	map[string]interface{}
+//	@Router			/foods/{food_id}/price-changes/{price_change_id} [delete]
+func CancelPriceChange() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var change models.PriceChange
+
+		err := database.DB.Where("food_id = ? AND price_change_id = ?", ctx.Param("food_id"), ctx.Param("price_change_id")).First(&change).Error
+		if err != nil {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "price_change_id not found"})
+			return
+		}
+
+		// Only a change still scheduled can be cancelled; the scheduler may apply it meanwhile.
+		result := database.DB.Model(&change).Where("status = ?", models.PriceChangeScheduled).Update("status", models.PriceChangeCancelled)
+		if result.Error != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
+			return
+		}
+		if result.RowsAffected == 0 {
+			ctx.JSON(http.StatusConflict, gin.H{"error": "price change is " + change.Status})
+			return
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{
+			"message":         "price change cancelled",
+			"price_change_id": change.Price_change_id,
+		})
+	}
+}
+
+// recordPriceChange records a price change that has just been made.
+func recordPriceChange(tx *gorm.DB, food models.Food, base bool, currency string, old *models.Money, price *models.Money, userID string) error {
+	now := time.Now()
+
+	change := models.PriceChange{
+		Price_change_id: uuid.New().String(),
+		Food_id:         food.Food_id,
+		Base:            base,
+		Currency:        currency,
+		Old_price:       old,
+		New_price:       price,
+		Effective_at:    now,
+		Status:          models.PriceChangeApplied,
+		Changed_by:      userID,
+		Applied_at:      &now,
+	}
+
+	return tx.Create(&change).Error
+}
+
+// priceTimeline turns a food's price changes, in order, into the periods each
+// price was (or is scheduled to be) in effect. A price that was in place
+// before the first recorded change is dated from the food's creation.
+func priceTimeline(food models.Food, changes []models.PriceChange) []PricePeriod {
+	var periods []PricePeriod
+	open := map[string]int{}
+
+	for _, change := range changes {
+		key := change.Currency
+		if change.Base {
+			key = "base"
+		}
+
+		i, ok := open[key]
+		switch {
+		case ok:
+			periods[i].To = &change.Effective_at
+		case change.Old_price != nil:
+			periods = append(periods, PricePeriod{
+				Base:     change.Base,
+				Currency: change.Old_price.Currency,
+				Price:    change.Old_price,
+				From:     food.CreatedAt,
+				To:       &change.Effective_at,
+			})
+		}
+		delete(open, key)
+
+		if change.New_price == nil {
+			continue
+		}
+
+		open[key] = len(periods)
+		periods = append(periods, PricePeriod{
+			Base:      change.Base,
+			Currency:  change.New_price.Currency,
+			Price:     change.New_price,
+			From:      change.Effective_at,
+			Scheduled: change.Status == models.PriceChangeScheduled,
+		})
+	}
+
+	return periods
+}
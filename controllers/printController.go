@@ -0,0 +1,587 @@
+package controllers
+
+import (
+	"log"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Hdeee1/go-restaurant-management/database"
+	"github.com/Hdeee1/go-restaurant-management/helpers"
+	"github.com/Hdeee1/go-restaurant-management/models"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	printKitchenTicket = "KITCHEN_TICKET"
+	printReceipt       = "RECEIPT"
+)
+
+// GetPrinters godoc
+//
+//	@Summary		Get all printers
+//	@Description	Retrieve the printer registry mapping stations to network printers
+//	@Tags			Printing
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Success		200	{object}	map[string]interface{}
+//	@Failure		500	{object}	map[string]interface{}
+//	@Router			/printers [get]
+func GetPrinters() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var printers []models.Printer
+
+		if err := database.DB.Order("station").Find(&printers).Error; err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{"printers": printers})
+	}
+}
+
+// CreatePrinter godoc
+//
+//	@Summary		Register a printer (Admin only)
+//	@Description	Register a network printer (host:port, usually port 9100) for a station such as KITCHEN, BAR or RECEIPT
+//	@Tags			Printing
+//	@Accept			json
+//	@Produce		json
+//	@Param			printer	body	models.Printer	true	"Printer object"
+//	@Security		BearerAuth
+//	@Success		201	{object}	map[string]interface{}
+//	@Failure		400	{object}	map[string]interface{}
+//	@Failure		500	{object}	map[string]interface{}
+//	@Router			/printers [post]
+func CreatePrinter() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var printer models.Printer
+
+		if err := ctx.BindJSON(&printer); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		if err := helpers.Validate.Struct(printer); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		printer.Printer_id = uuid.New().String()
+		station := strings.ToUpper(*printer.Station)
+		printer.Station = &station
+
+		if err := database.DB.Create(&printer).Error; err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		ctx.JSON(http.StatusCreated, gin.H{
+			"message":    "printer created",
+			"printer_id": printer.Printer_id,
+		})
+	}
+}
+
+// UpdatePrinter godoc
+//
+//	@Summary		Update a printer (Admin only)
+//	@Description	Update a printer by printer_id, e.g. to move it to another station or disable it
+//	@Tags			Printing
+//	@Accept			json
+//	@Produce		json
+//	@Param			printer_id	path	string			true	"Printer ID"
+//	@Param			printer		body	models.Printer	true	"Printer object"
+//	@Security		BearerAuth
+//	@Success		200	{object}	map[string]interface{}
+//	@Failure		400	{object}	map[string]interface{}
+//	@Failure		404	{object}	map[string]interface{}
+//	@Failure		500	{object}	map[string]interface{}
+//	@Router			/printers/{printer_id} [patch]
+func UpdatePrinter() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		printerID := ctx.Param("printer_id")
+
+		var printer models.Printer
+
+		if err := database.DB.Where("printer_id = ?", printerID).First(&printer).Error; err != nil {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "printer_id not found"})
+			return
+		}
+
+		var updateData models.Printer
+		if err := ctx.BindJSON(&updateData); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		if updateData.Address != nil {
+			if err := helpers.Validate.Var(*updateData.Address, "hostname_port"); err != nil {
+				ctx.JSON(http.StatusBadRequest, gin.H{"error": "address must be host:port"})
+				return
+			}
+		}
+
+		if updateData.Station != nil {
+			station := strings.ToUpper(*updateData.Station)
+			updateData.Station = &station
+		}
+
+		if err := database.DB.Model(&printer).Updates(updateData).Error; err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{
+			"message":    "printer updated",
+			"printer_id": printer.Printer_id,
+		})
+	}
+}
+
+// GetPrintJobs godoc
+//
+//	@Summary		Get print jobs
+//	@Description	Retrieve a paginated list of print jobs, newest first
+//	@Tags			Printing
+//	@Accept			json
+//	@Produce		json
+//	@Param			status	query	string	false	"QUEUED, PRINTING, PRINTED or FAILED"
+//	@Param			page	query	int		false	"Page number"		default(1)
+//	@Param			limit	query	int		false	"Items per page"	default(10)
+//	@Security		BearerAuth
+//	@Success		200	{object}	map[string]interface{}
+//	@Failure		500	{object}	map[string]interface{}
+//	@Router			/print-jobs [get]
+func GetPrintJobs() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var jobs []models.PrintJob
+
+		query := database.DB.Scopes(helpers.Paginate(ctx)).Order("created_at DESC")
+		if status := ctx.Query("status"); status != "" {
+			query = query.Where("status = ?", status)
+		}
+
+		if err := query.Find(&jobs).Error; err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{
+			"print_jobs": jobs,
+			"page":       ctx.DefaultQuery("page", "1"),
+			"limit":      ctx.DefaultQuery("limit", "10"),
+		})
+	}
+}
+
+// RetryPrintJob godoc
+//
+//	@Summary		Retry a print job
+//	@Description	Put a failed print job back in the queue
+//	@Tags			Printing
+//	@Accept			json
+//	@Produce		json
+//	@Param			print_job_id	path	string	true	"Print job ID"
+//	@Security		BearerAuth
+//	@Success		200	{object}	map[string]interface{}
+//	@Failure		404	{object}	map[string]interface{}
+//	@Failure		409	{object}	map[string]interface{}
+//	@Failure		500	{object}	map[string]interface{}
+//	@Router			/print-jobs/{print_job_id}/retry [post]
+func RetryPrintJob() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		jobID := ctx.Param("print_job_id")
+
+		var job models.PrintJob
+
+		if err := database.DB.Where("print_job_id = ?", jobID).First(&job).Error; err != nil {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "print_job_id not found"})
+			return
+		}
+
+		// A job being sent right now is left to finish.
+		result := database.DB.Model(&job).Where("status <> ?", models.PrintPrinting).Updates(map[string]interface{}{
+			"status":          models.PrintQueued,
+			"attempts":        0,
+			"next_attempt_at": time.Now(),
+		})
+		if result.Error != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
+			return
+		}
+		if result.RowsAffected == 0 {
+			ctx.JSON(http.StatusConflict, gin.H{"error": "print job is being printed"})
+			return
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{
+			"message":      "print job queued",
+			"print_job_id": job.Print_job_id,
+		})
+	}
+}
+
+// GetOrderTicket godoc
+//
+//	@Summary		Preview kitchen tickets
+//	@Description	Render the kitchen tickets for an order's fired items, one per station, as plain text or ESC/POS
+//	@Tags			Printing
+//	@Produce		plain
+//	@Produce		octet-stream
+//	@Param			order_id	path	string	true	"Order ID"
+//	@Param			format		query	string	false	"text or escpos"	default(text)
+//	@Security		BearerAuth
+//	@Success		200	{string}	string
+//	@Failure		404	{object}	map[string]interface{}
+//	@Failure		500	{object}	map[string]interface{}
+//	@Router			/orders/{order_id}/ticket [get]
+func GetOrderTicket() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		order_id := ctx.Param("order_id")
+
+		tickets, err := kitchenTickets(database.DB, order_id, nil)
+		if err == gorm.ErrRecordNotFound {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "order_id not found"})
+			return
+		}
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		docs := make([]*helpers.PrintDocument, 0, len(tickets))
+		for _, ticket := range tickets {
+			docs = append(docs, ticket.Document())
+		}
+
+		writeDocuments(ctx, docs)
+	}
+}
+
+// PrintOrderTicket godoc
+//
+//	@Summary		Print kitchen tickets
+//	@Description	Queue the kitchen tickets for an order's fired items on each station's printers
+//	@Tags			Printing
+//	@Accept			json
+//	@Produce		json
+//	@Param			order_id	path	string	true	"Order ID"
+//	@Security		BearerAuth
+//	@Success		200	{object}	map[string]interface{}
+//	@Failure		404	{object}	map[string]interface{}
+//	@Failure		500	{object}	map[string]interface{}
+//	@Router			/orders/{order_id}/print [post]
+func PrintOrderTicket() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		order_id := ctx.Param("order_id")
+
+		jobs, err := queueKitchenTickets(database.DB, order_id, nil)
+		if err == gorm.ErrRecordNotFound {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "order_id not found"})
+			return
+		}
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{
+			"message":    "tickets queued",
+			"print_jobs": jobs,
+		})
+	}
+}
+
+// GetInvoiceReceipt godoc
+//
+//	@Summary		Preview a receipt
+//	@Description	Render the customer receipt for an invoice as plain text or ESC/POS
+//	@Tags			Printing
+//	@Produce		plain
+//	@Produce		octet-stream
+//	@Param			invoice_id	path	string	true	"Invoice ID"
+//	@Param			format		query	string	false	"text or escpos"	default(text)
+//	@Security		BearerAuth
+//	@Success		200	{string}	string
+//	@Failure		404	{object}	map[string]interface{}
+//	@Failure		500	{object}	map[string]interface{}
+//	@Router			/invoices/{invoice_id}/receipt [get]
+func GetInvoiceReceipt() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		invoice_id := ctx.Param("invoice_id")
+
+		receipt, err := invoiceReceipt(database.DB, invoice_id)
+		if err == gorm.ErrRecordNotFound {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "invoice_id not found"})
+			return
+		}
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		writeDocuments(ctx, []*helpers.PrintDocument{receipt.Document()})
+	}
+}
+
+// PrintInvoiceReceipt godoc
+//
+//	@Summary		Print a receipt
+//	@Description	Queue the customer receipt for an invoice on the RECEIPT station printers
+//	@Tags			Printing
+//	@Accept			json
+//	@Produce		json
+//	@Param			invoice_id	path	string	true	"Invoice ID"
+//	@Security		BearerAuth
+//	@Success		200	{object}	map[string]interface{}
+//	@Failure		404	{object}	map[string]interface{}
+//	@Failure		409	{object}	map[string]interface{}
+//	@Failure		500	{object}	map[string]interface{}
+//	@Router			/invoices/{invoice_id}/print [post]
+func PrintInvoiceReceipt() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		invoice_id := ctx.Param("invoice_id")
+
+		receipt, err := invoiceReceipt(database.DB, invoice_id)
+		if err == gorm.ErrRecordNotFound {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "invoice_id not found"})
+			return
+		}
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		jobs, err := queueDocument(database.DB, models.StationReceipt, printReceipt, invoice_id, receipt.Document())
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		if len(jobs) == 0 {
+			ctx.JSON(http.StatusConflict, gin.H{"error": "no printer registered for station " + models.StationReceipt})
+			return
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{
+			"message":    "receipt queued",
+			"print_jobs": jobs,
+		})
+	}
+}
+
+func writeDocuments(ctx *gin.Context, docs []*helpers.PrintDocument) {
+	if ctx.DefaultQuery("format", "text") == "escpos" {
+		var stream []byte
+		for _, doc := range docs {
+			stream = append(stream, doc.ESCPOS()...)
+		}
+		ctx.Data(http.StatusOK, "application/octet-stream", stream)
+		return
+	}
+
+	var text strings.Builder
+	for _, doc := range docs {
+		text.WriteString(doc.PlainText())
+	}
+	ctx.String(http.StatusOK, text.String())
+}
+
+// kitchenTickets builds one ticket per station for an order's fired items.
+// When itemIDs is given only those items are included.
+func kitchenTickets(db *gorm.DB, orderID string, itemIDs []string) ([]helpers.KitchenTicket, error) {
+	var order models.Order
+
+	if err := db.Preload("OrderItems").Where("order_id = ?", orderID).First(&order).Error; err != nil {
+		return nil, err
+	}
+
+	var table models.Table
+	if order.Table_id != nil {
+		db.Where("table_id = ?", *order.Table_id).First(&table)
+	}
+
+	foods, err := foodsByID(db, order.OrderItems)
+	if err != nil {
+		return nil, err
+	}
+
+	wanted := make(map[string]bool)
+	for _, id := range itemIDs {
+		wanted[id] = true
+	}
+
+	tickets := make(map[string]*helpers.KitchenTicket)
+	for _, item := range order.OrderItems {
+		if item.Fire_status != models.ItemFired || (itemIDs != nil && !wanted[item.Order_item_id]) {
+			continue
+		}
+
+		food := foods[*item.Food_id]
+
+		station := models.StationKitchen
+		if food.Station != nil && *food.Station != "" {
+			station = strings.ToUpper(*food.Station)
+		}
+
+		ticket, ok := tickets[station]
+		if !ok {
+			ticket = &helpers.KitchenTicket{
+				Station:      station,
+				Order_id:     order.Order_id,
+				Table_number: table.Table_number,
+				Time:         time.Now(),
+			}
+			tickets[station] = ticket
+		}
+
+		line := helpers.TicketLine{Quantity: *item.Quantity, Name: *item.Food_id}
+		if food.Name != nil {
+			line.Name = *food.Name
+		}
+		if item.Bundle_item_id != nil {
+			line.Bundle_item_id = *item.Bundle_item_id
+			line.Bundle = *item.Bundle_id
+			if bundle := foods[*item.Bundle_id]; bundle.Name != nil {
+				line.Bundle = *bundle.Name
+			}
+		}
+		if item.Course != nil {
+			line.Course = *item.Course
+		}
+		ticket.Lines = append(ticket.Lines, line)
+	}
+
+	stations := make([]string, 0, len(tickets))
+	for station := range tickets {
+		stations = append(stations, station)
+	}
+	sort.Strings(stations)
+
+	sorted := make([]helpers.KitchenTicket, 0, len(stations))
+	for _, station := range stations {
+		ticket := tickets[station]
+		sort.SliceStable(ticket.Lines, func(i, j int) bool {
+			return courseRank(ticket.Lines[i].Course) < courseRank(ticket.Lines[j].Course)
+		})
+		sorted = append(sorted, *ticket)
+	}
+
+	return sorted, nil
+}
+
+func courseRank(course string) int {
+	for i, c := range models.Courses {
+		if c == course {
+			return i
+		}
+	}
+	return -1
+}
+
+func foodsByID(db *gorm.DB, items []models.OrderItem) (map[string]models.Food, error) {
+	var foodIDs []string
+	for _, item := range items {
+		if item.Food_id != nil {
+			foodIDs = append(foodIDs, *item.Food_id)
+		}
+		if item.Bundle_id != nil {
+			foodIDs = append(foodIDs, *item.Bundle_id)
+		}
+	}
+
+	var foods []models.Food
+	if err := db.Preload("Prices").Where("food_id IN ?", foodIDs).Find(&foods).Error; err != nil {
+		return nil, err
+	}
+
+	byID := make(map[string]models.Food, len(foods))
+	for _, food := range foods {
+		byID[food.Food_id] = food
+	}
+
+	return byID, nil
+}
+
+// invoiceReceipt gathers everything printed on the customer receipt for an invoice.
+func invoiceReceipt(db *gorm.DB, invoiceID string) (helpers.Receipt, error) {
+	doc, err := invoiceDocument(db, invoiceID)
+	if err != nil {
+		return helpers.Receipt{}, err
+	}
+
+	return helpers.Receipt{
+		Restaurant_name: doc.Restaurant_name,
+		Invoice_id:      doc.Invoice_id,
+		Invoice_number:  doc.Invoice_number,
+		Order_id:        doc.Order_id,
+		Table_number:    doc.Table_number,
+		Time:            time.Now(),
+		Lines:           doc.Lines,
+		Discounts:       doc.Discounts,
+		Taxes:           doc.Taxes,
+		Included_taxes:  doc.Included_taxes,
+		Total:           doc.Total(),
+		Tip:             doc.Tip,
+		Payment_method:  doc.Payment_method,
+		Payment_status:  doc.Payment_status,
+		Footer:          os.Getenv("RECEIPT_FOOTER"),
+	}, nil
+}
+
+// queueDocument queues a document on every enabled printer of a station.
+func queueDocument(db *gorm.DB, station string, kind string, referenceID string, doc *helpers.PrintDocument) ([]models.PrintJob, error) {
+	var printers []models.Printer
+
+	if err := db.Where("station = ? AND enabled = ?", station, true).Find(&printers).Error; err != nil {
+		return nil, err
+	}
+
+	jobs := make([]models.PrintJob, 0, len(printers))
+	for _, printer := range printers {
+		job, err := helpers.EnqueuePrintJob(db, printer.Printer_id, kind, referenceID, doc.ESCPOS())
+		if err != nil {
+			return jobs, err
+		}
+		jobs = append(jobs, job)
+	}
+
+	return jobs, nil
+}
+
+// queueKitchenTickets queues an order's kitchen tickets at their stations.
+func queueKitchenTickets(db *gorm.DB, orderID string, itemIDs []string) ([]models.PrintJob, error) {
+	tickets, err := kitchenTickets(db, orderID, itemIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	var jobs []models.PrintJob
+	for _, ticket := range tickets {
+		queued, err := queueDocument(db, ticket.Station, printKitchenTicket, orderID, ticket.Document())
+		if err != nil {
+			return jobs, err
+		}
+		jobs = append(jobs, queued...)
+	}
+
+	return jobs, nil
+}
+
+// printFiredItems queues tickets for freshly fired items. Printing must never
+// hold up service, so failures are only logged.
+func printFiredItems(orderID string, itemIDs []string) {
+	if len(itemIDs) == 0 {
+		return
+	}
+
+	if _, err := queueKitchenTickets(database.DB, orderID, itemIDs); err != nil {
+		log.Printf("order %s: queue kitchen tickets: %v", orderID, err)
+	}
+}
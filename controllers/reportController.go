@@ -0,0 +1,657 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Hdeee1/go-restaurant-management/database"
+	"github.com/Hdeee1/go-restaurant-management/helpers"
+	"github.com/Hdeee1/go-restaurant-management/models"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// GetTipReport godoc
+//
+//	@Summary		Tip distribution report (Admin only)
+//	@Description	Pool the tips taken in a date range by shift and split each pool among the staff on shift. The split follows TIP_SPLIT (HOURS or EQUAL) and TIP_ROLE_WEIGHTS. Tips in other currencies are converted to the default currency.
+//	@Tags			Reports
+//	@Accept			json
+//	@Produce		json
+//	@Param			from	query	string	false	"Start date (YYYY-MM-DD or RFC3339), defaults to today"
+//	@Param			to		query	string	false	"End date, inclusive (YYYY-MM-DD or RFC3339), defaults to from"
+//	@Param			format	query	string	false	"json, csv or xlsx"
+//	@Security		BearerAuth
+//	@Success		200	{object}	helpers.TipReport
+//	@Failure		400	{object}	map[string]interface{}
+//	@Failure		500	{object}	map[string]interface{}
+//	@Router			/reports/tips [get]
+func GetTipReport() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		format, err := helpers.ExportFormat(ctx)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		from, to, err := reportRange(ctx)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		var assignments []models.SectionAssignment
+
+		if err := database.DB.Where("shift_start < ? AND shift_end > ?", to, from).Find(&assignments).Error; err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		userIDs := make([]string, 0, len(assignments))
+		for _, assignment := range assignments {
+			userIDs = append(userIDs, *assignment.User_id)
+		}
+
+		var users []models.User
+		if err := database.DB.Where("user_id IN ?", userIDs).Find(&users).Error; err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		roles := map[string]string{}
+		for _, user := range users {
+			if user.Role != nil {
+				roles[user.User_id] = *user.Role
+			}
+		}
+
+		shifts := make([]helpers.TipShift, 0, len(assignments))
+		for _, assignment := range assignments {
+			shift := helpers.TipShift{
+				User_id: *assignment.User_id,
+				Role:    roles[*assignment.User_id],
+				Start:   assignment.Shift_start,
+				End:     assignment.Shift_end,
+			}
+			if shift.Start.Before(from) {
+				shift.Start = from
+			}
+			if shift.End.After(to) {
+				shift.End = to
+			}
+			shifts = append(shifts, shift)
+		}
+
+		var payments []models.Payment
+
+		err = database.DB.Where("tip_minor > 0 AND status IN ? AND created_at >= ? AND created_at < ?",
+			[]string{models.PaymentCaptured, models.PaymentRefunded}, from, to).Find(&payments).Error
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		tips := make([]helpers.Tip, 0, len(payments))
+		for _, payment := range payments {
+			tip, _, err := convertMoney(database.DB, payment.Tip_amount, models.DefaultCurrency(), payment.CreatedAt)
+			if err != nil {
+				ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+			tips = append(tips, helpers.Tip{Amount: tip, At: payment.CreatedAt})
+		}
+
+		report := helpers.PoolTips(shifts, tips, helpers.TipPoolRulesFromEnv())
+
+		if format != "" {
+			var rows [][]interface{}
+			for _, pool := range report.Pools {
+				for _, share := range pool.Shares {
+					rows = append(rows, []interface{}{pool.Start, pool.End, pool.Tips, share.User_id, share.Role, share.Hours, share.Weight, share.Amount})
+				}
+			}
+			header := []string{"pool_start", "pool_end", "pool_tips", "user_id", "role", "hours", "weight", "amount"}
+			if err := helpers.ExportRows(ctx, format, "tips", header, rows); err != nil {
+				helpers.ExportFailed(ctx, err)
+			}
+			return
+		}
+
+		ctx.JSON(http.StatusOK, report)
+	}
+}
+
+type AgingInvoice struct {
+	Invoice_id     string       `json:"invoice_id"`
+	Invoice_number *string      `json:"invoice_number"`
+	Due_date       time.Time    `json:"due_date"`
+	Days_overdue   int          `json:"days_overdue"`
+	Balance        models.Money `json:"balance"`
+}
+
+type AgingBucket struct {
+	Bucket   string         `json:"bucket"`
+	Total    models.Money   `json:"total"`
+	Invoices []AgingInvoice `json:"invoices"`
+}
+
+// agingBuckets are the report's buckets by days past the due date; invoices
+// not yet due count as -1 days and the last bucket takes everything older.
+var agingBuckets = []struct {
+	name    string
+	maxDays int
+}{
+	{"current", -1},
+	{"0-30", 30},
+	{"31-60", 60},
+	{"61-90", 90},
+	{"90+", 0},
+}
+
+// GetAgingReport godoc
+//
+//	@Summary		Accounts receivable aging report (Admin only)
+//	@Description	Bucket the outstanding balance of unpaid invoices by days past due: current (not yet due), 0-30, 31-60, 61-90 and 90+. Balances are converted to the default currency; invoices in a currency with no exchange rate are listed under unconverted in their own currency and left out of the totals.
+//	@Tags			Reports
+//	@Accept			json
+//	@Produce		json
+//	@Param			branch	query	string	false	"Branch code"
+//	@Param			format	query	string	false	"json, csv or xlsx"
+//	@Security		BearerAuth
+//	@Success		200	{object}	map[string]interface{}
+//	@Failure		500	{object}	map[string]interface{}
+//	@Router			/reports/aging [get]
+func GetAgingReport() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		format, err := helpers.ExportFormat(ctx)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		now := time.Now()
+		currency := models.DefaultCurrency()
+
+		query := database.DB.Where("payment_status = ?", models.InvoicePending).Order("payment_due_date")
+		if branch := ctx.Query("branch"); branch != "" {
+			query = query.Where("branch = ?", strings.ToUpper(branch))
+		}
+
+		var invoices []models.Invoice
+
+		if err := query.Find(&invoices).Error; err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		buckets := make([]AgingBucket, len(agingBuckets))
+		for i, bucket := range agingBuckets {
+			buckets[i] = AgingBucket{Bucket: bucket.name, Total: models.Zero(currency), Invoices: []AgingInvoice{}}
+		}
+		total := models.Zero(currency)
+		unconverted := []AgingInvoice{}
+
+		for _, invoice := range invoices {
+			doc, err := invoiceDocument(database.DB, invoice.Invoice_id)
+			if err != nil {
+				ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+
+			days := -1
+			if invoice.StateAt(now) == models.InvoiceOverdue {
+				days = int(now.Sub(invoice.Payment_due_date).Hours() / 24)
+			}
+
+			balance, _, err := convertMoney(database.DB, doc.Balance(), currency, now)
+			if errors.Is(err, errNoExchangeRate) {
+				if doc.Balance().IsPositive() {
+					unconverted = append(unconverted, AgingInvoice{
+						Invoice_id:     invoice.Invoice_id,
+						Invoice_number: invoice.Invoice_number,
+						Due_date:       invoice.Payment_due_date,
+						Days_overdue:   max(days, 0),
+						Balance:        doc.Balance(),
+					})
+				}
+				continue
+			}
+			if err != nil {
+				ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+
+			if !balance.IsPositive() {
+				continue
+			}
+
+			i := 0
+			for i < len(agingBuckets)-1 && days > agingBuckets[i].maxDays {
+				i++
+			}
+
+			buckets[i].Total = buckets[i].Total.Add(balance)
+			buckets[i].Invoices = append(buckets[i].Invoices, AgingInvoice{
+				Invoice_id:     invoice.Invoice_id,
+				Invoice_number: invoice.Invoice_number,
+				Due_date:       invoice.Payment_due_date,
+				Days_overdue:   max(days, 0),
+				Balance:        balance,
+			})
+			total = total.Add(balance)
+		}
+
+		if format != "" {
+			var rows [][]interface{}
+			for _, bucket := range buckets {
+				for _, invoice := range bucket.Invoices {
+					rows = append(rows, []interface{}{bucket.Bucket, invoice.Invoice_id, invoice.Invoice_number, invoice.Due_date,
+						invoice.Days_overdue, invoice.Balance, invoice.Balance.Currency})
+				}
+			}
+			for _, invoice := range unconverted {
+				rows = append(rows, []interface{}{"unconverted", invoice.Invoice_id, invoice.Invoice_number, invoice.Due_date,
+					invoice.Days_overdue, invoice.Balance, invoice.Balance.Currency})
+			}
+			header := []string{"bucket", "invoice_id", "invoice_number", "due_date", "days_overdue", "balance", "currency"}
+			if err := helpers.ExportRows(ctx, format, "aging", header, rows); err != nil {
+				helpers.ExportFailed(ctx, err)
+			}
+			return
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{
+			"as_of":       now,
+			"buckets":     buckets,
+			"total":       total,
+			"unconverted": unconverted,
+		})
+	}
+}
+
+// reportRange reads the from/to query of a report. Plain dates cover whole
+// days, so to=2026-03-01 includes everything on the 1st.
+func reportRange(ctx *gin.Context) (time.Time, time.Time, error) {
+	now := time.Now().In(reportLocation())
+	from := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	to := from.AddDate(0, 0, 1)
+
+	if value := ctx.Query("from"); value != "" {
+		start, _, err := parseReportTime(value)
+		if err != nil {
+			return from, to, errors.New("invalid from: " + err.Error())
+		}
+		from = start
+		to = from.AddDate(0, 0, 1)
+	}
+
+	if value := ctx.Query("to"); value != "" {
+		end, isDate, err := parseReportTime(value)
+		if err != nil {
+			return from, to, errors.New("invalid to: " + err.Error())
+		}
+		if isDate {
+			end = end.AddDate(0, 0, 1)
+		}
+		to = end
+	}
+
+	if !to.After(from) {
+		return from, to, errors.New("to must be after from")
+	}
+
+	return from, to, nil
+}
+
+// reportLocation is the time zone reports read dates and group sales in,
+// REPORT_TIMEZONE or else TZ as an IANA name such as Europe/Paris (default UTC).
+func reportLocation() *time.Location {
+	name := os.Getenv("REPORT_TIMEZONE")
+	if name == "" {
+		name = os.Getenv("TZ")
+	}
+
+	location, err := time.LoadLocation(name)
+	if err != nil {
+		return time.UTC
+	}
+	return location
+}
+
+// reportZone is reportLocation as MySQL's CONVERT_TZ takes it. Named zones
+// need the server's time zone tables loaded (mysql_tzinfo_to_sql); UTC is
+// passed as an offset so it works without them.
+func reportZone() string {
+	if location := reportLocation(); location != time.UTC {
+		return location.String()
+	}
+	return "+00:00"
+}
+
+func parseReportTime(value string) (time.Time, bool, error) {
+	if date, err := time.ParseInLocation("2006-01-02", value, reportLocation()); err == nil {
+		return date, true, nil
+	}
+
+	t, err := time.Parse(time.RFC3339, value)
+	return t, false, err
+}
+
+// SalesFigure is one row of a sales report: what was sold in a group, in one currency.
+type SalesFigure struct {
+	Group         string        `json:"group"`
+	Label         string        `json:"label,omitempty"`
+	Orders        int64         `json:"orders"`
+	Items         int64         `json:"items"`
+	Revenue       models.Money  `json:"revenue"`
+	Average_order *models.Money `json:"average_order,omitempty"`
+}
+
+type salesRow struct {
+	Group_key     string
+	Label         string
+	Currency      string
+	Orders        int64
+	Items         int64
+	Revenue_minor int64
+}
+
+// salesGroupings are the time buckets sales can be grouped by. Order dates
+// are stored in UTC and converted to the report zone before bucketing, so
+// every row gets the offset in force on its own date.
+var salesGroupings = map[string]string{
+	"day":         "DATE_FORMAT(CONVERT_TZ(o.order_date, '+00:00', ?), '%Y-%m-%d')",
+	"week":        "DATE_FORMAT(CONVERT_TZ(o.order_date, '+00:00', ?), '%x-W%v')",
+	"month":       "DATE_FORMAT(CONVERT_TZ(o.order_date, '+00:00', ?), '%Y-%m')",
+	"hour":        "DATE_FORMAT(CONVERT_TZ(o.order_date, '+00:00', ?), '%Y-%m-%d %H:00')",
+	"hour_of_day": "DATE_FORMAT(CONVERT_TZ(o.order_date, '+00:00', ?), '%H:00')",
+	"weekday":     "DATE_FORMAT(CONVERT_TZ(o.order_date, '+00:00', ?), '%w %W')",
+}
+
+// GetSalesReport godoc
+//
+//	@Summary		Sales over time (Admin only)
+//	@Description	Orders, items sold and revenue from order items in a date range, grouped by day, week, month, hour, hour_of_day or weekday. Rejected orders are left out; paid=true counts only orders with a paid invoice. Revenue is per currency.
+//	@Tags			Reports
+//	@Accept			json
+//	@Produce		json
+//	@Param			from			query	string	false	"Start date (YYYY-MM-DD or RFC3339), defaults to today"
+//	@Param			to				query	string	false	"End date, inclusive (YYYY-MM-DD or RFC3339), defaults to from"
+//	@Param			group_by		query	string	false	"day, week, month, hour, hour_of_day or weekday"	default(day)
+//	@Param			currency		query	string	false	"Only sales in this currency"
+//	@Param			service_type	query	string	false	"DINE_IN or TAKEOUT"
+//	@Param			paid			query	bool	false	"Only orders with a paid invoice"
+//	@Param			format			query	string	false	"json, csv or xlsx"
+//	@Security		BearerAuth
+//	@Success		200	{object}	map[string]interface{}
+//	@Failure		400	{object}	map[string]interface{}
+//	@Failure		500	{object}	map[string]interface{}
+//	@Router			/reports/sales [get]
+func GetSalesReport() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		groupBy := ctx.DefaultQuery("group_by", "day")
+
+		group, ok := salesGroupings[groupBy]
+		if !ok {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "group_by must be day, week, month, hour, hour_of_day or weekday"})
+			return
+		}
+
+		salesReport(ctx, gin.H{"group_by": groupBy}, func(query *gorm.DB) *gorm.DB {
+			return query.Select(salesColumns+", "+group+" AS group_key, '' AS label", reportZone()).
+				Group("group_key, label, currency").
+				Order("group_key, currency")
+		}, true)
+	}
+}
+
+// GetAverageOrderReport godoc
+//
+//	@Summary		Average order value (Admin only)
+//	@Description	Average revenue per order in a date range, per currency, optionally grouped by day, week, month, hour, hour_of_day or weekday
+//	@Tags			Reports
+//	@Accept			json
+//	@Produce		json
+//	@Param			from			query	string	false	"Start date (YYYY-MM-DD or RFC3339), defaults to today"
+//	@Param			to				query	string	false	"End date, inclusive (YYYY-MM-DD or RFC3339), defaults to from"
+//	@Param			group_by		query	string	false	"day, week, month, hour, hour_of_day or weekday; the whole range when empty"
+//	@Param			currency		query	string	false	"Only sales in this currency"
+//	@Param			service_type	query	string	false	"DINE_IN or TAKEOUT"
+//	@Param			paid			query	bool	false	"Only orders with a paid invoice"
+//	@Param			format			query	string	false	"json, csv or xlsx"
+//	@Security		BearerAuth
+//	@Success		200	{object}	map[string]interface{}
+//	@Failure		400	{object}	map[string]interface{}
+//	@Failure		500	{object}	map[string]interface{}
+//	@Router			/reports/average-order-value [get]
+func GetAverageOrderReport() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		groupBy := ctx.Query("group_by")
+
+		group := "'all'"
+		if groupBy != "" {
+			var ok bool
+			if group, ok = salesGroupings[groupBy]; !ok {
+				ctx.JSON(http.StatusBadRequest, gin.H{"error": "group_by must be day, week, month, hour, hour_of_day or weekday"})
+				return
+			}
+		}
+
+		salesReport(ctx, gin.H{"group_by": groupBy}, func(query *gorm.DB) *gorm.DB {
+			var args []interface{}
+			if groupBy != "" {
+				args = append(args, reportZone())
+			}
+			return query.Select(salesColumns+", "+group+" AS group_key, '' AS label", args...).
+				Group("group_key, label, currency").
+				Order("group_key, currency")
+		}, true)
+	}
+}
+
+// GetTopFoodsReport godoc
+//
+//	@Summary		Top-selling foods (Admin only)
+//	@Description	Foods ranked by items sold, then revenue, in a date range
+//	@Tags			Reports
+//	@Accept			json
+//	@Produce		json
+//	@Param			from			query	string	false	"Start date (YYYY-MM-DD or RFC3339), defaults to today"
+//	@Param			to				query	string	false	"End date, inclusive (YYYY-MM-DD or RFC3339), defaults to from"
+//	@Param			limit			query	int		false	"Number of foods (max 100)"	default(10)
+//	@Param			currency		query	string	false	"Only sales in this currency"
+//	@Param			service_type	query	string	false	"DINE_IN or TAKEOUT"
+//	@Param			paid			query	bool	false	"Only orders with a paid invoice"
+//	@Param			format			query	string	false	"json, csv or xlsx"
+//	@Security		BearerAuth
+//	@Success		200	{object}	map[string]interface{}
+//	@Failure		400	{object}	map[string]interface{}
+//	@Failure		500	{object}	map[string]interface{}
+//	@Router			/reports/top-foods [get]
+func GetTopFoodsReport() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		limit, err := strconv.Atoi(ctx.DefaultQuery("limit", "10"))
+		if err != nil || limit < 1 || limit > 100 {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "limit must be between 1 and 100"})
+			return
+		}
+
+		salesReport(ctx, gin.H{"limit": limit}, func(query *gorm.DB) *gorm.DB {
+			return query.Joins("LEFT JOIN foods AS f ON f.food_id = oi.food_id AND f.deleted_at IS NULL").
+				Select(salesColumns + ", oi.food_id AS group_key, COALESCE(f.name, '') AS label").
+				Group("group_key, label, currency").
+				Order("items DESC, revenue_minor DESC").
+				Limit(limit)
+		}, false)
+	}
+}
+
+// GetCategoryReport godoc
+//
+//	@Summary		Revenue per menu category (Admin only)
+//	@Description	Items sold and revenue per food category in a date range. Each food counts for the category it is filed under, not that category's parents; foods without a category are grouped under an empty category_id.
+//	@Tags			Reports
+//	@Accept			json
+//	@Produce		json
+//	@Param			from			query	string	false	"Start date (YYYY-MM-DD or RFC3339), defaults to today"
+//	@Param			to				query	string	false	"End date, inclusive (YYYY-MM-DD or RFC3339), defaults to from"
+//	@Param			currency		query	string	false	"Only sales in this currency"
+//	@Param			service_type	query	string	false	"DINE_IN or TAKEOUT"
+//	@Param			paid			query	bool	false	"Only orders with a paid invoice"
+//	@Param			format			query	string	false	"json, csv or xlsx"
+//	@Security		BearerAuth
+//	@Success		200	{object}	map[string]interface{}
+//	@Failure		400	{object}	map[string]interface{}
+//	@Failure		500	{object}	map[string]interface{}
+//	@Router			/reports/categories [get]
+func GetCategoryReport() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		salesReport(ctx, gin.H{}, func(query *gorm.DB) *gorm.DB {
+			return query.Joins("LEFT JOIN foods AS f ON f.food_id = oi.food_id AND f.deleted_at IS NULL").
+				Joins("LEFT JOIN categories AS c ON c.category_id = f.category_id AND c.deleted_at IS NULL").
+				Select(salesColumns + ", COALESCE(f.category_id, '') AS group_key, COALESCE(c.name, '') AS label").
+				Group("group_key, label, currency").
+				Order("revenue_minor DESC")
+		}, false)
+	}
+}
+
+// GetTableSalesReport godoc
+//
+//	@Summary		Revenue per table (Admin only)
+//	@Description	Orders, revenue and average order value per table in a date range
+//	@Tags			Reports
+//	@Accept			json
+//	@Produce		json
+//	@Param			from			query	string	false	"Start date (YYYY-MM-DD or RFC3339), defaults to today"
+//	@Param			to				query	string	false	"End date, inclusive (YYYY-MM-DD or RFC3339), defaults to from"
+//	@Param			currency		query	string	false	"Only sales in this currency"
+//	@Param			service_type	query	string	false	"DINE_IN or TAKEOUT"
+//	@Param			paid			query	bool	false	"Only orders with a paid invoice"
+//	@Param			format			query	string	false	"json, csv or xlsx"
+//	@Security		BearerAuth
+//	@Success		200	{object}	map[string]interface{}
+//	@Failure		400	{object}	map[string]interface{}
+//	@Failure		500	{object}	map[string]interface{}
+//	@Router			/reports/tables [get]
+func GetTableSalesReport() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		salesReport(ctx, gin.H{}, func(query *gorm.DB) *gorm.DB {
+			return query.Joins("LEFT JOIN tables AS t ON t.table_id = o.table_id AND t.deleted_at IS NULL").
+				Select(salesColumns + ", COALESCE(o.table_id, '') AS group_key, COALESCE(CAST(t.table_number AS CHAR), '') AS label").
+				Group("group_key, label, currency").
+				Order("revenue_minor DESC")
+		}, true)
+	}
+}
+
+// GetWaiterSalesReport godoc
+//
+//	@Summary		Revenue per waiter (Admin only)
+//	@Description	Orders, revenue and average order value per waiter in a date range. An order counts for every waiter assigned to its table's section when it was placed.
+//	@Tags			Reports
+//	@Accept			json
+//	@Produce		json
+//	@Param			from			query	string	false	"Start date (YYYY-MM-DD or RFC3339), defaults to today"
+//	@Param			to				query	string	false	"End date, inclusive (YYYY-MM-DD or RFC3339), defaults to from"
+//	@Param			currency		query	string	false	"Only sales in this currency"
+//	@Param			service_type	query	string	false	"DINE_IN or TAKEOUT"
+//	@Param			paid			query	bool	false	"Only orders with a paid invoice"
+//	@Param			format			query	string	false	"json, csv or xlsx"
+//	@Security		BearerAuth
+//	@Success		200	{object}	map[string]interface{}
+//	@Failure		400	{object}	map[string]interface{}
+//	@Failure		500	{object}	map[string]interface{}
+//	@Router			/reports/waiters [get]
+func GetWaiterSalesReport() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		salesReport(ctx, gin.H{}, func(query *gorm.DB) *gorm.DB {
+			return query.Joins("JOIN tables AS t ON t.table_id = o.table_id").
+				Joins("JOIN section_assignments AS sa ON sa.section_id = t.section_id AND sa.shift_start <= o.order_date AND sa.shift_end >= o.order_date AND sa.deleted_at IS NULL").
+				Joins("LEFT JOIN users AS u ON u.user_id = sa.user_id").
+				Select(salesColumns + ", sa.user_id AS group_key, COALESCE(CONCAT(u.first_name, ' ', u.last_name), '') AS label").
+				Group("group_key, label, currency").
+				Order("revenue_minor DESC")
+		}, true)
+	}
+}
+
+// salesColumns are the aggregates every sales report selects. Each order item is one unit sold.
+const salesColumns = "oi.unit_price_currency AS currency, COUNT(DISTINCT o.order_id) AS orders, COUNT(*) AS items, COALESCE(SUM(oi.unit_price_minor), 0) AS revenue_minor"
+
+// salesReport runs a sales aggregation over the order items of non-rejected
+// orders in the report range and responds with its figures.
+func salesReport(ctx *gin.Context, response gin.H, aggregate func(query *gorm.DB) *gorm.DB, averages bool) {
+	format, err := helpers.ExportFormat(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	from, to, err := reportRange(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	query := database.DB.Table("order_items AS oi").
+		Joins("JOIN orders AS o ON o.order_id = oi.order_id AND o.deleted_at IS NULL").
+		Where("oi.deleted_at IS NULL AND o.status <> ? AND o.order_date >= ? AND o.order_date < ?", models.OrderRejected, from, to)
+
+	if currency := ctx.Query("currency"); currency != "" {
+		query = query.Where("oi.unit_price_currency = ?", strings.ToUpper(currency))
+	}
+
+	if serviceType := ctx.Query("service_type"); serviceType != "" {
+		query = query.Where("o.service_type = ?", strings.ToUpper(serviceType))
+	}
+
+	if ctx.Query("paid") == "true" {
+		query = query.Where("EXISTS (SELECT 1 FROM invoices AS i WHERE i.order_id = o.order_id AND i.payment_status = ? AND i.deleted_at IS NULL)", models.InvoicePaid)
+	}
+
+	var rows []salesRow
+	if err := aggregate(query).Scan(&rows).Error; err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	figures := make([]SalesFigure, 0, len(rows))
+	for _, row := range rows {
+		figure := SalesFigure{
+			Group:   row.Group_key,
+			Label:   row.Label,
+			Orders:  row.Orders,
+			Items:   row.Items,
+			Revenue: models.Money{Minor: row.Revenue_minor, Currency: row.Currency},
+		}
+		if averages && row.Orders > 0 {
+			average := models.Money{Minor: models.RoundMinor(float64(row.Revenue_minor) / float64(row.Orders)), Currency: row.Currency}
+			figure.Average_order = &average
+		}
+		figures = append(figures, figure)
+	}
+
+	if format != "" {
+		rows := make([][]interface{}, 0, len(figures))
+		for _, figure := range figures {
+			rows = append(rows, []interface{}{figure.Group, figure.Label, figure.Revenue.Currency, figure.Orders, figure.Items,
+				figure.Revenue, figure.Average_order})
+		}
+		header := []string{"group", "label", "currency", "orders", "items", "revenue", "average_order"}
+		if err := helpers.ExportRows(ctx, format, path.Base(ctx.FullPath()), header, rows); err != nil {
+			helpers.ExportFailed(ctx, err)
+		}
+		return
+	}
+
+	response["from"] = from
+	response["to"] = to
+	response["sales"] = figures
+	ctx.JSON(http.StatusOK, response)
+}
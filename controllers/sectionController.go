@@ -0,0 +1,316 @@
+package controllers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/Hdeee1/go-restaurant-management/database"
+	"github.com/Hdeee1/go-restaurant-management/helpers"
+	"github.com/Hdeee1/go-restaurant-management/models"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// GetSections godoc
+//
+//	@Summary		Get all sections
+//	@Description	Retrieve a paginated list of floor sections with their tables
+//	@Tags			Sections
+//	@Accept			json
+//	@Produce		json
+//	@Param			page	query	int	false	"Page number"		default(1)
+//	@Param			limit	query	int	false	"Items per page"	default(10)
+//	@Security		BearerAuth
+//	@Success		200	{object}	map[string]interface{}
+//	@Failure		500	{object}	map[string]interface{}
+//	@Router			/sections [get]
+func GetSections() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var sections []models.Section
+
+		result := database.DB.Scopes(helpers.Paginate(ctx)).Preload("Tables").Find(&sections)
+		if result.Error != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
+			return
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{
+			"sections": sections,
+			"page":     ctx.DefaultQuery("page", "1"),
+			"limit":    ctx.DefaultQuery("limit", "10"),
+		})
+	}
+}
+
+// GetSection godoc
+//
+//	@Summary		Get section by ID
+//	@Description	Retrieve a specific section by section_id with its tables
+//	@Tags			Sections
+//	@Accept			json
+//	@Produce		json
+//	@Param			section_id	path	string	true	"Section ID"
+//	@Security		BearerAuth
+//	@Success		200	{object}	models.Section
+//	@Failure		404	{object}	map[string]interface{}
+//	@Router			/sections/{section_id} [get]
+func GetSection() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		sectionID := ctx.Param("section_id")
+
+		var section models.Section
+
+		if err := database.DB.Preload("Tables").Where("section_id = ?", sectionID).First(&section).Error; err != nil {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "section_id not found"})
+			return
+		}
+
+		ctx.JSON(http.StatusOK, section)
+	}
+}
+
+// CreateSection godoc
+//
+//	@Summary		Create a new section (Admin only)
+//	@Description	Create a new floor section such as patio, bar or main room
+//	@Tags			Sections
+//	@Accept			json
+//	@Produce		json
+//	@Param			section	body	models.Section	true	"Section object"
+//	@Security		BearerAuth
+//	@Success		201	{object}	map[string]interface{}
+//	@Failure		400	{object}	map[string]interface{}
+//	@Failure		500	{object}	map[string]interface{}
+//	@Router			/sections [post]
+func CreateSection() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var section models.Section
+
+		if err := ctx.BindJSON(&section); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		if err := helpers.Validate.Struct(section); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		section.Section_id = uuid.New().String()
+		section.Tables = nil
+
+		if err := database.DB.Create(&section).Error; err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		ctx.JSON(http.StatusCreated, gin.H{
+			"message":    "section created",
+			"section_id": section.Section_id,
+		})
+	}
+}
+
+// UpdateSection godoc
+//
+//	@Summary		Update a section (Admin only)
+//	@Description	Update an existing section by section_id
+//	@Tags			Sections
+//	@Accept			json
+//	@Produce		json
+//	@Param			section_id	path	string			true	"Section ID"
+//	@Param			section		body	models.Section	true	"Section object"
+//	@Security		BearerAuth
+//	@Success		200	{object}	map[string]interface{}
+//	@Failure		400	{object}	map[string]interface{}
+//	@Failure		404	{object}	map[string]interface{}
+//	@Failure		500	{object}	map[string]interface{}
+//	@Router			/sections/{section_id} [patch]
+func UpdateSection() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		sectionID := ctx.Param("section_id")
+
+		var section models.Section
+
+		if err := database.DB.Where("section_id = ?", sectionID).First(&section).Error; err != nil {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "section_id not found"})
+			return
+		}
+
+		var updateData models.Section
+		if err := ctx.BindJSON(&updateData); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		updateData.Tables = nil
+
+		if err := database.DB.Model(&section).Updates(updateData).Error; err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{
+			"message":    "section updated",
+			"section_id": section.Section_id,
+		})
+	}
+}
+
+// GetSectionAssignments godoc
+//
+//	@Summary		Get waiter assignments
+//	@Description	Retrieve waiter-to-section assignments, optionally filtered by section_id, user_id or a point in time
+//	@Tags			Sections
+//	@Accept			json
+//	@Produce		json
+//	@Param			section_id	query	string	false	"Section ID"
+//	@Param			user_id		query	string	false	"User ID"
+//	@Param			at			query	string	false	"Only shifts covering this RFC3339 time"
+//	@Param			page		query	int		false	"Page number"		default(1)
+//	@Param			limit		query	int		false	"Items per page"	default(10)
+//	@Security		BearerAuth
+//	@Success		200	{object}	map[string]interface{}
+//	@Failure		400	{object}	map[string]interface{}
+//	@Failure		500	{object}	map[string]interface{}
+//	@Router			/sections/assignments [get]
+func GetSectionAssignments() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var assignments []models.SectionAssignment
+
+		query := database.DB.Scopes(helpers.Paginate(ctx))
+
+		if sectionID := ctx.Query("section_id"); sectionID != "" {
+			query = query.Where("section_id = ?", sectionID)
+		}
+
+		if userID := ctx.Query("user_id"); userID != "" {
+			query = query.Where("user_id = ?", userID)
+		}
+
+		if at := ctx.Query("at"); at != "" {
+			atTime, err := time.Parse(time.RFC3339, at)
+			if err != nil {
+				ctx.JSON(http.StatusBadRequest, gin.H{"error": "at must be an RFC3339 time"})
+				return
+			}
+			query = query.Where("shift_start <= ? AND shift_end >= ?", atTime, atTime)
+		}
+
+		if err := query.Order("shift_start").Find(&assignments).Error; err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{
+			"assignments": assignments,
+			"page":        ctx.DefaultQuery("page", "1"),
+			"limit":       ctx.DefaultQuery("limit", "10"),
+		})
+	}
+}
+
+// CreateSectionAssignment godoc
+//
+//	@Summary		Assign a waiter to a section (Admin only)
+//	@Description	Assign a waiter to a section for a shift
+//	@Tags			Sections
+//	@Accept			json
+//	@Produce		json
+//	@Param			assignment	body	models.SectionAssignment	true	"Assignment object"
+//	@Security		BearerAuth
+//	@Success		201	{object}	map[string]interface{}
+//	@Failure		400	{object}	map[string]interface{}
+//	@Failure		404	{object}	map[string]interface{}
+//	@Failure		500	{object}	map[string]interface{}
+//	@Router			/sections/assignments [post]
+func CreateSectionAssignment() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var assignment models.SectionAssignment
+
+		if err := ctx.BindJSON(&assignment); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		if err := helpers.Validate.Struct(assignment); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		var section models.Section
+		if err := database.DB.Where("section_id = ?", *assignment.Section_id).First(&section).Error; err != nil {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "section_id not found"})
+			return
+		}
+
+		var user models.User
+		if err := database.DB.Where("user_id = ?", *assignment.User_id).First(&user).Error; err != nil {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "user_id not found"})
+			return
+		}
+
+		assignment.Assignment_id = uuid.New().String()
+
+		if err := database.DB.Create(&assignment).Error; err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		ctx.JSON(http.StatusCreated, gin.H{
+			"message":       "assignment created",
+			"assignment_id": assignment.Assignment_id,
+		})
+	}
+}
+
+// DeleteSectionAssignment godoc
+//
+//	@Summary		Remove a waiter assignment (Admin only)
+//	@Description	Remove a waiter-to-section assignment by assignment_id
+//	@Tags			Sections
+//	@Accept			json
+//	@Produce		json
+//	@Param			assignment_id	path	string	true	"Assignment ID"
+//	@Security		BearerAuth
+//	@Success		200	{object}	map[string]interface{}
+//	@Failure		404	{object}	map[string]interface{}
+//	@Failure		500	{object}	map[string]interface{}
+//	@Router			/sections/assignments/{assignment_id} [delete]
+func DeleteSectionAssignment() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		assignmentID := ctx.Param("assignment_id")
+
+		var assignment models.SectionAssignment
+
+		if err := database.DB.Where("assignment_id = ?", assignmentID).First(&assignment).Error; err != nil {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "assignment_id not found"})
+			return
+		}
+
+		if err := database.DB.Delete(&assignment).Error; err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{
+			"message":       "assignment deleted",
+			"assignment_id": assignment.Assignment_id,
+		})
+	}
+}
+
+// assignedTableIDs returns the table_ids in the sections a waiter is assigned to at the given time.
+func assignedTableIDs(db *gorm.DB, userID string, at time.Time) ([]string, error) {
+	var tableIDs []string
+
+	sectionIDs := db.Model(&models.SectionAssignment{}).
+		Select("section_id").
+		Where("user_id = ? AND shift_start <= ? AND shift_end >= ?", userID, at, at)
+
+	err := db.Model(&models.Table{}).
+		Where("section_id IN (?)", sectionIDs).
+		Pluck("table_id", &tableIDs).Error
+
+	return tableIDs, err
+}
@@ -1,15 +1,53 @@
 package controllers
 
 import (
+	"errors"
 	"net/http"
+	"time"
 
 	"github.com/Hdeee1/go-restaurant-management/database"
 	"github.com/Hdeee1/go-restaurant-management/helpers"
 	"github.com/Hdeee1/go-restaurant-management/models"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
+type TableBoardEntry struct {
+	Table_id        string     `json:"table_id"`
+	Table_number    *int       `json:"table_number"`
+	Number_of_guest *int       `json:"number_of_guest"`
+	Party_size      *int       `json:"party_size"`
+	Status          string     `json:"status"`
+	Seated_at       *time.Time `json:"seated_at"`
+	Order_id        *string    `json:"order_id"`
+	Section_id      *string    `json:"section_id"`
+	Pos_x           *float64   `json:"pos_x"`
+	Pos_y           *float64   `json:"pos_y"`
+	Shape           *string    `json:"shape"`
+	Is_combined     bool       `json:"is_combined"`
+	Merged_into     *string    `json:"merged_into"`
+}
+
+type MergeRequest struct {
+	Table_ids []string `json:"table_ids" validate:"required,min=2,dive,required"`
+}
+
+// TableUpdate is what can be edited on a table. Its status only changes by
+// seating, reserving, cleaning, merging and splitting it.
+type TableUpdate struct {
+	Number_of_guest *int     `json:"number_of_guest" validate:"omitempty,min=1"`
+	Table_number    *int     `json:"table_number" validate:"omitempty,min=1"`
+	Section_id      *string  `json:"section_id"`
+	Pos_x           *float64 `json:"pos_x"`
+	Pos_y           *float64 `json:"pos_y"`
+	Shape           *string  `json:"shape" validate:"omitempty,eq=ROUND|eq=SQUARE|eq=RECTANGLE"`
+}
+
+type SeatRequest struct {
+	Party_size *int `json:"party_size" validate:"required,min=1"`
+}
+
 // GetTables godoc
 //
 //	@Summary		Get all tables
@@ -94,7 +132,18 @@ func CreateTable() gin.HandlerFunc {
 			return
 		}
 
+		if table.Section_id != nil {
+			var section models.Section
+			if err := database.DB.Where("section_id = ?", *table.Section_id).First(&section).Error; err != nil {
+				ctx.JSON(http.StatusNotFound, gin.H{"error": "section_id not found"})
+				return
+			}
+		}
+
 		table.Table_id = uuid.New().String()
+		table.Status = models.TableAvailable
+		table.Is_combined = false
+		table.Merged_into = nil
 
 		if err := database.DB.Create(&table).Error; err != nil {
 			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -111,18 +160,18 @@ func CreateTable() gin.HandlerFunc {
 // UpdateTable godoc
 //
 //	@Summary		Update a table
-//	@Description	Update an existing table by table_id
+//	@Description	Update a table's number, capacity, section or floor plan position. An empty section_id takes the table out of its section. Status changes go through the seat, reserve, clean, merge and split endpoints.
 //	@Tags			Tables
 //	@Accept			json
 //	@Produce		json
-//	@Param			table_id	path	string			true	"Table ID"
-//	@Param			table		body	models.Table	true	"Table object"
+//	@Param			table_id	path	string		true	"Table ID"
+//	@Param			table		body	TableUpdate	true	"Table fields"
 //	@Security		BearerAuth
 //	@Success		200	{object}	map[string]interface{}
 //	@Failure		400	{object}	map[string]interface{}
 //	@Failure		404	{object}	map[string]interface{}
 //	@Failure		500	{object}	map[string]interface{}
-//	@Router			/tables/{table_id} [put]
+//	@Router			/table/{table_id} [patch]
 func UpdateTable() gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		tableID := ctx.Param("table_id")
@@ -134,20 +183,587 @@ func UpdateTable() gin.HandlerFunc {
 			return
 		}
 
-		var updateData models.Table
-		if err := ctx.BindJSON(&updateData); err != nil {
+		var req TableUpdate
+		if err := ctx.BindJSON(&req); err != nil {
 			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 			return
 		}
 
-		if err := database.DB.Model(&table).Updates(updateData).Error; err != nil {
-			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		if err := helpers.Validate.Struct(req); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 			return
 		}
 
+		updates := map[string]interface{}{}
+		if req.Number_of_guest != nil {
+			updates["number_of_guest"] = *req.Number_of_guest
+		}
+		if req.Table_number != nil {
+			updates["table_number"] = *req.Table_number
+		}
+		if req.Pos_x != nil {
+			updates["pos_x"] = *req.Pos_x
+		}
+		if req.Pos_y != nil {
+			updates["pos_y"] = *req.Pos_y
+		}
+		if req.Shape != nil {
+			updates["shape"] = *req.Shape
+		}
+		if req.Section_id != nil {
+			updates["section_id"] = nil
+			if *req.Section_id != "" {
+				var section models.Section
+				if err := database.DB.Where("section_id = ?", *req.Section_id).First(&section).Error; err != nil {
+					ctx.JSON(http.StatusNotFound, gin.H{"error": "section_id not found"})
+					return
+				}
+				updates["section_id"] = *req.Section_id
+			}
+		}
+
+		if len(updates) > 0 {
+			if err := database.DB.Model(&table).Updates(updates).Error; err != nil {
+				ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+		}
+
 		ctx.JSON(http.StatusOK, gin.H{
 			"message":  "table updated",
 			"table_id": table.Table_id,
 		})
 	}
 }
+
+// GetTableBoard godoc
+//
+//	@Summary		Get the table status board
+//	@Description	Retrieve the live floor state: every table with its status, seated party, open order and floor plan position
+//	@Tags			Tables
+//	@Accept			json
+//	@Produce		json
+//	@Param			section_id	query	string	false	"Only tables in this section"
+//	@Security		BearerAuth
+//	@Success		200	{object}	map[string]interface{}
+//	@Failure		500	{object}	map[string]interface{}
+//	@Router			/tables/board [get]
+func GetTableBoard() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var tables []models.Table
+
+		query := database.DB.Order("table_number")
+		if sectionID := ctx.Query("section_id"); sectionID != "" {
+			query = query.Where("section_id = ?", sectionID)
+		}
+
+		if err := query.Find(&tables).Error; err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		openOrders, err := openOrdersByTable(database.DB)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		board := make([]TableBoardEntry, 0, len(tables))
+		for _, table := range tables {
+			entry := TableBoardEntry{
+				Table_id:        table.Table_id,
+				Table_number:    table.Table_number,
+				Number_of_guest: table.Number_of_guest,
+				Party_size:      table.Party_size,
+				Status:          table.Status,
+				Seated_at:       table.Seated_at,
+				Section_id:      table.Section_id,
+				Pos_x:           table.Pos_x,
+				Pos_y:           table.Pos_y,
+				Shape:           table.Shape,
+				Is_combined:     table.Is_combined,
+				Merged_into:     table.Merged_into,
+			}
+			if orderID, ok := openOrders[table.Table_id]; ok {
+				entry.Order_id = &orderID
+			}
+			board = append(board, entry)
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{"tables": board})
+	}
+}
+
+// SeatTable godoc
+//
+//	@Summary		Seat a party at a table
+//	@Description	Seat a party at an available or reserved table. The party must fit the table's number_of_guest.
+//	@Tags			Tables
+//	@Accept			json
+//	@Produce		json
+//	@Param			table_id	path	string		true	"Table ID"
+//	@Param			party		body	SeatRequest	true	"Party details"
+//	@Security		BearerAuth
+//	@Success		200	{object}	map[string]interface{}
+//	@Failure		400	{object}	map[string]interface{}
+//	@Failure		404	{object}	map[string]interface{}
+//	@Failure		409	{object}	map[string]interface{}
+//	@Failure		500	{object}	map[string]interface{}
+//	@Router			/tables/{table_id}/seat [post]
+func SeatTable() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		tableID := ctx.Param("table_id")
+
+		var req SeatRequest
+		if err := ctx.BindJSON(&req); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		if err := helpers.Validate.Struct(req); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		var table models.Table
+
+		if err := database.DB.Where("table_id = ?", tableID).First(&table).Error; err != nil {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "table_id not found"})
+			return
+		}
+
+		if table.Status != models.TableAvailable && table.Status != models.TableReserved {
+			ctx.JSON(http.StatusConflict, gin.H{"error": "table is " + table.Status})
+			return
+		}
+
+		if table.Number_of_guest != nil && *req.Party_size > *table.Number_of_guest {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "party is larger than the table"})
+			return
+		}
+
+		if err := seatParty(database.DB, table, *req.Party_size); err != nil {
+			ctx.JSON(orderErrorStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{
+			"message":  "party seated",
+			"table_id": table.Table_id,
+		})
+	}
+}
+
+// ReserveTable godoc
+//
+//	@Summary		Reserve a table
+//	@Description	Hold an available table for an expected party. Seating the party or cleaning the table releases the reservation.
+//	@Tags			Tables
+//	@Accept			json
+//	@Produce		json
+//	@Param			table_id	path	string	true	"Table ID"
+//	@Security		BearerAuth
+//	@Success		200	{object}	map[string]interface{}
+//	@Failure		404	{object}	map[string]interface{}
+//	@Failure		409	{object}	map[string]interface{}
+//	@Failure		500	{object}	map[string]interface{}
+//	@Router			/tables/{table_id}/reserve [post]
+func ReserveTable() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		tableID := ctx.Param("table_id")
+
+		var table models.Table
+
+		if err := database.DB.Where("table_id = ?", tableID).First(&table).Error; err != nil {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "table_id not found"})
+			return
+		}
+
+		if table.Status != models.TableAvailable {
+			ctx.JSON(http.StatusConflict, gin.H{"error": "table is " + table.Status})
+			return
+		}
+
+		if err := setTableStatus(database.DB, table, models.TableReserved); err != nil {
+			ctx.JSON(orderErrorStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{
+			"message":  "table reserved",
+			"table_id": table.Table_id,
+		})
+	}
+}
+
+// CleanTable godoc
+//
+//	@Summary		Mark a table clean
+//	@Description	Mark a table as cleaned and available again, which also releases a reservation. Tables with an open order cannot be cleaned.
+//	@Tags			Tables
+//	@Accept			json
+//	@Produce		json
+//	@Param			table_id	path	string	true	"Table ID"
+//	@Security		BearerAuth
+//	@Success		200	{object}	map[string]interface{}
+//	@Failure		404	{object}	map[string]interface{}
+//	@Failure		409	{object}	map[string]interface{}
+//	@Failure		500	{object}	map[string]interface{}
+//	@Router			/tables/{table_id}/clean [post]
+func CleanTable() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		tableID := ctx.Param("table_id")
+
+		var table models.Table
+
+		if err := database.DB.Where("table_id = ?", tableID).First(&table).Error; err != nil {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "table_id not found"})
+			return
+		}
+
+		if table.Status == models.TableOrdering || table.Status == models.TableAwaitingPayment {
+			ctx.JSON(http.StatusConflict, gin.H{"error": "table still has an open order"})
+			return
+		}
+
+		if table.Status == models.TableMerged {
+			ctx.JSON(http.StatusConflict, gin.H{"error": mergedMessage(table)})
+			return
+		}
+
+		if err := clearTable(database.DB, table); err != nil {
+			ctx.JSON(orderErrorStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{
+			"message":  "table cleaned",
+			"table_id": table.Table_id,
+		})
+	}
+}
+
+// MergeTables godoc
+//
+//	@Summary		Merge tables for a large party
+//	@Description	Combine several available tables into one temporary table whose number_of_guest is the sum of its members. Orders and invoices are placed against the combined table.
+//	@Tags			Tables
+//	@Accept			json
+//	@Produce		json
+//	@Param			merge	body	MergeRequest	true	"Tables to merge"
+//	@Security		BearerAuth
+//	@Success		201	{object}	map[string]interface{}
+//	@Failure		400	{object}	map[string]interface{}
+//	@Failure		404	{object}	map[string]interface{}
+//	@Failure		409	{object}	map[string]interface{}
+//	@Failure		500	{object}	map[string]interface{}
+//	@Router			/tables/merge [post]
+func MergeTables() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var req MergeRequest
+
+		if err := ctx.BindJSON(&req); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		if err := helpers.Validate.Struct(req); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		seen := make(map[string]bool, len(req.Table_ids))
+		for _, id := range req.Table_ids {
+			if seen[id] {
+				ctx.JSON(http.StatusBadRequest, gin.H{"error": "table " + id + " is listed more than once"})
+				return
+			}
+			seen[id] = true
+		}
+
+		var members []models.Table
+
+		if err := database.DB.Where("table_id IN ?", req.Table_ids).Find(&members).Error; err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		if len(members) != len(req.Table_ids) {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "table_id not found"})
+			return
+		}
+
+		capacity := 0
+		lowestNumber := 0
+		for i, member := range members {
+			if member.Is_combined {
+				ctx.JSON(http.StatusConflict, gin.H{"error": "cannot merge a combined table"})
+				return
+			}
+			if member.Status != models.TableAvailable {
+				ctx.JSON(http.StatusConflict, gin.H{"error": "table " + member.Table_id + " is " + member.Status})
+				return
+			}
+			if member.Number_of_guest != nil {
+				capacity += *member.Number_of_guest
+			}
+			if member.Table_number != nil && (i == 0 || *member.Table_number < lowestNumber) {
+				lowestNumber = *member.Table_number
+			}
+		}
+
+		combined := models.Table{
+			Number_of_guest: &capacity,
+			Table_number:    &lowestNumber,
+			Table_id:        uuid.New().String(),
+			Status:          models.TableAvailable,
+			Section_id:      members[0].Section_id,
+			Pos_x:           members[0].Pos_x,
+			Pos_y:           members[0].Pos_y,
+			Shape:           members[0].Shape,
+			Is_combined:     true,
+		}
+
+		tx := database.DB.Begin()
+
+		if err := tx.Create(&combined).Error; err != nil {
+			tx.Rollback()
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		// Only tables that are still available are merged; if a party was
+		// seated at one of them since they were read, nothing is.
+		result := tx.Model(&models.Table{}).Where("table_id IN ? AND status = ?", req.Table_ids, models.TableAvailable).Updates(map[string]interface{}{
+			"status":      models.TableMerged,
+			"merged_into": combined.Table_id,
+		})
+		if result.Error != nil {
+			tx.Rollback()
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
+			return
+		}
+		if result.RowsAffected != int64(len(req.Table_ids)) {
+			tx.Rollback()
+			ctx.JSON(http.StatusConflict, gin.H{"error": errTableChanged.Error()})
+			return
+		}
+		tx.Commit()
+
+		ctx.JSON(http.StatusCreated, gin.H{
+			"message":         "tables merged",
+			"table_id":        combined.Table_id,
+			"number_of_guest": capacity,
+		})
+	}
+}
+
+// SplitTable godoc
+//
+//	@Summary		Split a combined table
+//	@Description	Split a combined table back into its member tables. The combined table must not have an open order.
+//	@Tags			Tables
+//	@Accept			json
+//	@Produce		json
+//	@Param			table_id	path	string	true	"Combined table ID"
+//	@Security		BearerAuth
+//	@Success		200	{object}	map[string]interface{}
+//	@Failure		400	{object}	map[string]interface{}
+//	@Failure		404	{object}	map[string]interface{}
+//	@Failure		409	{object}	map[string]interface{}
+//	@Failure		500	{object}	map[string]interface{}
+//	@Router			/tables/{table_id}/split [post]
+func SplitTable() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		tableID := ctx.Param("table_id")
+
+		var combined models.Table
+
+		if err := database.DB.Where("table_id = ?", tableID).First(&combined).Error; err != nil {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "table_id not found"})
+			return
+		}
+
+		if !combined.Is_combined {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "table is not a combined table"})
+			return
+		}
+
+		if combined.Status == models.TableOrdering || combined.Status == models.TableAwaitingPayment {
+			ctx.JSON(http.StatusConflict, gin.H{"error": "table still has an open order"})
+			return
+		}
+
+		// Members inherit the combined table's state so a table left dirty stays dirty after the split.
+		status := models.TableAvailable
+		if combined.Status == models.TableNeedsCleaning {
+			status = models.TableNeedsCleaning
+		}
+
+		tx := database.DB.Begin()
+
+		updates := tableStatusUpdates(status)
+		updates["merged_into"] = nil
+		updates["party_size"] = nil
+		updates["seated_at"] = nil
+		err := tx.Model(&models.Table{}).Where("merged_into = ?", combined.Table_id).Updates(updates).Error
+		if err != nil {
+			tx.Rollback()
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		if err := tx.Delete(&combined).Error; err != nil {
+			tx.Rollback()
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		tx.Commit()
+
+		ctx.JSON(http.StatusOK, gin.H{
+			"message":  "table split",
+			"table_id": combined.Table_id,
+		})
+	}
+}
+
+// mergedMessage explains that a table is merged into a combined table.
+func mergedMessage(table models.Table) string {
+	if table.Merged_into == nil {
+		return "table is merged"
+	}
+	return "table is merged into " + *table.Merged_into
+}
+
+// errTableChanged is returned when a table left the status it was read in
+// before it could be updated, e.g. because another party was seated there.
+var errTableChanged = &orderError{http.StatusConflict, "table status changed, reload the table and try again"}
+
+// updateTable applies updates to a table only while it is still in the status
+// it was read in, so two requests racing for the same table cannot both win.
+func updateTable(db *gorm.DB, table models.Table, updates map[string]interface{}) error {
+	result := db.Model(&models.Table{}).Where("table_id = ? AND status = ?", table.Table_id, table.Status).Updates(updates)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errTableChanged
+	}
+	return nil
+}
+
+// setTableStatus moves a table to the given status without touching the seated party.
+func setTableStatus(db *gorm.DB, table models.Table, status string) error {
+	if table.Status == status {
+		return nil
+	}
+	return updateTable(db, table, tableStatusUpdates(status))
+}
+
+// tableStatusUpdates are the columns to update to move a table to a status.
+func tableStatusUpdates(status string) map[string]interface{} {
+	updates := map[string]interface{}{"status": status}
+	if releasesTable(status) {
+		updates["guest_token_version"] = gorm.Expr("guest_token_version + 1")
+	}
+	return updates
+}
+
+// releasesTable reports whether the party has left a table in this status,
+// which revokes the guest tokens handed to them.
+func releasesTable(status string) bool {
+	return status == models.TableAvailable || status == models.TableNeedsCleaning
+}
+
+// takesOrders reports whether an order can be placed at a table in this status.
+// A table that is reserved, merged or still dirty from the last party cannot.
+func takesOrders(status string) bool {
+	switch status {
+	case models.TableAvailable, models.TableSeated, models.TableOrdering, models.TableAwaitingPayment:
+		return true
+	}
+	return false
+}
+
+// seatParty marks a table as seated by a party of the given size.
+func seatParty(db *gorm.DB, table models.Table, partySize int) error {
+	return updateTable(db, table, map[string]interface{}{
+		"status":     models.TableSeated,
+		"party_size": partySize,
+		"seated_at":  time.Now(),
+	})
+}
+
+// clearTable makes a table available again and forgets the party that was seated there.
+func clearTable(db *gorm.DB, table models.Table) error {
+	updates := tableStatusUpdates(models.TableAvailable)
+	updates["party_size"] = nil
+	updates["seated_at"] = nil
+	return updateTable(db, table, updates)
+}
+
+// syncTableWithInvoice reflects an invoice's payment status on the table of its order.
+// A table that is no longer ordering or awaiting payment has moved on to
+// another party and is left alone.
+func syncTableWithInvoice(db *gorm.DB, invoice models.Invoice) error {
+	var order models.Order
+
+	if err := db.Where("order_id = ?", invoice.Order_id).First(&order).Error; err != nil {
+		return nil
+	}
+
+	if order.Table_id == nil {
+		return nil
+	}
+
+	var table models.Table
+	if err := db.Where("table_id = ?", *order.Table_id).First(&table).Error; err != nil {
+		return nil
+	}
+
+	if table.Status != models.TableOrdering && table.Status != models.TableAwaitingPayment {
+		return nil
+	}
+
+	status := models.TableAwaitingPayment
+	if invoice.Payment_status != nil && *invoice.Payment_status == "PAID" {
+		status = models.TableNeedsCleaning
+	}
+
+	if err := setTableStatus(db, table, status); err != nil && !errors.Is(err, errTableChanged) {
+		return err
+	}
+	return nil
+}
+
+// openOrdersByTable returns the most recent unpaid order_id for every occupied
+// table that has one. Only orders placed since the current party was seated
+// count, so the lookup stays small however long the order history grows.
+func openOrdersByTable(db *gorm.DB) (map[string]string, error) {
+	var rows []struct {
+		Order_id string
+		Table_id string
+	}
+
+	err := db.Model(&models.Order{}).
+		Select("orders.order_id, orders.table_id").
+		Joins("JOIN tables ON tables.table_id = orders.table_id AND tables.deleted_at IS NULL").
+		Joins("LEFT JOIN invoices ON invoices.order_id = orders.order_id AND invoices.deleted_at IS NULL").
+		Where("tables.status IN ?", []string{models.TableSeated, models.TableOrdering, models.TableAwaitingPayment}).
+		Where("tables.seated_at IS NULL OR orders.created_at >= tables.seated_at").
+		Where("invoices.payment_status IS NULL OR invoices.payment_status <> ?", "PAID").
+		Order("orders.created_at DESC").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	openOrders := make(map[string]string)
+	for _, row := range rows {
+		if _, ok := openOrders[row.Table_id]; !ok {
+			openOrders[row.Table_id] = row.Order_id
+		}
+	}
+
+	return openOrders, nil
+}
@@ -0,0 +1,285 @@
+package controllers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/Hdeee1/go-restaurant-management/database"
+	"github.com/Hdeee1/go-restaurant-management/helpers"
+	"github.com/Hdeee1/go-restaurant-management/models"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// GetTaxCategories godoc
+//
+//	@Summary		Get tax categories
+//	@Description	Retrieve every tax category
+//	@Tags			Taxes
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Success		200	{object}	map[string]interface{}
+//	@Failure		500	{object}	map[string]interface{}
+//	@Router			/tax-categories [get]
+func GetTaxCategories() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var categories []models.TaxCategory
+
+		if err := database.DB.Order("name").Find(&categories).Error; err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{"tax_categories": categories})
+	}
+}
+
+// CreateTaxCategory godoc
+//
+//	@Summary		Create a tax category (Admin only)
+//	@Description	Create a tax category that foods can be assigned to, e.g. food or alcohol
+//	@Tags			Taxes
+//	@Accept			json
+//	@Produce		json
+//	@Param			tax_category	body	models.TaxCategory	true	"Tax category"
+//	@Security		BearerAuth
+//	@Success		201	{object}	map[string]interface{}
+//	@Failure		400	{object}	map[string]interface{}
+//	@Failure		500	{object}	map[string]interface{}
+//	@Router			/tax-categories [post]
+func CreateTaxCategory() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var category models.TaxCategory
+
+		if err := ctx.BindJSON(&category); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		if err := helpers.Validate.Struct(category); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		category.Tax_category_id = uuid.New().String()
+
+		if err := database.DB.Create(&category).Error; err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		ctx.JSON(http.StatusCreated, gin.H{
+			"message":         "tax category created",
+			"tax_category_id": category.Tax_category_id,
+		})
+	}
+}
+
+// GetTaxRates godoc
+//
+//	@Summary		Get tax rates
+//	@Description	Retrieve tax rates, optionally for one category or only those in effect at a time
+//	@Tags			Taxes
+//	@Accept			json
+//	@Produce		json
+//	@Param			tax_category_id	query	string	false	"Tax category ID"
+//	@Param			at				query	string	false	"Only rates in effect at this time (RFC3339)"
+//	@Security		BearerAuth
+//	@Success		200	{object}	map[string]interface{}
+//	@Failure		400	{object}	map[string]interface{}
+//	@Failure		500	{object}	map[string]interface{}
+//	@Router			/tax-rates [get]
+func GetTaxRates() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		query := database.DB.Order("effective_from DESC")
+
+		if categoryID := ctx.Query("tax_category_id"); categoryID != "" {
+			query = query.Where("tax_category_id = ?", categoryID)
+		}
+
+		if at := ctx.Query("at"); at != "" {
+			atTime, err := time.Parse(time.RFC3339, at)
+			if err != nil {
+				ctx.JSON(http.StatusBadRequest, gin.H{"error": "at must be an RFC3339 time"})
+				return
+			}
+			query = query.Scopes(taxRatesInEffect(atTime))
+		}
+
+		var rates []models.TaxRate
+
+		if err := query.Find(&rates).Error; err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{"tax_rates": rates})
+	}
+}
+
+// CreateTaxRate godoc
+//
+//	@Summary		Create a tax rate (Admin only)
+//	@Description	Add a rate to a tax category. Set service_type to charge it only for dine-in or takeout orders.
+//	@Tags			Taxes
+//	@Accept			json
+//	@Produce		json
+//	@Param			tax_rate	body	models.TaxRate	true	"Tax rate"
+//	@Security		BearerAuth
+//	@Success		201	{object}	map[string]interface{}
+//	@Failure		400	{object}	map[string]interface{}
+//	@Failure		404	{object}	map[string]interface{}
+//	@Failure		500	{object}	map[string]interface{}
+//	@Router			/tax-rates [post]
+func CreateTaxRate() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var rate models.TaxRate
+
+		if err := ctx.BindJSON(&rate); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		if err := helpers.Validate.Struct(rate); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		var category models.TaxCategory
+		if err := database.DB.Where("tax_category_id = ?", *rate.Tax_category_id).First(&category).Error; err != nil {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "tax_category_id not found"})
+			return
+		}
+
+		rate.Tax_rate_id = uuid.New().String()
+
+		if err := database.DB.Create(&rate).Error; err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		ctx.JSON(http.StatusCreated, gin.H{
+			"message":     "tax rate created",
+			"tax_rate_id": rate.Tax_rate_id,
+		})
+	}
+}
+
+// TaxRateUpdate ends a tax rate. Invoices are taxed with the rates in effect
+// when they were issued, so the rest of a rate cannot change; a new rate
+// takes over from the end of the old one instead.
+type TaxRateUpdate struct {
+	Effective_to *time.Time `json:"effective_to" validate:"required"`
+
+	Name           *string    `json:"name"`
+	Rate           *float64   `json:"rate"`
+	Inclusive      *bool      `json:"inclusive"`
+	Service_type   *string    `json:"service_type"`
+	Effective_from *time.Time `json:"effective_from"`
+}
+
+// UpdateTaxRate godoc
+//
+//	@Summary		End a tax rate (Admin only)
+//	@Description	Set effective_to to end a rate when a new one takes over. The end cannot be in the past, and nothing else about a rate can change, so invoices already issued keep their taxes.
+//	@Tags			Taxes
+//	@Accept			json
+//	@Produce		json
+//	@Param			tax_rate_id	path	string			true	"Tax rate ID"
+//	@Param			tax_rate	body	TaxRateUpdate	true	"End of the rate"
+//	@Security		BearerAuth
+//	@Success		200	{object}	map[string]interface{}
+//	@Failure		400	{object}	map[string]interface{}
+//	@Failure		404	{object}	map[string]interface{}
+//	@Failure		409	{object}	map[string]interface{}
+//	@Failure		500	{object}	map[string]interface{}
+//	@Router			/tax-rates/{tax_rate_id} [patch]
+func UpdateTaxRate() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var rate models.TaxRate
+
+		if err := database.DB.Where("tax_rate_id = ?", ctx.Param("tax_rate_id")).First(&rate).Error; err != nil {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "tax_rate_id not found"})
+			return
+		}
+
+		var updateData TaxRateUpdate
+		if err := ctx.BindJSON(&updateData); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		if updateData.Name != nil || updateData.Rate != nil || updateData.Inclusive != nil ||
+			updateData.Service_type != nil || updateData.Effective_from != nil {
+			ctx.JSON(http.StatusConflict, gin.H{"error": "only effective_to can change; end this rate and create a new one"})
+			return
+		}
+
+		if err := helpers.Validate.Struct(updateData); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		now := time.Now()
+		if rate.Effective_to != nil && !rate.Effective_to.After(now) {
+			ctx.JSON(http.StatusConflict, gin.H{"error": "tax rate has already ended"})
+			return
+		}
+
+		effectiveTo := *updateData.Effective_to
+		if effectiveTo.Before(now) {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "effective_to cannot be in the past"})
+			return
+		}
+		if !effectiveTo.After(*rate.Effective_from) {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "effective_to must be after effective_from"})
+			return
+		}
+
+		if err := database.DB.Model(&rate).Update("effective_to", effectiveTo).Error; err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{
+			"message":     "tax rate updated",
+			"tax_rate_id": rate.Tax_rate_id,
+		})
+	}
+}
+
+func taxRatesInEffect(at time.Time) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("effective_from <= ? AND (effective_to IS NULL OR effective_to > ?)", at, at)
+	}
+}
+
+// taxRules picks the rates in effect at a time and returns them as tax rules
+// per tax category for an order of a service type. Rates for the service type
+// replace the category's general rates.
+func taxRules(rates []models.TaxRate, serviceType string, at time.Time) map[string][]helpers.TaxRule {
+	general := map[string][]helpers.TaxRule{}
+	specific := map[string][]helpers.TaxRule{}
+
+	for _, rate := range rates {
+		if rate.Effective_from.After(at) || (rate.Effective_to != nil && !rate.Effective_to.After(at)) {
+			continue
+		}
+		rule := helpers.TaxRule{Id: rate.Tax_rate_id, Label: *rate.Name, Rate: *rate.Rate, Inclusive: rate.Inclusive}
+
+		switch {
+		case rate.Service_type == nil || *rate.Service_type == "":
+			general[*rate.Tax_category_id] = append(general[*rate.Tax_category_id], rule)
+		case *rate.Service_type == serviceType:
+			specific[*rate.Tax_category_id] = append(specific[*rate.Tax_category_id], rule)
+		}
+	}
+
+	for categoryID, rules := range specific {
+		general[categoryID] = rules
+	}
+
+	return general
+}
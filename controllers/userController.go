@@ -36,8 +36,9 @@ func VerifyPassword(password string, providedPassword string) (bool, string) {
 //	@Tags			Users
 //	@Accept			json
 //	@Produce		json
-//	@Param			page	query	int	false	"Page number"		default(1)
-//	@Param			limit	query	int	false	"Items per page"	default(10)
+//	@Param			page	query	int		false	"Page number"		default(1)
+//	@Param			limit	query	int		false	"Items per page"	default(10)
+//	@Param			format	query	string	false	"json, csv or xlsx; csv and xlsx export every user"
 //	@Security		BearerAuth
 //	@Success		200	{object}	models.UsersListResponse
 //	@Failure		401	{object}	models.ErrorResponse
@@ -47,6 +48,23 @@ func GetUsers() gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		var users []models.User
 
+		format, err := helpers.ExportFormat(ctx)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		if format != "" {
+			header := []string{"user_id", "first_name", "last_name", "email", "phone", "role", "created_at"}
+			err := helpers.ExportQuery(ctx, format, "users", header, database.DB, func(user models.User) ([]interface{}, error) {
+				return []interface{}{user.User_id, user.First_name, user.Last_name, user.Email, user.Phone, user.Role, user.CreatedAt}, nil
+			})
+			if err != nil {
+				helpers.ExportFailed(ctx, err)
+			}
+			return
+		}
+
 		result := database.DB.Scopes(helpers.Paginate(ctx)).Find(&users)
 		if result.Error != nil {
 			ctx.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
@@ -0,0 +1,453 @@
+package controllers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/Hdeee1/go-restaurant-management/database"
+	"github.com/Hdeee1/go-restaurant-management/helpers"
+	"github.com/Hdeee1/go-restaurant-management/models"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type WaitlistEntryResponse struct {
+	models.WaitlistEntry
+	Estimated_wait_minutes *int `json:"estimated_wait_minutes"`
+}
+
+type SeatNextRequest struct {
+	Table_id string `json:"table_id" validate:"required"`
+}
+
+// waitEstimator holds a snapshot of the floor and the queue used to estimate waits.
+type waitEstimator struct {
+	tables   []models.Table
+	waiting  []models.WaitlistEntry
+	turnTime time.Duration
+	now      time.Time
+}
+
+func newWaitEstimator(db *gorm.DB) (*waitEstimator, error) {
+	estimator := &waitEstimator{now: time.Now()}
+
+	if err := db.Where("status <> ?", models.TableMerged).Find(&estimator.tables).Error; err != nil {
+		return nil, err
+	}
+
+	err := db.Where("status = ?", models.WaitlistWaiting).Order("arrival_time").Find(&estimator.waiting).Error
+	if err != nil {
+		return nil, err
+	}
+
+	turnTime, err := averageTurnTime(db, estimator.now.AddDate(0, 0, -30))
+	if err != nil {
+		return nil, err
+	}
+	estimator.turnTime = turnTime
+
+	return estimator, nil
+}
+
+// estimate returns the expected wait for a party, or false when no table is big enough.
+func (e *waitEstimator) estimate(partySize int, arrival time.Time) (time.Duration, bool) {
+	var remaining []time.Duration
+	largest := 0
+
+	for _, table := range e.tables {
+		if table.Number_of_guest == nil || *table.Number_of_guest < partySize {
+			continue
+		}
+		if *table.Number_of_guest > largest {
+			largest = *table.Number_of_guest
+		}
+
+		switch table.Status {
+		case models.TableAvailable, models.TableNeedsCleaning:
+			remaining = append(remaining, 0)
+		case models.TableReserved:
+			remaining = append(remaining, e.turnTime)
+		default:
+			elapsed := e.turnTime / 2
+			if table.Seated_at != nil {
+				elapsed = e.now.Sub(*table.Seated_at)
+			}
+			remaining = append(remaining, e.turnTime-elapsed)
+		}
+	}
+
+	if len(remaining) == 0 {
+		return 0, false
+	}
+
+	ahead := 0
+	for _, entry := range e.waiting {
+		if !entry.Arrival_time.Before(arrival) {
+			break
+		}
+		if entry.Party_size != nil && *entry.Party_size <= largest {
+			ahead++
+		}
+	}
+
+	return helpers.EstimateWait(remaining, ahead, e.turnTime), true
+}
+
+func (e *waitEstimator) response(entry models.WaitlistEntry) WaitlistEntryResponse {
+	res := WaitlistEntryResponse{WaitlistEntry: entry}
+
+	if entry.Status != models.WaitlistWaiting || entry.Party_size == nil {
+		return res
+	}
+
+	if wait, ok := e.estimate(*entry.Party_size, entry.Arrival_time); ok {
+		minutes := int(wait.Round(time.Minute) / time.Minute)
+		res.Estimated_wait_minutes = &minutes
+	}
+
+	return res
+}
+
+// averageTurnTime is the mean time from ordering to payment for orders placed since the given time.
+func averageTurnTime(db *gorm.DB, since time.Time) (time.Duration, error) {
+	var turns []struct {
+		Started  time.Time
+		Finished time.Time
+	}
+
+	err := db.Model(&models.Order{}).
+		Select("orders.created_at AS started, invoices.updated_at AS finished").
+		Joins("JOIN invoices ON invoices.order_id = orders.order_id AND invoices.deleted_at IS NULL").
+		Where("invoices.payment_status = ? AND orders.created_at >= ?", "PAID", since).
+		Scan(&turns).Error
+	if err != nil {
+		return 0, err
+	}
+
+	var total time.Duration
+	count := 0
+	for _, turn := range turns {
+		if turn.Finished.After(turn.Started) {
+			total += turn.Finished.Sub(turn.Started)
+			count++
+		}
+	}
+
+	if count == 0 {
+		return helpers.DefaultTurnTime(), nil
+	}
+
+	return total / time.Duration(count), nil
+}
+
+// GetWaitlist godoc
+//
+//	@Summary		Get the waitlist
+//	@Description	Retrieve waitlist entries in arrival order with an estimated wait for parties still waiting
+//	@Tags			Waitlist
+//	@Accept			json
+//	@Produce		json
+//	@Param			status	query	string	false	"Entry status"	default(WAITING)
+//	@Security		BearerAuth
+//	@Success		200	{object}	map[string]interface{}
+//	@Failure		500	{object}	map[string]interface{}
+//	@Router			/waitlist [get]
+func GetWaitlist() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var entries []models.WaitlistEntry
+
+		status := ctx.DefaultQuery("status", models.WaitlistWaiting)
+
+		if err := database.DB.Where("status = ?", status).Order("arrival_time").Find(&entries).Error; err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		estimator, err := newWaitEstimator(database.DB)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		waitlist := make([]WaitlistEntryResponse, 0, len(entries))
+		for _, entry := range entries {
+			waitlist = append(waitlist, estimator.response(entry))
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{
+			"waitlist":          waitlist,
+			"turn_time_minutes": int(estimator.turnTime / time.Minute),
+		})
+	}
+}
+
+// GetWaitlistEntry godoc
+//
+//	@Summary		Get waitlist entry by ID
+//	@Description	Retrieve a specific waitlist entry by waitlist_id with its estimated wait
+//	@Tags			Waitlist
+//	@Accept			json
+//	@Produce		json
+//	@Param			waitlist_id	path	string	true	"Waitlist ID"
+//	@Security		BearerAuth
+//	@Success		200	{object}	WaitlistEntryResponse
+//	@Failure		404	{object}	map[string]interface{}
+//	@Failure		500	{object}	map[string]interface{}
+//	@Router			/waitlist/{waitlist_id} [get]
+func GetWaitlistEntry() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		waitlistID := ctx.Param("waitlist_id")
+
+		var entry models.WaitlistEntry
+
+		if err := database.DB.Where("waitlist_id = ?", waitlistID).First(&entry).Error; err != nil {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "waitlist_id not found"})
+			return
+		}
+
+		estimator, err := newWaitEstimator(database.DB)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		ctx.JSON(http.StatusOK, estimator.response(entry))
+	}
+}
+
+// CreateWaitlistEntry godoc
+//
+//	@Summary		Add a party to the waitlist
+//	@Description	Add a walk-in party to the waitlist and quote an estimated wait
+//	@Tags			Waitlist
+//	@Accept			json
+//	@Produce		json
+//	@Param			entry	body	models.WaitlistEntry	true	"Waitlist entry"
+//	@Security		BearerAuth
+//	@Success		201	{object}	map[string]interface{}
+//	@Failure		400	{object}	map[string]interface{}
+//	@Failure		500	{object}	map[string]interface{}
+//	@Router			/waitlist [post]
+func CreateWaitlistEntry() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var entry models.WaitlistEntry
+
+		if err := ctx.BindJSON(&entry); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		if err := helpers.Validate.Struct(entry); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		entry.Waitlist_id = uuid.New().String()
+		entry.Arrival_time = time.Now()
+		entry.Status = models.WaitlistWaiting
+		entry.Table_id = nil
+		entry.Seated_at = nil
+
+		estimator, err := newWaitEstimator(database.DB)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		wait, ok := estimator.estimate(*entry.Party_size, entry.Arrival_time)
+		if !ok {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "no table can seat this party"})
+			return
+		}
+		entry.Quoted_minutes = int(wait.Round(time.Minute) / time.Minute)
+
+		if err := database.DB.Create(&entry).Error; err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		ctx.JSON(http.StatusCreated, gin.H{
+			"message":                "party added to waitlist",
+			"waitlist_id":            entry.Waitlist_id,
+			"estimated_wait_minutes": entry.Quoted_minutes,
+		})
+	}
+}
+
+// WaitlistUpdate holds what can change on a waiting party. Parties are
+// seated through /waitlist/seat-next.
+type WaitlistUpdate struct {
+	Party_name *string `json:"party_name" validate:"omitempty,min=1,max=100"`
+	Party_size *int    `json:"party_size" validate:"omitempty,min=1"`
+	Phone      *string `json:"phone" validate:"omitempty,min=1"`
+	Status     *string `json:"status" validate:"omitempty,eq=WAITING|eq=CANCELLED|eq=NO_SHOW"`
+}
+
+// UpdateWaitlistEntry godoc
+//
+//	@Summary		Update a waitlist entry
+//	@Description	Update a waiting party by waitlist_id, e.g. to change the party size, or cancel it or mark it a no-show. Parties that are no longer waiting cannot change.
+//	@Tags			Waitlist
+//	@Accept			json
+//	@Produce		json
+//	@Param			waitlist_id	path	string			true	"Waitlist ID"
+//	@Param			entry		body	WaitlistUpdate	true	"Changes"
+//	@Security		BearerAuth
+//	@Success		200	{object}	map[string]interface{}
+//	@Failure		400	{object}	map[string]interface{}
+//	@Failure		404	{object}	map[string]interface{}
+//	@Failure		409	{object}	map[string]interface{}
+//	@Failure		500	{object}	map[string]interface{}
+//	@Router			/waitlist/{waitlist_id} [patch]
+func UpdateWaitlistEntry() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		waitlistID := ctx.Param("waitlist_id")
+
+		var entry models.WaitlistEntry
+
+		if err := database.DB.Where("waitlist_id = ?", waitlistID).First(&entry).Error; err != nil {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "waitlist_id not found"})
+			return
+		}
+
+		var updateData WaitlistUpdate
+		if err := ctx.BindJSON(&updateData); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		if err := helpers.Validate.Struct(updateData); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		if entry.Status != models.WaitlistWaiting {
+			ctx.JSON(http.StatusConflict, gin.H{"error": "party is " + entry.Status})
+			return
+		}
+
+		updates := map[string]interface{}{}
+		if updateData.Party_name != nil {
+			updates["party_name"] = *updateData.Party_name
+		}
+		if updateData.Party_size != nil {
+			updates["party_size"] = *updateData.Party_size
+		}
+		if updateData.Phone != nil {
+			updates["phone"] = *updateData.Phone
+		}
+		if updateData.Status != nil {
+			updates["status"] = *updateData.Status
+		}
+
+		if len(updates) > 0 {
+			// The party may be seated meanwhile, which ends their wait.
+			result := database.DB.Model(&entry).Where("status = ?", models.WaitlistWaiting).Updates(updates)
+			if result.Error != nil {
+				ctx.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
+				return
+			}
+			if result.RowsAffected == 0 {
+				ctx.JSON(http.StatusConflict, gin.H{"error": "party is no longer waiting"})
+				return
+			}
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{
+			"message":     "waitlist entry updated",
+			"waitlist_id": entry.Waitlist_id,
+		})
+	}
+}
+
+// SeatNextFromWaitlist godoc
+//
+//	@Summary		Seat the next waiting party
+//	@Description	Seat the longest-waiting party that fits a freed table and notify them that their table is ready
+//	@Tags			Waitlist
+//	@Accept			json
+//	@Produce		json
+//	@Param			table	body	SeatNextRequest	true	"Freed table"
+//	@Security		BearerAuth
+//	@Success		200	{object}	map[string]interface{}
+//	@Failure		400	{object}	map[string]interface{}
+//	@Failure		404	{object}	map[string]interface{}
+//	@Failure		409	{object}	map[string]interface{}
+//	@Failure		500	{object}	map[string]interface{}
+//	@Router			/waitlist/seat-next [post]
+func SeatNextFromWaitlist() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var req SeatNextRequest
+
+		if err := ctx.BindJSON(&req); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		if err := helpers.Validate.Struct(req); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		var table models.Table
+
+		if err := database.DB.Where("table_id = ?", req.Table_id).First(&table).Error; err != nil {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "table_id not found"})
+			return
+		}
+
+		if table.Status != models.TableAvailable {
+			ctx.JSON(http.StatusConflict, gin.H{"error": "table is " + table.Status})
+			return
+		}
+
+		var entry models.WaitlistEntry
+
+		err := database.DB.
+			Where("status = ? AND party_size <= ?", models.WaitlistWaiting, *table.Number_of_guest).
+			Order("arrival_time").
+			First(&entry).Error
+		if err != nil {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "no waiting party fits this table"})
+			return
+		}
+
+		now := time.Now()
+
+		// Both rows only change if nobody else seated the party or took the
+		// table in the meantime.
+		err = database.DB.Transaction(func(tx *gorm.DB) error {
+			result := tx.Model(&entry).Where("status = ?", models.WaitlistWaiting).Updates(map[string]interface{}{
+				"status":    models.WaitlistSeated,
+				"table_id":  table.Table_id,
+				"seated_at": now,
+			})
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				return &orderError{http.StatusConflict, "party is no longer waiting"}
+			}
+
+			return seatParty(tx, table, *entry.Party_size)
+		})
+		if err != nil {
+			ctx.JSON(orderErrorStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+
+		message := fmt.Sprintf("Hi %s, your table (number %d) is ready.", *entry.Party_name, *table.Table_number)
+		if err := helpers.GuestNotifier.Notify(*entry.Phone, message); err != nil {
+			log.Printf("waitlist %s: notify failed: %v", entry.Waitlist_id, err)
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{
+			"message":     "party seated",
+			"waitlist_id": entry.Waitlist_id,
+			"table_id":    table.Table_id,
+		})
+	}
+}
@@ -18,7 +18,7 @@ func InitDB() {
 	dsn := os.Getenv("DB_USER") + ":" + os.Getenv("DB_PASSWORD") + "@tcp(" + os.Getenv("DB_HOST") + ":" + os.Getenv("DB_PORT") + ")/" + os.Getenv("DB_NAME") + "?parseTime=true"
 
 	var err error
-	DB, err = gorm.Open(mysql.Open(dsn), &gorm.Config{})
+	DB, err = gorm.Open(mysql.Open(dsn), &gorm.Config{TranslateError: true})
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -28,9 +28,46 @@ func InitDB() {
 		&models.Food{},
 		&models.Invoice{},
 		&models.Menu{},
+		&models.Category{},
 		&models.Note{},
 		&models.Order{},
 		&models.OrderItem{},
 		&models.Table{},
+		&models.Section{},
+		&models.SectionAssignment{},
+		&models.WaitlistEntry{},
+		&models.Printer{},
+		&models.PrintJob{},
+		&models.Payment{},
+		&models.FoodPrice{},
+		&models.FoodImage{},
+		&models.BundleSlot{},
+		&models.BundleOption{},
+		&models.PriceChange{},
+		&models.ExchangeRate{},
+		&models.TaxCategory{},
+		&models.TaxRate{},
+		&models.InvoiceSequence{},
+		&models.PaymentRefund{},
+		&models.PaymentEvent{},
+		&models.BusinessDay{},
+		&models.CashDrawerSession{},
+		&models.CashMovement{},
 	)
+
+	if err := migrateFloatMoney(DB); err != nil {
+		log.Fatal(err)
+	}
+
+	if err := numberExistingInvoices(DB); err != nil {
+		log.Fatal(err)
+	}
+
+	if err := backfillVoidedAt(DB); err != nil {
+		log.Fatal(err)
+	}
+
+	if err := backfillOpenDrawers(DB); err != nil {
+		log.Fatal(err)
+	}
 }
@@ -0,0 +1,117 @@
+package database
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/Hdeee1/go-restaurant-management/models"
+	"gorm.io/gorm"
+)
+
+// floatMoneyColumns maps the old float price columns to the prefix of the
+// money columns that replaced them.
+var floatMoneyColumns = []struct {
+	table  string
+	column string
+	prefix string
+}{
+	{"foods", "price", "price_"},
+	{"order_items", "unit_price", "unit_price_"},
+	{"payments", "amount", "amount_"},
+	{"payments", "refunded_amount", "refunded_"},
+	{"payments", "tip_amount", "tip_"},
+}
+
+// migrateFloatMoney moves amounts stored as floats into minor units in the
+// default currency and drops the float columns. It runs after AutoMigrate has
+// added the new columns and does nothing once the old columns are gone.
+func migrateFloatMoney(db *gorm.DB) error {
+	currency := models.DefaultCurrency()
+	scale := int64(math.Pow10(models.CurrencyExponent(currency)))
+
+	for _, money := range floatMoneyColumns {
+		if !db.Migrator().HasColumn(money.table, money.column) {
+			continue
+		}
+
+		err := db.Transaction(func(tx *gorm.DB) error {
+			// DECIMAL arithmetic rounds halves away from zero, like models.RoundMinor.
+			err := tx.Exec(fmt.Sprintf(
+				"UPDATE %s SET %sminor = ROUND(CAST(%s AS DECIMAL(20,6)) * ?), %scurrency = ? WHERE %s IS NOT NULL",
+				money.table, money.prefix, money.column, money.prefix, money.column,
+			), scale, currency).Error
+			if err != nil {
+				return err
+			}
+
+			return tx.Migrator().DropColumn(money.table, money.column)
+		})
+		if err != nil {
+			return fmt.Errorf("migrate %s.%s: %w", money.table, money.column, err)
+		}
+	}
+
+	return nil
+}
+
+// numberExistingInvoices gives invoices created before numbering a number
+// from their year's sequence, in the order they were created.
+func numberExistingInvoices(db *gorm.DB) error {
+	var invoices []models.Invoice
+
+	if err := db.Unscoped().Where("invoice_number IS NULL").Order("created_at, id").Find(&invoices).Error; err != nil {
+		return err
+	}
+
+	if len(invoices) == 0 {
+		return nil
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		sequences := map[string]*models.InvoiceSequence{}
+
+		for _, invoice := range invoices {
+			year := invoice.CreatedAt.Year()
+			key := fmt.Sprintf("%s/%d", invoice.Branch, year)
+
+			sequence, ok := sequences[key]
+			if !ok {
+				sequence = &models.InvoiceSequence{Branch: invoice.Branch, Year: year}
+				if err := tx.Where("branch = ? AND year = ?", invoice.Branch, year).FirstOrCreate(sequence).Error; err != nil {
+					return err
+				}
+				sequences[key] = sequence
+			}
+
+			sequence.Last_number++
+			number := models.InvoiceNumber(invoice.Branch, year, sequence.Last_number)
+			if err := tx.Unscoped().Model(&invoice).Update("invoice_number", number).Error; err != nil {
+				return err
+			}
+		}
+
+		for _, sequence := range sequences {
+			if err := tx.Model(sequence).Update("last_number", sequence.Last_number).Error; err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// backfillVoidedAt dates payments voided before voided_at was recorded by
+// their last update, which was the void.
+func backfillVoidedAt(db *gorm.DB) error {
+	return db.Model(&models.Payment{}).
+		Where("status = ? AND voided_at IS NULL", models.PaymentVoided).
+		UpdateColumn("voided_at", gorm.Expr("updated_at")).Error
+}
+
+// backfillOpenDrawers claims the drawer and cashier of sessions opened before
+// open_drawer and open_user were recorded. If a drawer or cashier already has
+// two open sessions, only one of them is claimed.
+func backfillOpenDrawers(db *gorm.DB) error {
+	return db.Exec("UPDATE IGNORE cash_drawer_sessions SET open_drawer = drawer, open_user = opened_by WHERE status = ? AND open_drawer IS NULL AND deleted_at IS NULL",
+		models.DrawerOpen).Error
+}
@@ -0,0 +1,283 @@
+package helpers
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Hdeee1/go-restaurant-management/models"
+)
+
+// TicketWidth is the number of characters per line on an 80mm printer using font B.
+const TicketWidth = 42
+
+const (
+	alignLeft   = 0
+	alignCenter = 1
+	alignRight  = 2
+)
+
+type printOp struct {
+	text   string
+	align  int
+	bold   bool
+	double bool
+	cut    bool
+}
+
+// PrintDocument is a printer-independent ticket layout that renders to ESC/POS
+// for thermal printers or to plain text for previews.
+type PrintDocument struct {
+	ops []printOp
+}
+
+func (d *PrintDocument) add(op printOp) *PrintDocument {
+	d.ops = append(d.ops, op)
+	return d
+}
+
+func (d *PrintDocument) Line(text string) *PrintDocument {
+	return d.add(printOp{text: text})
+}
+
+func (d *PrintDocument) Center(text string) *PrintDocument {
+	return d.add(printOp{text: text, align: alignCenter})
+}
+
+func (d *PrintDocument) Title(text string) *PrintDocument {
+	return d.add(printOp{text: text, align: alignCenter, bold: true, double: true})
+}
+
+func (d *PrintDocument) Bold(text string) *PrintDocument {
+	return d.add(printOp{text: text, bold: true})
+}
+
+// Columns prints left and right aligned text on the same line.
+func (d *PrintDocument) Columns(left string, right string) *PrintDocument {
+	space := TicketWidth - len([]rune(left)) - len([]rune(right))
+	if space < 1 {
+		space = 1
+	}
+	return d.Line(left + strings.Repeat(" ", space) + right)
+}
+
+func (d *PrintDocument) Rule() *PrintDocument {
+	return d.Line(strings.Repeat("-", TicketWidth))
+}
+
+func (d *PrintDocument) Blank() *PrintDocument {
+	return d.Line("")
+}
+
+func (d *PrintDocument) Cut() *PrintDocument {
+	return d.add(printOp{cut: true})
+}
+
+// ESCPOS renders the document as an ESC/POS byte stream.
+func (d *PrintDocument) ESCPOS() []byte {
+	var out bytes.Buffer
+
+	out.Write([]byte{0x1b, '@'})
+
+	for _, op := range d.ops {
+		if op.cut {
+			out.Write([]byte{0x1b, 'd', 3})
+			out.Write([]byte{0x1d, 'V', 66, 0})
+			continue
+		}
+
+		out.Write([]byte{0x1b, 'a', byte(op.align)})
+		if op.bold {
+			out.Write([]byte{0x1b, 'E', 1})
+		}
+		if op.double {
+			out.Write([]byte{0x1d, '!', 0x11})
+		}
+
+		out.WriteString(asciiOnly(op.text))
+		out.WriteByte('\n')
+
+		if op.double {
+			out.Write([]byte{0x1d, '!', 0})
+		}
+		if op.bold {
+			out.Write([]byte{0x1b, 'E', 0})
+		}
+	}
+
+	return out.Bytes()
+}
+
+// PlainText renders the document as it would look on paper, for previews.
+func (d *PrintDocument) PlainText() string {
+	var out strings.Builder
+
+	for _, op := range d.ops {
+		if op.cut {
+			out.WriteString(strings.Repeat("=", TicketWidth) + "\n")
+			continue
+		}
+
+		text := op.text
+		if op.double {
+			text = strings.ToUpper(text)
+		}
+
+		pad := 0
+		switch op.align {
+		case alignCenter:
+			pad = (TicketWidth - len([]rune(text))) / 2
+		case alignRight:
+			pad = TicketWidth - len([]rune(text))
+		}
+		if pad > 0 {
+			text = strings.Repeat(" ", pad) + text
+		}
+
+		out.WriteString(text + "\n")
+	}
+
+	return out.String()
+}
+
+// asciiOnly replaces characters the printer's default code page cannot show.
+func asciiOnly(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r < 0x20 || r > 0x7e {
+			return '?'
+		}
+		return r
+	}, s)
+}
+
+// TicketLine is one item on a kitchen ticket. Components of a bundle share a
+// Bundle_item_id and are printed under the bundle's name.
+type TicketLine struct {
+	Quantity       string
+	Name           string
+	Course         string
+	Bundle         string
+	Bundle_item_id string
+}
+
+// KitchenTicket is what a station needs to cook an order.
+type KitchenTicket struct {
+	Station      string
+	Order_id     string
+	Table_number *int
+	Time         time.Time
+	Lines        []TicketLine
+}
+
+func (t KitchenTicket) Document() *PrintDocument {
+	doc := &PrintDocument{}
+
+	doc.Title(t.Station)
+	if t.Table_number != nil {
+		doc.Title(fmt.Sprintf("TABLE %d", *t.Table_number))
+	}
+	doc.Center("Order " + shortID(t.Order_id))
+	doc.Center(t.Time.Format("2006-01-02 15:04"))
+	doc.Rule()
+
+	course, bundle := "", ""
+	for _, line := range t.Lines {
+		if line.Course != course {
+			course, bundle = line.Course, ""
+			if course != "" {
+				doc.Bold("-- " + course + " --")
+			}
+		}
+		if line.Bundle_item_id == "" {
+			bundle = ""
+			doc.Bold(fmt.Sprintf("[%s] %s", line.Quantity, line.Name))
+			continue
+		}
+		if line.Bundle_item_id != bundle {
+			bundle = line.Bundle_item_id
+			doc.Bold(fmt.Sprintf("[%s] %s", line.Quantity, line.Bundle))
+		}
+		doc.Bold("    - " + line.Name)
+	}
+
+	return doc.Rule().Cut()
+}
+
+type ReceiptLine struct {
+	Name     string
+	Quantity string
+	Price    models.Money
+}
+
+// Receipt is what the customer takes home after paying an invoice.
+type Receipt struct {
+	Restaurant_name string
+	Invoice_id      string
+	Invoice_number  string
+	Order_id        string
+	Table_number    *int
+	Time            time.Time
+	Lines           []ReceiptLine
+	Discounts       []AmountLine
+	Taxes           []AmountLine
+	Included_taxes  []AmountLine
+	Total           models.Money
+	Tip             models.Money
+	Payment_method  string
+	Payment_status  string
+	Footer          string
+}
+
+func (r Receipt) Document() *PrintDocument {
+	doc := &PrintDocument{}
+
+	doc.Title(r.Restaurant_name)
+	doc.Center(r.Time.Format("2006-01-02 15:04"))
+	if r.Invoice_number != "" {
+		doc.Line("Invoice " + r.Invoice_number)
+	} else {
+		doc.Line("Invoice " + shortID(r.Invoice_id))
+	}
+	if r.Table_number != nil {
+		doc.Line(fmt.Sprintf("Table %d", *r.Table_number))
+	}
+	doc.Rule()
+
+	for _, line := range r.Lines {
+		doc.Columns(fmt.Sprintf("%s (%s)", line.Name, line.Quantity), line.Price.String())
+	}
+
+	doc.Rule()
+	for _, discount := range r.Discounts {
+		doc.Columns(discount.Label, discount.Amount.Neg().String())
+	}
+	for _, tax := range r.Taxes {
+		doc.Columns(tax.Label, tax.Amount.String())
+	}
+	doc.add(printOp{text: "TOTAL " + r.Total.String(), align: alignRight, bold: true})
+	for _, tax := range r.Included_taxes {
+		doc.Columns("Incl. "+tax.Label, tax.Amount.String())
+	}
+	if r.Tip.IsPositive() {
+		doc.Columns("Tip", r.Tip.String())
+	}
+	if r.Payment_method != "" {
+		doc.Columns("Payment", r.Payment_method)
+	}
+	doc.Columns("Status", r.Payment_status)
+
+	if r.Footer != "" {
+		doc.Blank()
+		doc.Center(r.Footer)
+	}
+
+	return doc.Cut()
+}
+
+func shortID(id string) string {
+	if len(id) > 8 {
+		return strings.ToUpper(id[:8])
+	}
+	return strings.ToUpper(id)
+}
@@ -0,0 +1,166 @@
+package helpers
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Hdeee1/go-restaurant-management/models"
+)
+
+func TestPrintDocumentESCPOS(t *testing.T) {
+	initialize := []byte{0x1b, '@'}
+
+	tests := []struct {
+		name string
+		doc  *PrintDocument
+		want [][]byte
+	}{
+		{
+			name: "empty",
+			doc:  &PrintDocument{},
+			want: [][]byte{initialize},
+		},
+		{
+			name: "line",
+			doc:  (&PrintDocument{}).Line("Soup"),
+			want: [][]byte{initialize, {0x1b, 'a', 0}, []byte("Soup\n")},
+		},
+		{
+			name: "centered",
+			doc:  (&PrintDocument{}).Center("Order"),
+			want: [][]byte{initialize, {0x1b, 'a', 1}, []byte("Order\n")},
+		},
+		{
+			name: "bold",
+			doc:  (&PrintDocument{}).Bold("[1] Soup"),
+			want: [][]byte{initialize, {0x1b, 'a', 0}, {0x1b, 'E', 1}, []byte("[1] Soup\n"), {0x1b, 'E', 0}},
+		},
+		{
+			name: "title",
+			doc:  (&PrintDocument{}).Title("BAR"),
+			want: [][]byte{initialize, {0x1b, 'a', 1}, {0x1b, 'E', 1}, {0x1d, '!', 0x11}, []byte("BAR\n"), {0x1d, '!', 0}, {0x1b, 'E', 0}},
+		},
+		{
+			name: "cut",
+			doc:  (&PrintDocument{}).Cut(),
+			want: [][]byte{initialize, {0x1b, 'd', 3}, {0x1d, 'V', 66, 0}},
+		},
+		{
+			name: "non ascii",
+			doc:  (&PrintDocument{}).Line("Crème\tbrûlée"),
+			want: [][]byte{initialize, {0x1b, 'a', 0}, []byte("Cr?me?br?l?e\n")},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			want := bytes.Join(tt.want, nil)
+			if got := tt.doc.ESCPOS(); !bytes.Equal(got, want) {
+				t.Errorf("ESCPOS() = %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+func TestPrintDocumentPlainText(t *testing.T) {
+	tests := []struct {
+		name string
+		doc  *PrintDocument
+		want string
+	}{
+		{"line", (&PrintDocument{}).Line("Soup"), "Soup\n"},
+		{"centered", (&PrintDocument{}).Center("ab"), strings.Repeat(" ", 20) + "ab\n"},
+		{"title is upper case", (&PrintDocument{}).Title("bar"), strings.Repeat(" ", 19) + "BAR\n"},
+		{"columns", (&PrintDocument{}).Columns("Soup (2)", "9.00"), "Soup (2)" + strings.Repeat(" ", 30) + "9.00\n"},
+		{"columns too wide", (&PrintDocument{}).Columns(strings.Repeat("a", 40), "9.00"), strings.Repeat("a", 40) + " 9.00\n"},
+		{"rule", (&PrintDocument{}).Rule(), strings.Repeat("-", TicketWidth) + "\n"},
+		{"cut", (&PrintDocument{}).Cut(), strings.Repeat("=", TicketWidth) + "\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.doc.PlainText(); got != tt.want {
+				t.Errorf("PlainText() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestKitchenTicketDocument(t *testing.T) {
+	table := 7
+	ticket := KitchenTicket{
+		Station:      "KITCHEN",
+		Order_id:     "abcdef123456",
+		Table_number: &table,
+		Time:         time.Date(2026, 3, 1, 19, 30, 0, 0, time.UTC),
+		Lines: []TicketLine{
+			{Quantity: "2", Name: "Soup", Course: "STARTER"},
+			{Quantity: "1", Name: "Burger", Course: "MAIN", Bundle: "Lunch deal", Bundle_item_id: "b1"},
+			{Quantity: "1", Name: "Fries", Course: "MAIN", Bundle: "Lunch deal", Bundle_item_id: "b1"},
+			{Quantity: "1", Name: "Steak", Course: "MAIN"},
+		},
+	}
+
+	text := ticket.Document().PlainText()
+
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"station", "KITCHEN\n"},
+		{"table", "TABLE 7\n"},
+		{"short order id", "Order ABCDEF12\n"},
+		{"time", "2026-03-01 19:30\n"},
+		{"course heading", "-- STARTER --\n[2] Soup\n"},
+		{"bundle with its components", "-- MAIN --\n[1] Lunch deal\n    - Burger\n    - Fries\n[1] Steak\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !strings.Contains(text, tt.want) {
+				t.Errorf("ticket does not contain %q:\n%s", tt.want, text)
+			}
+		})
+	}
+}
+
+func TestReceiptDocument(t *testing.T) {
+	usd := func(minor int64) models.Money { return models.Money{Minor: minor, Currency: "USD"} }
+	receipt := Receipt{
+		Restaurant_name: "Bistro",
+		Invoice_number:  "INV-2026-000123",
+		Time:            time.Date(2026, 3, 1, 21, 5, 0, 0, time.UTC),
+		Lines:           []ReceiptLine{{Name: "Soup", Quantity: "2", Price: usd(1800)}},
+		Discounts:       []AmountLine{{"Regulars", usd(300)}},
+		Taxes:           []AmountLine{{"Service 5%", usd(75)}},
+		Total:           usd(1575),
+		Payment_status:  "PAID",
+	}
+
+	text := receipt.Document().PlainText()
+	columns := func(left, right string) string {
+		return left + strings.Repeat(" ", TicketWidth-len(left)-len(right)) + right + "\n"
+	}
+
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"invoice number", "Invoice INV-2026-000123\n"},
+		{"line", columns("Soup (2)", "18.00")},
+		{"discount taken off", columns("Regulars", "-3.00")},
+		{"tax", columns("Service 5%", "0.75")},
+		{"total", "TOTAL 15.75\n"},
+		{"status", columns("Status", "PAID")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !strings.Contains(text, tt.want) {
+				t.Errorf("receipt does not contain %q:\n%s", tt.want, text)
+			}
+		})
+	}
+}
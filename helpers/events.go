@@ -0,0 +1,36 @@
+package helpers
+
+import (
+	"encoding/json"
+	"log"
+	"time"
+)
+
+// Event is something that happened which other systems may want to act on,
+// e.g. sending a payment reminder.
+type Event struct {
+	Type         string                 `json:"type"`
+	Reference_id string                 `json:"reference_id"`
+	Occurred_at  time.Time              `json:"occurred_at"`
+	Data         map[string]interface{} `json:"data,omitempty"`
+}
+
+// EventSink receives events.
+type EventSink interface {
+	Emit(event Event) error
+}
+
+// LogEventSink writes events to the server log.
+type LogEventSink struct{}
+
+func (LogEventSink) Emit(event Event) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	log.Printf("event %s", payload)
+	return nil
+}
+
+// Events is where events are emitted. Replace it at startup to forward them elsewhere.
+var Events EventSink = LogEventSink{}
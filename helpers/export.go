@@ -0,0 +1,270 @@
+package helpers
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Hdeee1/go-restaurant-management/models"
+	"github.com/gin-gonic/gin"
+	"github.com/xuri/excelize/v2"
+	"gorm.io/gorm"
+)
+
+const (
+	ExportCSV  = "csv"
+	ExportXLSX = "xlsx"
+
+	csvContentType  = "text/csv; charset=utf-8"
+	xlsxContentType = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+
+	exportBatchSize = 500
+)
+
+// ExportFormat reads the spreadsheet format a listing was asked for, from the
+// format query or else the Accept header. It is empty for a JSON response.
+func ExportFormat(ctx *gin.Context) (string, error) {
+	switch format := strings.ToLower(ctx.Query("format")); format {
+	case ExportCSV, ExportXLSX:
+		return format, nil
+	case "", "json":
+	default:
+		return "", errors.New("format must be json, csv or xlsx")
+	}
+
+	if ctx.Query("format") == "" {
+		accept := ctx.GetHeader("Accept")
+		switch {
+		case strings.Contains(accept, "text/csv"):
+			return ExportCSV, nil
+		case strings.Contains(accept, xlsxContentType):
+			return ExportXLSX, nil
+		}
+	}
+
+	return "", nil
+}
+
+// Exporter writes rows of a listing to the response as CSV or XLSX. CSV rows
+// go out as they are written; XLSX rows are spooled by excelize's stream
+// writer, which keeps large sheets on disk rather than in memory, and the
+// workbook is sent on Close.
+type Exporter struct {
+	format string
+	out    io.Writer
+	csv    *csv.Writer
+	file   *excelize.File
+	stream *excelize.StreamWriter
+	row    int
+}
+
+// NewExporter starts an export named name (used for the file and the sheet)
+// with a header row.
+func NewExporter(ctx *gin.Context, format string, name string, header []string) (*Exporter, error) {
+	exporter := &Exporter{format: format, out: ctx.Writer}
+	filename := name + "-" + time.Now().Format("20060102-150405") + "." + format
+
+	switch format {
+	case ExportCSV:
+		exporter.csv = csv.NewWriter(ctx.Writer)
+		ctx.Header("Content-Type", csvContentType)
+	case ExportXLSX:
+		exporter.file = excelize.NewFile()
+		if err := exporter.file.SetSheetName("Sheet1", name); err != nil {
+			return nil, err
+		}
+		stream, err := exporter.file.NewStreamWriter(name)
+		if err != nil {
+			return nil, err
+		}
+		exporter.stream = stream
+		ctx.Header("Content-Type", xlsxContentType)
+	default:
+		return nil, fmt.Errorf("unknown export format %q", format)
+	}
+	ctx.Header("Content-Disposition", `attachment; filename="`+filename+`"`)
+
+	values := make([]interface{}, len(header))
+	for i, column := range header {
+		values[i] = column
+	}
+
+	return exporter, exporter.Write(values...)
+}
+
+// Write adds a row. Money is written as its decimal amount and pointers as
+// what they point to, or an empty cell when nil.
+func (e *Exporter) Write(values ...interface{}) error {
+	e.row++
+
+	if e.csv != nil {
+		record := make([]string, len(values))
+		for i, value := range values {
+			record[i] = csvCell(exportCell(value))
+		}
+		return e.csv.Write(record)
+	}
+
+	cells := make([]interface{}, len(values))
+	for i, value := range values {
+		cell := exportCell(value)
+		if money, ok := cell.(models.Money); ok {
+			cell = money.Float()
+		}
+		cells[i] = cell
+	}
+
+	start, err := excelize.CoordinatesToCellName(1, e.row)
+	if err != nil {
+		return err
+	}
+	return e.stream.SetRow(start, cells)
+}
+
+// Close finishes the export and sends whatever is still buffered.
+func (e *Exporter) Close() error {
+	if e.csv != nil {
+		e.csv.Flush()
+		return e.csv.Error()
+	}
+
+	defer e.file.Close()
+	if err := e.stream.Flush(); err != nil {
+		return err
+	}
+	_, err := e.file.WriteTo(e.out)
+	return err
+}
+
+// ExportQuery streams every record a query finds, a batch at a time, as rows
+// of an export.
+func ExportQuery[T any](ctx *gin.Context, format string, name string, header []string, query *gorm.DB, row func(T) ([]interface{}, error)) error {
+	return ExportQueryBatches(ctx, format, name, header, query, func(batch []T) ([][]interface{}, error) {
+		rows := make([][]interface{}, len(batch))
+		for i, record := range batch {
+			values, err := row(record)
+			if err != nil {
+				return nil, err
+			}
+			rows[i] = values
+		}
+		return rows, nil
+	})
+}
+
+// ExportQueryBatches is ExportQuery for rows that need more data: rows gets a
+// whole batch of records at once, so it can load what they need together.
+func ExportQueryBatches[T any](ctx *gin.Context, format string, name string, header []string, query *gorm.DB, rows func([]T) ([][]interface{}, error)) error {
+	exporter, err := NewExporter(ctx, format, name, header)
+	if err != nil {
+		return err
+	}
+
+	var batch []T
+	err = query.FindInBatches(&batch, exportBatchSize, func(tx *gorm.DB, n int) error {
+		values, err := rows(batch)
+		if err != nil {
+			return err
+		}
+		for _, row := range values {
+			if err := exporter.Write(row...); err != nil {
+				return err
+			}
+		}
+		return nil
+	}).Error
+	if err != nil {
+		return err
+	}
+
+	return exporter.Close()
+}
+
+// ExportRows sends rows that are already in memory, such as a report's, as an export.
+func ExportRows(ctx *gin.Context, format string, name string, header []string, rows [][]interface{}) error {
+	exporter, err := NewExporter(ctx, format, name, header)
+	if err != nil {
+		return err
+	}
+
+	for _, row := range rows {
+		if err := exporter.Write(row...); err != nil {
+			return err
+		}
+	}
+
+	return exporter.Close()
+}
+
+func exportCell(value interface{}) interface{} {
+	switch v := value.(type) {
+	case *string:
+		if v == nil {
+			return nil
+		}
+		return *v
+	case *int:
+		if v == nil {
+			return nil
+		}
+		return *v
+	case *float64:
+		if v == nil {
+			return nil
+		}
+		return *v
+	case *time.Time:
+		if v == nil || v.IsZero() {
+			return nil
+		}
+		return *v
+	case time.Time:
+		if v.IsZero() {
+			return nil
+		}
+	case *models.Money:
+		if v == nil {
+			return nil
+		}
+		return *v
+	}
+	return value
+}
+
+// csvCell formats a CSV cell. Text that a spreadsheet would read as a
+// formula is quoted with a leading apostrophe, so exported names and notes
+// cannot run formulas when the file is opened.
+func csvCell(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		if v != "" && strings.ContainsRune("=+-@\t\r", rune(v[0])) {
+			return "'" + v
+		}
+		return v
+	case time.Time:
+		return v.Format(time.RFC3339)
+	case models.Money:
+		return v.String()
+	}
+	return fmt.Sprint(value)
+}
+
+// ExportFailed reports an export error: as JSON when nothing has been sent
+// yet, otherwise only in the log since the response is already under way.
+func ExportFailed(ctx *gin.Context, err error) {
+	if !ctx.Writer.Written() {
+		ctx.Writer.Header().Del("Content-Type")
+		ctx.Writer.Header().Del("Content-Disposition")
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	log.Printf("export %s: %v", ctx.Request.URL.Path, err)
+}
@@ -0,0 +1,38 @@
+package helpers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Hdeee1/go-restaurant-management/models"
+)
+
+func TestCSVCell(t *testing.T) {
+	tests := []struct {
+		name  string
+		value interface{}
+		want  string
+	}{
+		{"nil", nil, ""},
+		{"text", "Soup", "Soup"},
+		{"empty text", "", ""},
+		{"formula", "=HYPERLINK(\"http://x\")", "'=HYPERLINK(\"http://x\")"},
+		{"plus", "+1 555 0100", "'+1 555 0100"},
+		{"minus", "-2+3", "'-2+3"},
+		{"at", "@SUM(A1)", "'@SUM(A1)"},
+		{"tab", "\t=1", "'\t=1"},
+		{"carriage return", "\r=1", "'\r=1"},
+		{"formula later in the text", "a=b", "a=b"},
+		{"negative money", models.Money{Minor: -250, Currency: "USD"}, "-2.50"},
+		{"negative number", -3, "-3"},
+		{"time", time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC), "2026-03-01T12:00:00Z"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := csvCell(tt.value); got != tt.want {
+				t.Errorf("csvCell(%#v) = %q, want %q", tt.value, got, tt.want)
+			}
+		})
+	}
+}
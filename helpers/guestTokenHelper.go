@@ -0,0 +1,72 @@
+package helpers
+
+import (
+	"crypto/sha256"
+	"errors"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// GuestDetail is the claim set of a table's guest token. It only grants
+// ordering at that one table, for as long as the same party sits there:
+// Version must match the table's guest token version, which moves on when
+// the table is released.
+type GuestDetail struct {
+	Table_id string
+	Version  int
+	jwt.RegisteredClaims
+}
+
+// guestKey is derived from SECRET_KEY so guest tokens can never pass as staff tokens.
+func guestKey() []byte {
+	sum := sha256.Sum256([]byte("guest:" + os.Getenv("SECRET_KEY")))
+	return sum[:]
+}
+
+// guestTokenTTL is how long a guest token lasts, GUEST_TOKEN_HOURS (default 4).
+func guestTokenTTL() time.Duration {
+	hours, err := strconv.Atoi(os.Getenv("GUEST_TOKEN_HOURS"))
+	if err != nil || hours <= 0 {
+		hours = 4
+	}
+	return time.Duration(hours) * time.Hour
+}
+
+func GenerateGuestToken(tableID string, version int) (string, error) {
+	claims := &GuestDetail{
+		Table_id: tableID,
+		Version:  version,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   tableID,
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(guestTokenTTL())),
+		},
+	}
+
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(guestKey())
+}
+
+func ValidateGuestToken(signedToken string) (*GuestDetail, error) {
+	token, err := jwt.ParseWithClaims(
+		signedToken,
+		&GuestDetail{},
+		func(t *jwt.Token) (interface{}, error) {
+			return guestKey(), nil
+		},
+		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}),
+		jwt.WithExpirationRequired(),
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	claims, ok := token.Claims.(*GuestDetail)
+	if !ok || claims.Table_id == "" {
+		return nil, errors.New("invalid guest token")
+	}
+
+	return claims, nil
+}
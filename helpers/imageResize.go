@@ -0,0 +1,101 @@
+package helpers
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"net/http"
+
+	"github.com/disintegration/imaging"
+	_ "golang.org/x/image/webp"
+)
+
+// ImageSize is a rendition images are resized to, fitting within Max pixels
+// on their longest side. Smaller images are never enlarged.
+type ImageSize struct {
+	Name string
+	Max  int
+}
+
+// ImageSizes are the renditions stored for every uploaded image. The last one
+// is the main image.
+var ImageSizes = []ImageSize{
+	{Name: "thumbnail", Max: 150},
+	{Name: "medium", Max: 600},
+	{Name: "large", Max: 1200},
+}
+
+// maxImagePixels guards against images that are small files but huge once decoded.
+const maxImagePixels = 40_000_000
+
+var ErrUnsupportedImage = errors.New("image must be a JPEG, PNG, GIF or WebP")
+
+// ResizedImage is one rendition of an uploaded image.
+type ResizedImage struct {
+	Size         string
+	Content_type string
+	Extension    string
+	Width        int
+	Height       int
+	Data         []byte
+}
+
+// ImageContentType sniffs the type of an image from its content, ignoring
+// whatever the client claimed, and rejects anything that is not an image.
+func ImageContentType(data []byte) (string, error) {
+	switch contentType := http.DetectContentType(data); contentType {
+	case "image/jpeg", "image/png", "image/gif", "image/webp":
+		return contentType, nil
+	default:
+		return contentType, ErrUnsupportedImage
+	}
+}
+
+// ResizeImage decodes an uploaded image, turns it upright and renders it in
+// every ImageSize. PNG and GIF images stay PNG to keep transparency; the
+// rest become JPEG.
+func ResizeImage(data []byte) ([]ResizedImage, error) {
+	contentType, err := ImageContentType(data)
+	if err != nil {
+		return nil, err
+	}
+
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrUnsupportedImage
+	}
+	if config.Width*config.Height > maxImagePixels {
+		return nil, errors.New("image is too large")
+	}
+
+	img, err := imaging.Decode(bytes.NewReader(data), imaging.AutoOrientation(true))
+	if err != nil {
+		return nil, ErrUnsupportedImage
+	}
+
+	format, outputType, extension := imaging.JPEG, "image/jpeg", ".jpg"
+	if contentType == "image/png" || contentType == "image/gif" {
+		format, outputType, extension = imaging.PNG, "image/png", ".png"
+	}
+
+	images := make([]ResizedImage, 0, len(ImageSizes))
+	for _, size := range ImageSizes {
+		resized := imaging.Fit(img, size.Max, size.Max, imaging.Lanczos)
+
+		var buf bytes.Buffer
+		if err := imaging.Encode(&buf, resized, format, imaging.JPEGQuality(85)); err != nil {
+			return nil, err
+		}
+
+		images = append(images, ResizedImage{
+			Size:         size.Name,
+			Content_type: outputType,
+			Extension:    extension,
+			Width:        resized.Bounds().Dx(),
+			Height:       resized.Bounds().Dy(),
+			Data:         buf.Bytes(),
+		})
+	}
+
+	return images, nil
+}
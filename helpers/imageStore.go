@@ -0,0 +1,190 @@
+package helpers
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+// ImageStore keeps uploaded images and knows the URL each one is served from.
+type ImageStore interface {
+	Save(key string, contentType string, data []byte) (string, error)
+	Delete(key string) error
+}
+
+// Images is where uploaded images are stored.
+var Images ImageStore = LocalImageStore{Dir: "uploads/images", Mount_path: "/images", Base_url: "/images"}
+
+// NewImageStoreFromEnv returns the store named by IMAGE_STORAGE: "local"
+// (the default) writes under IMAGE_DIR and serves it at the IMAGE_MOUNT_PATH
+// route, linked as IMAGE_BASE_URL (the mount path, unless a proxy or CDN
+// serves it elsewhere); "s3" uploads to the S3_BUCKET bucket of any
+// S3-compatible service at S3_ENDPOINT.
+func NewImageStoreFromEnv() (ImageStore, error) {
+	switch name := os.Getenv("IMAGE_STORAGE"); name {
+	case "", "local":
+		store := LocalImageStore{
+			Dir:        os.Getenv("IMAGE_DIR"),
+			Mount_path: strings.TrimRight(os.Getenv("IMAGE_MOUNT_PATH"), "/"),
+			Base_url:   strings.TrimRight(os.Getenv("IMAGE_BASE_URL"), "/"),
+		}
+		if store.Dir == "" {
+			store.Dir = "uploads/images"
+		}
+		if store.Mount_path == "" {
+			store.Mount_path = "/images"
+		}
+		if store.Base_url == "" {
+			store.Base_url = store.Mount_path
+		}
+
+		if !strings.HasPrefix(store.Mount_path, "/") || strings.ContainsAny(store.Mount_path, ":*?#") {
+			return nil, fmt.Errorf("IMAGE_MOUNT_PATH must be a path such as /images, not %q", store.Mount_path)
+		}
+		if !publicImageURL(store.Base_url) {
+			return nil, fmt.Errorf("IMAGE_BASE_URL must be a path or an http(s) URL, not %q", store.Base_url)
+		}
+		return store, nil
+	case "s3":
+		return NewS3ImageStore(S3Config{
+			Endpoint:   os.Getenv("S3_ENDPOINT"),
+			Region:     os.Getenv("S3_REGION"),
+			Bucket:     os.Getenv("S3_BUCKET"),
+			Access_key: os.Getenv("S3_ACCESS_KEY"),
+			Secret_key: os.Getenv("S3_SECRET_KEY"),
+			Use_ssl:    os.Getenv("S3_USE_SSL") != "false",
+			Public_url: os.Getenv("S3_PUBLIC_URL"),
+		})
+	default:
+		return nil, fmt.Errorf("unknown image storage %q", name)
+	}
+}
+
+// publicImageURL reports whether images can be linked from a base URL: a
+// path on this server or an absolute http(s) URL.
+func publicImageURL(base string) bool {
+	parsed, err := url.Parse(base)
+	if err != nil {
+		return false
+	}
+	if parsed.IsAbs() {
+		return (parsed.Scheme == "http" || parsed.Scheme == "https") && parsed.Host != ""
+	}
+	return strings.HasPrefix(base, "/")
+}
+
+// LocalImageStore writes images to a directory that the API serves itself at
+// Mount_path. Base_url is where clients find them, which is Mount_path
+// unless a proxy or CDN sits in front.
+type LocalImageStore struct {
+	Dir        string
+	Mount_path string
+	Base_url   string
+}
+
+func (s LocalImageStore) Save(key string, contentType string, data []byte) (string, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return "", err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return "", err
+	}
+
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return "", err
+	}
+
+	return s.Base_url + "/" + key, nil
+}
+
+func (s LocalImageStore) Delete(key string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+func (s LocalImageStore) path(key string) (string, error) {
+	path := filepath.Join(s.Dir, filepath.FromSlash(key))
+	if !strings.HasPrefix(path, filepath.Clean(s.Dir)+string(filepath.Separator)) {
+		return "", fmt.Errorf("invalid image key %q", key)
+	}
+	return path, nil
+}
+
+type S3Config struct {
+	Endpoint   string
+	Region     string
+	Bucket     string
+	Access_key string
+	Secret_key string
+	Use_ssl    bool
+	Public_url string
+}
+
+// S3ImageStore uploads images to a bucket on S3 or an S3-compatible service
+// such as MinIO. Images are served straight from the bucket, or from
+// Public_url when a CDN sits in front of it.
+type S3ImageStore struct {
+	client     *minio.Client
+	bucket     string
+	public_url string
+}
+
+func NewS3ImageStore(config S3Config) (*S3ImageStore, error) {
+	if config.Endpoint == "" || config.Bucket == "" {
+		return nil, fmt.Errorf("S3_ENDPOINT and S3_BUCKET are required for s3 image storage")
+	}
+
+	client, err := minio.New(config.Endpoint, &minio.Options{
+		Creds:        credentials.NewStaticV4(config.Access_key, config.Secret_key, ""),
+		Secure:       config.Use_ssl,
+		Region:       config.Region,
+		BucketLookup: minio.BucketLookupPath,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	publicURL := config.Public_url
+	if publicURL == "" {
+		scheme := "http"
+		if config.Use_ssl {
+			scheme = "https"
+		}
+		publicURL = scheme + "://" + config.Endpoint + "/" + config.Bucket
+	}
+
+	return &S3ImageStore{client: client, bucket: config.Bucket, public_url: strings.TrimRight(publicURL, "/")}, nil
+}
+
+func (s *S3ImageStore) Save(key string, contentType string, data []byte) (string, error) {
+	_, err := s.client.PutObject(context.Background(), s.bucket, key, bytes.NewReader(data), int64(len(data)), minio.PutObjectOptions{
+		ContentType: contentType,
+		// Keys are never reused, so images can be cached for good.
+		CacheControl: "public, max-age=31536000, immutable",
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return s.public_url + "/" + key, nil
+}
+
+func (s *S3ImageStore) Delete(key string) error {
+	return s.client.RemoveObject(context.Background(), s.bucket, key, minio.RemoveObjectOptions{})
+}
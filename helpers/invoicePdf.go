@@ -0,0 +1,223 @@
+package helpers
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Hdeee1/go-restaurant-management/models"
+	"github.com/go-pdf/fpdf"
+)
+
+type AmountLine struct {
+	Label  string
+	Amount models.Money
+}
+
+// InvoiceDocument is everything shown on a printed or emailed invoice.
+type InvoiceDocument struct {
+	Restaurant_name    string
+	Restaurant_address string
+	Restaurant_phone   string
+	Restaurant_tax_id  string
+	Logo_path          string
+	Footer             string
+	Invoice_id         string
+	Invoice_number     string
+	Order_id           string
+	Table_number       *int
+	Issued_at          time.Time
+	Due_date           time.Time
+	Payment_method     string
+	Payment_status     string
+	Currency           string
+	Lines              []ReceiptLine
+	Discounts          []AmountLine
+	Taxes              []AmountLine
+	Included_taxes     []AmountLine
+	Charges            []AmountLine
+	Payments           []AmountLine
+	Tip                models.Money
+}
+
+// RestaurantInvoiceDocument starts an invoice document with the restaurant's
+// details and branding taken from the environment.
+func RestaurantInvoiceDocument() InvoiceDocument {
+	name := os.Getenv("RESTAURANT_NAME")
+	if name == "" {
+		name = "Restaurant"
+	}
+
+	return InvoiceDocument{
+		Restaurant_name:    name,
+		Restaurant_address: os.Getenv("RESTAURANT_ADDRESS"),
+		Restaurant_phone:   os.Getenv("RESTAURANT_PHONE"),
+		Restaurant_tax_id:  os.Getenv("RESTAURANT_TAX_ID"),
+		Logo_path:          os.Getenv("INVOICE_LOGO_PATH"),
+		Footer:             os.Getenv("INVOICE_FOOTER"),
+		Currency:           models.DefaultCurrency(),
+		Tip:                models.Zero(models.DefaultCurrency()),
+	}
+}
+
+// Number is the invoice number shown to customers, or the ID of invoices issued before numbering.
+func (d InvoiceDocument) Number() string {
+	if d.Invoice_number != "" {
+		return d.Invoice_number
+	}
+	return d.Invoice_id
+}
+
+func (d InvoiceDocument) Subtotal() models.Money {
+	total := models.Zero(d.Currency)
+	for _, line := range d.Lines {
+		total = total.Add(line.Price)
+	}
+	return total
+}
+
+func (d InvoiceDocument) Total() models.Money {
+	total := d.Subtotal()
+	for _, discount := range d.Discounts {
+		total = total.Sub(discount.Amount)
+	}
+	for _, tax := range d.Taxes {
+		total = total.Add(tax.Amount)
+	}
+	for _, charge := range d.Charges {
+		total = total.Add(charge.Amount)
+	}
+	return total
+}
+
+func (d InvoiceDocument) Paid() models.Money {
+	paid := models.Zero(d.Currency)
+	for _, payment := range d.Payments {
+		paid = paid.Add(payment.Amount)
+	}
+	return paid
+}
+
+func (d InvoiceDocument) Balance() models.Money {
+	return d.Total().Sub(d.Paid())
+}
+
+// PDF renders the invoice as an A4 PDF.
+func (d InvoiceDocument) PDF() ([]byte, error) {
+	pdf := fpdf.New("P", "mm", "A4", "")
+	pdf.SetMargins(15, 15, 15)
+	pdf.SetAutoPageBreak(true, 20)
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+
+	if d.Footer != "" {
+		pdf.SetFooterFunc(func() {
+			pdf.SetY(-15)
+			pdf.SetFont("Helvetica", "I", 8)
+			pdf.SetTextColor(120, 120, 120)
+			pdf.CellFormat(0, 10, tr(d.Footer), "", 0, "C", false, 0, "")
+		})
+	}
+
+	pdf.AddPage()
+
+	headerX := 15.0
+	if d.Logo_path != "" {
+		if _, err := os.Stat(d.Logo_path); err == nil {
+			imageType := strings.TrimPrefix(strings.ToUpper(filepath.Ext(d.Logo_path)), ".")
+			pdf.ImageOptions(d.Logo_path, 15, 15, 0, 20, false, fpdf.ImageOptions{ImageType: imageType, ReadDpi: true}, 0, "")
+			headerX = 45
+		}
+	}
+
+	pdf.SetXY(headerX, 15)
+	pdf.SetFont("Helvetica", "B", 16)
+	pdf.CellFormat(0, 8, tr(d.Restaurant_name), "", 2, "L", false, 0, "")
+	pdf.SetFont("Helvetica", "", 9)
+	for _, detail := range []string{d.Restaurant_address, d.Restaurant_phone, taxIDLabel(d.Restaurant_tax_id)} {
+		if detail != "" {
+			pdf.CellFormat(0, 4.5, tr(detail), "", 2, "L", false, 0, "")
+		}
+	}
+
+	pdf.SetXY(120, 15)
+	pdf.SetFont("Helvetica", "B", 20)
+	pdf.CellFormat(75, 10, "INVOICE", "", 2, "R", false, 0, "")
+	pdf.SetFont("Helvetica", "", 9)
+	pdf.CellFormat(75, 5, "No. "+d.Number(), "", 2, "R", false, 0, "")
+	pdf.CellFormat(75, 5, "Date: "+d.Issued_at.Format("2006-01-02"), "", 2, "R", false, 0, "")
+	if !d.Due_date.IsZero() {
+		pdf.CellFormat(75, 5, "Due: "+d.Due_date.Format("2006-01-02"), "", 2, "R", false, 0, "")
+	}
+	if d.Table_number != nil {
+		pdf.CellFormat(75, 5, fmt.Sprintf("Table %d", *d.Table_number), "", 2, "R", false, 0, "")
+	}
+	pdf.CellFormat(75, 5, "Status: "+d.Payment_status, "", 2, "R", false, 0, "")
+
+	pdf.SetY(55)
+	pdf.SetFont("Helvetica", "B", 10)
+	pdf.SetFillColor(235, 235, 235)
+	pdf.CellFormat(120, 8, "Item", "B", 0, "L", true, 0, "")
+	pdf.CellFormat(25, 8, "Size", "B", 0, "C", true, 0, "")
+	pdf.CellFormat(35, 8, "Amount", "B", 1, "R", true, 0, "")
+
+	pdf.SetFont("Helvetica", "", 10)
+	for _, line := range d.Lines {
+		pdf.CellFormat(120, 7, tr(line.Name), "", 0, "L", false, 0, "")
+		pdf.CellFormat(25, 7, line.Quantity, "", 0, "C", false, 0, "")
+		pdf.CellFormat(35, 7, money(line.Price), "", 1, "R", false, 0, "")
+	}
+
+	pdf.Ln(3)
+	total := func(label string, amount models.Money, bold bool) {
+		style := ""
+		if bold {
+			style = "B"
+		}
+		pdf.SetFont("Helvetica", style, 10)
+		pdf.CellFormat(145, 6, tr(label), "", 0, "R", false, 0, "")
+		pdf.CellFormat(35, 6, money(amount), "", 1, "R", false, 0, "")
+	}
+
+	total("Subtotal", d.Subtotal(), false)
+	for _, discount := range d.Discounts {
+		total(discount.Label, discount.Amount.Neg(), false)
+	}
+	for _, tax := range d.Taxes {
+		total(tax.Label, tax.Amount, false)
+	}
+	for _, charge := range d.Charges {
+		total(charge.Label, charge.Amount, false)
+	}
+	total("Total", d.Total(), true)
+	for _, tax := range d.Included_taxes {
+		total("Incl. "+tax.Label, tax.Amount, false)
+	}
+	for _, payment := range d.Payments {
+		total(payment.Label, payment.Amount.Neg(), false)
+	}
+	total("Balance due", d.Balance(), true)
+	if d.Tip.IsPositive() {
+		total("Tip", d.Tip, false)
+	}
+
+	var out bytes.Buffer
+	if err := pdf.Output(&out); err != nil {
+		return nil, err
+	}
+
+	return out.Bytes(), nil
+}
+
+func taxIDLabel(taxID string) string {
+	if taxID == "" {
+		return ""
+	}
+	return "Tax ID: " + taxID
+}
+
+func money(amount models.Money) string {
+	return amount.String()
+}
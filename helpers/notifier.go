@@ -0,0 +1,19 @@
+package helpers
+
+import "log"
+
+// Notifier delivers short messages to guests, e.g. by SMS.
+type Notifier interface {
+	Notify(phone string, message string) error
+}
+
+// LogNotifier writes notifications to the server log instead of sending them.
+type LogNotifier struct{}
+
+func (LogNotifier) Notify(phone string, message string) error {
+	log.Printf("notify %s: %s", phone, message)
+	return nil
+}
+
+// GuestNotifier is used to reach guests. Replace it at startup to plug in a real provider.
+var GuestNotifier Notifier = LogNotifier{}
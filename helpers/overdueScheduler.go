@@ -0,0 +1,105 @@
+package helpers
+
+import (
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/Hdeee1/go-restaurant-management/models"
+	"gorm.io/gorm"
+)
+
+const (
+	EventInvoiceOverdue  = "invoice.overdue"
+	EventInvoiceReminder = "invoice.reminder"
+)
+
+// OverdueInvoices selects PENDING invoices whose due date has passed.
+func OverdueInvoices(now time.Time) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("payment_status = ? AND payment_due_date > ? AND payment_due_date < ?", "PENDING", time.Time{}, now)
+	}
+}
+
+// StartOverdueScheduler flags overdue invoices in the background every
+// OVERDUE_CHECK_MINUTES (default 60).
+func StartOverdueScheduler(db *gorm.DB) {
+	minutes, err := strconv.Atoi(os.Getenv("OVERDUE_CHECK_MINUTES"))
+	if err != nil || minutes <= 0 {
+		minutes = 60
+	}
+
+	go func() {
+		ticker := time.NewTicker(time.Duration(minutes) * time.Minute)
+		defer ticker.Stop()
+
+		for {
+			if err := FlagOverdueInvoices(db, time.Now()); err != nil {
+				log.Printf("overdue scheduler: %v", err)
+			}
+			<-ticker.C
+		}
+	}()
+}
+
+// FlagOverdueInvoices marks newly overdue invoices and emits an overdue event
+// for them, then a reminder event every OVERDUE_REMINDER_DAYS (default 7)
+// until they are paid. Each invoice is claimed by moving its last_reminder_at
+// on from the value that was read, so when several instances run the
+// scheduler only the one that wins the claim emits the event.
+func FlagOverdueInvoices(db *gorm.DB, now time.Time) error {
+	days, err := strconv.Atoi(os.Getenv("OVERDUE_REMINDER_DAYS"))
+	if err != nil || days <= 0 {
+		days = 7
+	}
+	interval := time.Duration(days) * 24 * time.Hour
+
+	var invoices []models.Invoice
+
+	err = db.Scopes(OverdueInvoices(now)).
+		Where("last_reminder_at IS NULL OR last_reminder_at <= ?", now.Add(-interval)).
+		Find(&invoices).Error
+	if err != nil {
+		return err
+	}
+
+	for _, invoice := range invoices {
+		event := Event{
+			Type:         EventInvoiceReminder,
+			Reference_id: invoice.Invoice_id,
+			Occurred_at:  now,
+			Data: map[string]interface{}{
+				"invoice_number": invoice.Invoice_number,
+				"due_date":       invoice.Payment_due_date,
+				"days_overdue":   int(now.Sub(invoice.Payment_due_date).Hours() / 24),
+				"reminder":       invoice.Reminders_sent + 1,
+				"receipt_email":  invoice.Receipt_email,
+			},
+		}
+
+		updates := map[string]interface{}{
+			"last_reminder_at": now,
+			"reminders_sent":   invoice.Reminders_sent + 1,
+		}
+
+		if invoice.Overdue_at == nil {
+			event.Type = EventInvoiceOverdue
+			updates["overdue_at"] = now
+		}
+
+		result := db.Model(&invoice).Where("last_reminder_at <=> ?", invoice.Last_reminder_at).Updates(updates)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected != 1 {
+			continue
+		}
+
+		if err := Events.Emit(event); err != nil {
+			log.Printf("overdue scheduler: emit %s for %s: %v", event.Type, invoice.Invoice_id, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,153 @@
+package helpers
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/Hdeee1/go-restaurant-management/models"
+)
+
+// ErrPaymentDeclined is returned when the provider refuses a payment.
+var ErrPaymentDeclined = errors.New("payment declined")
+
+type PaymentRequest struct {
+	Amount     models.Money
+	Reference  string
+	Card_token string
+}
+
+// PaymentResult is the provider's answer. Pending results are settled later by a webhook.
+type PaymentResult struct {
+	Transaction_id string
+	Pending        bool
+}
+
+// WebhookEvent is an asynchronous notification from the provider. Event_id
+// is unique per event and stays the same when the provider retries it.
+// Refund events carry the provider's Refund_id.
+type WebhookEvent struct {
+	Event_id       string       `json:"event_id"`
+	Type           string       `json:"type"`
+	Transaction_id string       `json:"transaction_id"`
+	Refund_id      string       `json:"refund_id"`
+	Amount         models.Money `json:"amount"`
+	Reason         string       `json:"reason"`
+}
+
+const (
+	WebhookPaymentCaptured = "payment.captured"
+	WebhookPaymentFailed   = "payment.failed"
+	WebhookPaymentRefunded = "payment.refunded"
+)
+
+// PaymentProvider charges cards through an external gateway.
+type PaymentProvider interface {
+	Name() string
+	Authorize(req PaymentRequest) (PaymentResult, error)
+	Capture(transactionID string, amount models.Money) (PaymentResult, error)
+	Void(transactionID string) (PaymentResult, error)
+	// Refund returns the provider's refund ID as the result's
+	// Transaction_id. Reference identifies the refund on our side.
+	Refund(transactionID string, amount models.Money, reference string) (PaymentResult, error)
+	ParseWebhook(payload []byte, signature string) (WebhookEvent, error)
+}
+
+// Payments is the provider card payments are routed through.
+var Payments PaymentProvider = MockProvider{}
+
+// NewPaymentProviderFromEnv returns the provider named by PAYMENT_PROVIDER.
+// Only the offline mock provider is built in.
+func NewPaymentProviderFromEnv() (PaymentProvider, error) {
+	switch name := os.Getenv("PAYMENT_PROVIDER"); name {
+	case "", "mock":
+		return MockProvider{Secret: os.Getenv("PAYMENT_WEBHOOK_SECRET")}, nil
+	default:
+		return nil, fmt.Errorf("unknown payment provider %q", name)
+	}
+}
+
+// MockProvider is a deterministic, offline gateway for development and demos.
+// The card token decides the outcome: "tok_decline" is declined, "tok_pending"
+// stays pending until a webhook settles it, anything else is approved.
+// Transaction IDs are derived from the request so replays give the same ID.
+type MockProvider struct {
+	Secret string
+}
+
+func (MockProvider) Name() string {
+	return "mock"
+}
+
+func (MockProvider) transactionID(parts ...string) string {
+	sum := sha256.New()
+	for _, part := range parts {
+		sum.Write([]byte(part))
+		sum.Write([]byte{0})
+	}
+	return "mock_" + hex.EncodeToString(sum.Sum(nil))[:24]
+}
+
+func (p MockProvider) Authorize(req PaymentRequest) (PaymentResult, error) {
+	if !req.Amount.IsPositive() {
+		return PaymentResult{}, errors.New("amount must be positive")
+	}
+
+	switch req.Card_token {
+	case "tok_decline":
+		return PaymentResult{}, ErrPaymentDeclined
+	case "tok_pending":
+		return PaymentResult{Transaction_id: p.transactionID("auth", req.Reference, req.Amount.String(), req.Amount.Currency), Pending: true}, nil
+	}
+
+	return PaymentResult{Transaction_id: p.transactionID("auth", req.Reference, req.Amount.String(), req.Amount.Currency)}, nil
+}
+
+func (p MockProvider) Capture(transactionID string, amount models.Money) (PaymentResult, error) {
+	return PaymentResult{Transaction_id: transactionID}, nil
+}
+
+func (p MockProvider) Void(transactionID string) (PaymentResult, error) {
+	return PaymentResult{Transaction_id: transactionID}, nil
+}
+
+func (p MockProvider) Refund(transactionID string, amount models.Money, reference string) (PaymentResult, error) {
+	if !amount.IsPositive() {
+		return PaymentResult{}, errors.New("amount must be positive")
+	}
+	return PaymentResult{Transaction_id: p.transactionID("refund", transactionID, reference, amount.String(), amount.Currency)}, nil
+}
+
+func (p MockProvider) mac(payload []byte) []byte {
+	mac := hmac.New(sha256.New, []byte(p.Secret))
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
+
+// Sign returns the hex HMAC-SHA256 of a webhook payload, as sent in X-Signature.
+func (p MockProvider) Sign(payload []byte) string {
+	return hex.EncodeToString(p.mac(payload))
+}
+
+func (p MockProvider) ParseWebhook(payload []byte, signature string) (WebhookEvent, error) {
+	var event WebhookEvent
+
+	if p.Secret == "" {
+		return event, errors.New("webhook secret not configured")
+	}
+
+	expected, err := hex.DecodeString(signature)
+	if err != nil || !hmac.Equal(expected, p.mac(payload)) {
+		return event, errors.New("invalid signature")
+	}
+
+	if err := json.Unmarshal(payload, &event); err != nil {
+		return event, err
+	}
+
+	return event, nil
+}
@@ -0,0 +1,124 @@
+package helpers
+
+import (
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/Hdeee1/go-restaurant-management/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// StartPriceScheduler applies scheduled price changes in the background every
+// PRICE_CHECK_MINUTES (default 1).
+func StartPriceScheduler(db *gorm.DB) {
+	minutes, err := strconv.Atoi(os.Getenv("PRICE_CHECK_MINUTES"))
+	if err != nil || minutes <= 0 {
+		minutes = 1
+	}
+
+	go func() {
+		ticker := time.NewTicker(time.Duration(minutes) * time.Minute)
+		defer ticker.Stop()
+
+		for {
+			if err := ApplyScheduledPrices(db, time.Now()); err != nil {
+				log.Printf("price scheduler: %v", err)
+			}
+			<-ticker.C
+		}
+	}()
+}
+
+// ApplyScheduledPrices applies every scheduled price change that has come into
+// effect, oldest first.
+func ApplyScheduledPrices(db *gorm.DB, now time.Time) error {
+	var due []models.PriceChange
+
+	err := db.Where("status = ? AND effective_at <= ?", models.PriceChangeScheduled, now).
+		Order("effective_at, id").Find(&due).Error
+	if err != nil {
+		return err
+	}
+
+	for _, change := range due {
+		err := db.Transaction(func(tx *gorm.DB) error {
+			// Re-read under lock so a change cancelled meanwhile, or applied
+			// by another instance, is skipped.
+			var locked models.PriceChange
+			err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+				Where("price_change_id = ? AND status = ?", change.Price_change_id, models.PriceChangeScheduled).
+				First(&locked).Error
+			if err == gorm.ErrRecordNotFound {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+
+			return applyPriceChange(tx, locked, now)
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func applyPriceChange(tx *gorm.DB, change models.PriceChange, now time.Time) error {
+	var food models.Food
+	err := tx.Where("food_id = ?", change.Food_id).First(&food).Error
+	if err == gorm.ErrRecordNotFound {
+		return tx.Model(&change).Update("status", models.PriceChangeCancelled).Error
+	}
+	if err != nil {
+		return err
+	}
+
+	old, err := SetFoodPrice(tx, food, change.Base, change.Currency, change.New_price)
+	if err != nil {
+		return err
+	}
+
+	updates := map[string]interface{}{
+		"status":     models.PriceChangeApplied,
+		"applied_at": now,
+	}
+	if old != nil {
+		updates["old_price_minor"] = old.Minor
+		updates["old_price_currency"] = old.Currency
+	}
+
+	return tx.Model(&change).Updates(updates).Error
+}
+
+// SetFoodPrice sets a food's base price, or its price in a currency, and
+// returns the price it replaced. A nil price removes the currency price.
+func SetFoodPrice(tx *gorm.DB, food models.Food, base bool, currency string, price *models.Money) (*models.Money, error) {
+	if base {
+		err := tx.Model(&models.Food{}).Where("food_id = ?", food.Food_id).Updates(map[string]interface{}{
+			"price_minor":    price.Minor,
+			"price_currency": price.Currency,
+		}).Error
+		return food.Price, err
+	}
+
+	var existing models.FoodPrice
+	err := tx.Where("food_id = ? AND price_currency = ?", food.Food_id, currency).First(&existing).Error
+
+	switch {
+	case err == gorm.ErrRecordNotFound && price == nil:
+		return nil, nil
+	case err == gorm.ErrRecordNotFound:
+		return nil, tx.Create(&models.FoodPrice{Food_id: food.Food_id, Price: price}).Error
+	case err != nil:
+		return nil, err
+	case price == nil:
+		return existing.Price, tx.Delete(&existing).Error
+	}
+
+	return existing.Price, tx.Model(&existing).Update("price_minor", price.Minor).Error
+}
@@ -0,0 +1,135 @@
+package helpers
+
+import (
+	"log"
+	"net"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/Hdeee1/go-restaurant-management/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	printMaxAttempts = 5
+	printDialTimeout = 5 * time.Second
+	// printClaimTimeout is how long a job stays claimed; a job still
+	// PRINTING after that was dropped by a worker and is taken again.
+	printClaimTimeout = time.Minute
+)
+
+// EnqueuePrintJob stores a rendered document for the print queue to send.
+func EnqueuePrintJob(db *gorm.DB, printerID string, kind string, referenceID string, payload []byte) (models.PrintJob, error) {
+	job := models.PrintJob{
+		Print_job_id:    uuid.New().String(),
+		Printer_id:      printerID,
+		Kind:            kind,
+		Reference_id:    referenceID,
+		Payload:         payload,
+		Status:          models.PrintQueued,
+		Next_attempt_at: time.Now(),
+	}
+
+	return job, db.Create(&job).Error
+}
+
+// SendToPrinter writes a raw ESC/POS stream to a network printer (port 9100 style).
+func SendToPrinter(address string, payload []byte) error {
+	conn, err := net.DialTimeout("tcp", address, printDialTimeout)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	conn.SetWriteDeadline(time.Now().Add(printDialTimeout))
+	_, err = conn.Write(payload)
+	return err
+}
+
+// StartPrintQueue sends queued print jobs in the background, retrying failed
+// jobs with exponential backoff until printMaxAttempts is reached.
+func StartPrintQueue(db *gorm.DB) {
+	seconds, err := strconv.Atoi(os.Getenv("PRINT_QUEUE_INTERVAL_SECONDS"))
+	if err != nil || seconds <= 0 {
+		seconds = 2
+	}
+
+	go func() {
+		ticker := time.NewTicker(time.Duration(seconds) * time.Second)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			ProcessPrintQueue(db)
+		}
+	}()
+}
+
+// ProcessPrintQueue makes one pass over the jobs that are due. Each job is
+// claimed before it is sent, so two workers never print it twice. Jobs for a
+// disabled printer fail straight away and can be retried once it is back.
+func ProcessPrintQueue(db *gorm.DB) {
+	var jobs []models.PrintJob
+
+	now := time.Now()
+	err := db.Where("status IN ? AND next_attempt_at <= ?", []string{models.PrintQueued, models.PrintPrinting}, now).
+		Order("next_attempt_at").Limit(20).Find(&jobs).Error
+	if err != nil {
+		log.Printf("print queue: %v", err)
+		return
+	}
+
+	for _, job := range jobs {
+		claim := db.Model(&models.PrintJob{}).
+			Where("id = ? AND status = ? AND next_attempt_at <= ?", job.ID, job.Status, now).
+			Updates(map[string]interface{}{
+				"status":          models.PrintPrinting,
+				"next_attempt_at": time.Now().Add(printClaimTimeout),
+			})
+		if claim.Error != nil {
+			log.Printf("print job %s: %v", job.Print_job_id, claim.Error)
+			continue
+		}
+		if claim.RowsAffected != 1 {
+			continue
+		}
+
+		var printer models.Printer
+
+		err := db.Where("printer_id = ?", job.Printer_id).First(&printer).Error
+		if err == nil && printer.Enabled != nil && !*printer.Enabled {
+			db.Model(&job).Updates(map[string]interface{}{
+				"status":     models.PrintFailed,
+				"last_error": "printer is disabled",
+			})
+			continue
+		}
+		if err == nil {
+			err = SendToPrinter(*printer.Address, job.Payload)
+		}
+
+		if err == nil {
+			db.Model(&job).Updates(map[string]interface{}{
+				"status":     models.PrintPrinted,
+				"attempts":   job.Attempts + 1,
+				"last_error": nil,
+			})
+			continue
+		}
+
+		attempts := job.Attempts + 1
+		status := models.PrintQueued
+		if attempts >= printMaxAttempts {
+			status = models.PrintFailed
+		}
+
+		log.Printf("print job %s attempt %d: %v", job.Print_job_id, attempts, err)
+		db.Model(&job).Updates(map[string]interface{}{
+			"status":          status,
+			"attempts":        attempts,
+			"last_error":      err.Error(),
+			"next_attempt_at": time.Now().Add(time.Duration(1<<attempts) * time.Second),
+		})
+	}
+}
@@ -0,0 +1,74 @@
+package helpers
+
+import (
+	"bytes"
+	"io"
+	"net"
+	"testing"
+)
+
+// listenPrinter starts an in-process stand-in for a network printer, like
+// cmd/fakeprinter, and returns its address and the bytes of each connection.
+func listenPrinter(t *testing.T) (string, <-chan []byte) {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	received := make(chan []byte, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		data, _ := io.ReadAll(conn)
+		received <- data
+	}()
+
+	return listener.Addr().String(), received
+}
+
+func TestSendToPrinter(t *testing.T) {
+	ticket := (&PrintDocument{}).Title("KITCHEN").Bold("[2] Soup").Cut().ESCPOS()
+
+	tests := []struct {
+		name    string
+		payload []byte
+	}{
+		{"kitchen ticket", ticket},
+		{"single byte", []byte{0x1b}},
+		{"empty", []byte{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			address, received := listenPrinter(t)
+
+			if err := SendToPrinter(address, tt.payload); err != nil {
+				t.Fatalf("SendToPrinter: %v", err)
+			}
+
+			if got := <-received; !bytes.Equal(got, tt.payload) {
+				t.Errorf("printer received %q, want %q", got, tt.payload)
+			}
+		})
+	}
+}
+
+func TestSendToPrinterUnreachable(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	address := listener.Addr().String()
+	listener.Close()
+
+	if err := SendToPrinter(address, []byte("hello")); err == nil {
+		t.Error("SendToPrinter to a closed port succeeded")
+	}
+}
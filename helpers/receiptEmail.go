@@ -0,0 +1,92 @@
+package helpers
+
+import (
+	"bytes"
+	htmltemplate "html/template"
+	texttemplate "text/template"
+
+	"github.com/Hdeee1/go-restaurant-management/models"
+)
+
+var receiptFuncs = map[string]interface{}{
+	"money": money,
+	"neg":   func(amount models.Money) models.Money { return amount.Neg() },
+}
+
+var receiptText = texttemplate.Must(texttemplate.New("receipt").Funcs(receiptFuncs).Parse(
+	`Thank you for dining at {{.Restaurant_name}}!
+
+Invoice {{.Number}} - {{.Issued_at.Format "2006-01-02 15:04"}}
+{{range .Lines}}
+  {{.Name}} ({{.Quantity}}){{"\t"}}{{money .Price}}{{end}}
+
+Subtotal{{"\t"}}{{money .Subtotal}}{{range .Discounts}}
+{{.Label}}{{"\t"}}{{money (neg .Amount)}}{{end}}{{range .Taxes}}
+{{.Label}}{{"\t"}}{{money .Amount}}{{end}}{{range .Charges}}
+{{.Label}}{{"\t"}}{{money .Amount}}{{end}}
+Total{{"\t"}}{{money .Total}}{{range .Included_taxes}}
+Incl. {{.Label}}{{"\t"}}{{money .Amount}}{{end}}{{range .Payments}}
+{{.Label}}{{"\t"}}{{money (neg .Amount)}}{{end}}
+Balance due{{"\t"}}{{money .Balance}}{{if .Tip.IsPositive}}
+Tip{{"\t"}}{{money .Tip}}{{end}}
+{{if .Footer}}
+{{.Footer}}{{end}}
+`))
+
+var receiptHTML = htmltemplate.Must(htmltemplate.New("receipt").Funcs(receiptFuncs).Parse(`<!DOCTYPE html>
+<html>
+<body style="font-family: Helvetica, Arial, sans-serif; color: #222; max-width: 560px; margin: 0 auto;">
+  <h2 style="margin-bottom: 0;">{{.Restaurant_name}}</h2>
+  {{if .Restaurant_address}}<div style="color: #666;">{{.Restaurant_address}}</div>{{end}}
+  <p>Thank you for dining with us! Here is your receipt.</p>
+  <p style="color: #666;">Invoice {{.Number}}<br>{{.Issued_at.Format "2006-01-02 15:04"}}</p>
+  <table style="width: 100%; border-collapse: collapse;">
+    {{range .Lines}}
+    <tr><td style="padding: 4px 0;">{{.Name}} ({{.Quantity}})</td><td style="text-align: right;">{{money .Price}}</td></tr>
+    {{end}}
+    <tr><td style="border-top: 1px solid #ccc; padding-top: 6px;">Subtotal</td><td style="border-top: 1px solid #ccc; text-align: right;">{{money .Subtotal}}</td></tr>
+    {{range .Discounts}}<tr><td>{{.Label}}</td><td style="text-align: right;">{{money (neg .Amount)}}</td></tr>{{end}}
+    {{range .Taxes}}<tr><td>{{.Label}}</td><td style="text-align: right;">{{money .Amount}}</td></tr>{{end}}
+    {{range .Charges}}<tr><td>{{.Label}}</td><td style="text-align: right;">{{money .Amount}}</td></tr>{{end}}
+    <tr><td><strong>Total</strong></td><td style="text-align: right;"><strong>{{money .Total}}</strong></td></tr>
+    {{range .Included_taxes}}<tr><td style="color: #666;">Incl. {{.Label}}</td><td style="color: #666; text-align: right;">{{money .Amount}}</td></tr>{{end}}
+    {{range .Payments}}<tr><td>{{.Label}}</td><td style="text-align: right;">{{money (neg .Amount)}}</td></tr>{{end}}
+    <tr><td><strong>Balance due</strong></td><td style="text-align: right;"><strong>{{money .Balance}}</strong></td></tr>
+    {{if .Tip.IsPositive}}<tr><td>Tip</td><td style="text-align: right;">{{money .Tip}}</td></tr>{{end}}
+  </table>
+  {{if .Footer}}<p style="color: #666; font-size: 12px;">{{.Footer}}</p>{{end}}
+</body>
+</html>
+`))
+
+// ReceiptEmail builds the receipt email for an invoice, with the invoice PDF attached.
+func ReceiptEmail(to string, doc InvoiceDocument) (MailMessage, error) {
+	msg := MailMessage{
+		To:      to,
+		Subject: "Your receipt from " + doc.Restaurant_name,
+	}
+
+	var text bytes.Buffer
+	if err := receiptText.Execute(&text, doc); err != nil {
+		return msg, err
+	}
+	msg.Text = text.String()
+
+	var html bytes.Buffer
+	if err := receiptHTML.Execute(&html, doc); err != nil {
+		return msg, err
+	}
+	msg.HTML = html.String()
+
+	pdf, err := doc.PDF()
+	if err != nil {
+		return msg, err
+	}
+	msg.Attachments = append(msg.Attachments, MailAttachment{
+		Filename:    "invoice-" + doc.Number() + ".pdf",
+		ContentType: "application/pdf",
+		Data:        pdf,
+	})
+
+	return msg, nil
+}
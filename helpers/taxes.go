@@ -0,0 +1,62 @@
+package helpers
+
+import "github.com/Hdeee1/go-restaurant-management/models"
+
+// TaxRule is a tax rate applying to a line.
+type TaxRule struct {
+	Id        string
+	Label     string
+	Rate      float64
+	Inclusive bool
+}
+
+// TaxableLine is a priced line with the rules that apply to it.
+type TaxableLine struct {
+	Price models.Money
+	Rules []TaxRule
+}
+
+// ComputeTaxes itemises the taxes on a set of lines per rule. Inclusive taxes
+// are carved out of the price; exclusive taxes are charged on the price net
+// of inclusive taxes. Amounts are summed exactly per rule and rounded once.
+func ComputeTaxes(lines []TaxableLine, currency string) (included []AmountLine, added []AmountLine) {
+	type total struct {
+		rule  TaxRule
+		minor float64
+	}
+
+	var order []string
+	totals := map[string]*total{}
+
+	for _, line := range lines {
+		inclusiveRate := 0.0
+		for _, rule := range line.Rules {
+			if rule.Inclusive {
+				inclusiveRate += rule.Rate
+			}
+		}
+
+		net := float64(line.Price.Minor) / (1 + inclusiveRate)
+		for _, rule := range line.Rules {
+			t, ok := totals[rule.Id]
+			if !ok {
+				t = &total{rule: rule}
+				totals[rule.Id] = t
+				order = append(order, rule.Id)
+			}
+			t.minor += net * rule.Rate
+		}
+	}
+
+	for _, id := range order {
+		t := totals[id]
+		line := AmountLine{Label: t.rule.Label, Amount: models.Money{Minor: models.RoundMinor(t.minor), Currency: currency}}
+		if t.rule.Inclusive {
+			included = append(included, line)
+		} else {
+			added = append(added, line)
+		}
+	}
+
+	return included, added
+}
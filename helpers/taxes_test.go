@@ -0,0 +1,81 @@
+package helpers
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/Hdeee1/go-restaurant-management/models"
+)
+
+func TestComputeTaxes(t *testing.T) {
+	usd := func(minor int64) models.Money { return models.Money{Minor: minor, Currency: "USD"} }
+	vat := TaxRule{Id: "vat", Label: "VAT 10%", Rate: 0.10, Inclusive: true}
+	city := TaxRule{Id: "city", Label: "City 5%", Rate: 0.05, Inclusive: true}
+	service := TaxRule{Id: "service", Label: "Service 5%", Rate: 0.05}
+	sales := TaxRule{Id: "sales", Label: "Sales 10%", Rate: 0.10}
+
+	tests := []struct {
+		name         string
+		lines        []TaxableLine
+		wantIncluded []AmountLine
+		wantAdded    []AmountLine
+	}{
+		{
+			name: "no lines",
+		},
+		{
+			name:  "untaxed line",
+			lines: []TaxableLine{{Price: usd(1000)}},
+		},
+		{
+			name:      "exclusive tax is added on the price",
+			lines:     []TaxableLine{{Price: usd(1000), Rules: []TaxRule{sales}}},
+			wantAdded: []AmountLine{{"Sales 10%", usd(100)}},
+		},
+		{
+			name:         "inclusive tax is carved out of the price",
+			lines:        []TaxableLine{{Price: usd(1100), Rules: []TaxRule{vat}}},
+			wantIncluded: []AmountLine{{"VAT 10%", usd(100)}},
+		},
+		{
+			name:         "exclusive tax is charged net of inclusive tax",
+			lines:        []TaxableLine{{Price: usd(1100), Rules: []TaxRule{vat, service}}},
+			wantIncluded: []AmountLine{{"VAT 10%", usd(100)}},
+			wantAdded:    []AmountLine{{"Service 5%", usd(50)}},
+		},
+		{
+			name:         "inclusive taxes share the net price",
+			lines:        []TaxableLine{{Price: usd(1150), Rules: []TaxRule{vat, city}}},
+			wantIncluded: []AmountLine{{"VAT 10%", usd(100)}, {"City 5%", usd(50)}},
+		},
+		{
+			name: "each rule is rounded once over all lines",
+			lines: []TaxableLine{
+				{Price: usd(5), Rules: []TaxRule{sales}},
+				{Price: usd(5), Rules: []TaxRule{sales}},
+				{Price: usd(5), Rules: []TaxRule{sales}},
+			},
+			wantAdded: []AmountLine{{"Sales 10%", usd(2)}},
+		},
+		{
+			name: "rules keep the order they first appear in",
+			lines: []TaxableLine{
+				{Price: usd(1000), Rules: []TaxRule{service}},
+				{Price: usd(2000), Rules: []TaxRule{sales, service}},
+			},
+			wantAdded: []AmountLine{{"Service 5%", usd(150)}, {"Sales 10%", usd(200)}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			included, added := ComputeTaxes(tt.lines, "USD")
+			if !reflect.DeepEqual(included, tt.wantIncluded) {
+				t.Errorf("included = %v, want %v", included, tt.wantIncluded)
+			}
+			if !reflect.DeepEqual(added, tt.wantAdded) {
+				t.Errorf("added = %v, want %v", added, tt.wantAdded)
+			}
+		})
+	}
+}
@@ -0,0 +1,187 @@
+package helpers
+
+import (
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Hdeee1/go-restaurant-management/models"
+)
+
+const (
+	TipSplitHours = "HOURS"
+	TipSplitEqual = "EQUAL"
+)
+
+// AutoGratuity returns the party size from which gratuity is added automatically
+// and the rate to add. Automatic gratuity is off (party size 0) unless
+// AUTO_GRATUITY_PARTY_SIZE is set; AUTO_GRATUITY_PERCENT defaults to 18.
+func AutoGratuity() (int, float64) {
+	partySize, err := strconv.Atoi(os.Getenv("AUTO_GRATUITY_PARTY_SIZE"))
+	if err != nil || partySize < 0 {
+		partySize = 0
+	}
+
+	percent, err := strconv.ParseFloat(os.Getenv("AUTO_GRATUITY_PERCENT"), 64)
+	if err != nil || percent < 0 {
+		percent = 18
+	}
+
+	return partySize, percent / 100
+}
+
+// TipPoolRules decide how a pool is split: by hours worked or equally per
+// person, scaled by a weight per role (1 for roles not listed).
+type TipPoolRules struct {
+	Split        string             `json:"split"`
+	Role_weights map[string]float64 `json:"role_weights"`
+}
+
+// TipPoolRulesFromEnv reads TIP_SPLIT (HOURS or EQUAL) and TIP_ROLE_WEIGHTS,
+// a list like "waiter=1,bartender=0.8,busser=0.5".
+func TipPoolRulesFromEnv() TipPoolRules {
+	rules := TipPoolRules{Split: strings.ToUpper(os.Getenv("TIP_SPLIT")), Role_weights: map[string]float64{}}
+	if rules.Split != TipSplitEqual {
+		rules.Split = TipSplitHours
+	}
+
+	for _, pair := range strings.Split(os.Getenv("TIP_ROLE_WEIGHTS"), ",") {
+		role, value, ok := strings.Cut(pair, "=")
+		if !ok {
+			continue
+		}
+		weight, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil || weight < 0 {
+			continue
+		}
+		rules.Role_weights[strings.TrimSpace(role)] = weight
+	}
+
+	return rules
+}
+
+func (r TipPoolRules) weight(role string) float64 {
+	if weight, ok := r.Role_weights[role]; ok {
+		return weight
+	}
+	return 1
+}
+
+// TipShift is one staff member's shift.
+type TipShift struct {
+	User_id string
+	Role    string
+	Start   time.Time
+	End     time.Time
+}
+
+// Tip is a tip taken at a point in time.
+type Tip struct {
+	Amount models.Money
+	At     time.Time
+}
+
+type TipShare struct {
+	User_id string       `json:"user_id"`
+	Role    string       `json:"role"`
+	Hours   float64      `json:"hours"`
+	Weight  float64      `json:"weight"`
+	Amount  models.Money `json:"amount"`
+}
+
+// TipPool holds the tips taken while a group of overlapping shifts was on the floor.
+type TipPool struct {
+	Start  time.Time    `json:"start"`
+	End    time.Time    `json:"end"`
+	Tips   models.Money `json:"tips"`
+	Shares []TipShare   `json:"shares"`
+}
+
+type TipReport struct {
+	Rules      TipPoolRules `json:"rules"`
+	Pools      []TipPool    `json:"pools"`
+	Total      models.Money `json:"total"`
+	Unassigned models.Money `json:"unassigned"`
+}
+
+// PoolTips groups overlapping shifts into pools, puts every tip into the pool
+// that was on the floor when it was taken and splits each pool by the rules.
+// Tips taken while nobody was on shift are reported as unassigned. Tips must
+// already be converted to the default currency.
+func PoolTips(shifts []TipShift, tips []Tip, rules TipPoolRules) TipReport {
+	currency := models.DefaultCurrency()
+	report := TipReport{Rules: rules, Pools: []TipPool{}, Total: models.Zero(currency), Unassigned: models.Zero(currency)}
+
+	sort.Slice(shifts, func(i, j int) bool { return shifts[i].Start.Before(shifts[j].Start) })
+
+	var members [][]TipShift
+	for _, shift := range shifts {
+		last := len(report.Pools) - 1
+		if last >= 0 && shift.Start.Before(report.Pools[last].End) {
+			if shift.End.After(report.Pools[last].End) {
+				report.Pools[last].End = shift.End
+			}
+			members[last] = append(members[last], shift)
+			continue
+		}
+		report.Pools = append(report.Pools, TipPool{Start: shift.Start, End: shift.End, Tips: models.Zero(currency)})
+		members = append(members, []TipShift{shift})
+	}
+
+	for _, tip := range tips {
+		report.Total = report.Total.Add(tip.Amount)
+		assigned := false
+		for i := range report.Pools {
+			if !tip.At.Before(report.Pools[i].Start) && tip.At.Before(report.Pools[i].End) {
+				report.Pools[i].Tips = report.Pools[i].Tips.Add(tip.Amount)
+				assigned = true
+				break
+			}
+		}
+		if !assigned {
+			report.Unassigned = report.Unassigned.Add(tip.Amount)
+		}
+	}
+
+	for i := range report.Pools {
+		report.Pools[i].Shares = splitPool(report.Pools[i].Tips, members[i], rules)
+	}
+
+	return report
+}
+
+// splitPool shares out a pool among the staff on its shifts. A person working
+// several shifts in the pool gets one share covering all their hours.
+func splitPool(amount models.Money, shifts []TipShift, rules TipPoolRules) []TipShare {
+	var shares []TipShare
+	byUser := map[string]int{}
+
+	for _, shift := range shifts {
+		i, ok := byUser[shift.User_id]
+		if !ok {
+			i = len(shares)
+			byUser[shift.User_id] = i
+			shares = append(shares, TipShare{User_id: shift.User_id, Role: shift.Role})
+		}
+		shares[i].Hours += shift.End.Sub(shift.Start).Hours()
+	}
+
+	weights := make([]float64, len(shares))
+	for i := range shares {
+		shares[i].Weight = rules.weight(shares[i].Role)
+		if rules.Split == TipSplitHours {
+			shares[i].Weight *= shares[i].Hours
+		}
+		weights[i] = shares[i].Weight
+	}
+
+	for i, part := range amount.Allocate(weights) {
+		shares[i].Amount = part
+		shares[i].Hours = math.Round(shares[i].Hours*100) / 100
+	}
+
+	return shares
+}
@@ -0,0 +1,115 @@
+package helpers
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/Hdeee1/go-restaurant-management/models"
+)
+
+func TestPoolTips(t *testing.T) {
+	t.Setenv("CURRENCY", "USD")
+
+	start := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	at := func(hours float64) time.Time { return start.Add(time.Duration(hours * float64(time.Hour))) }
+	shift := func(user, role string, from, to float64) TipShift {
+		return TipShift{User_id: user, Role: role, Start: at(from), End: at(to)}
+	}
+	tip := func(minor int64, hours float64) Tip {
+		return Tip{Amount: models.Money{Minor: minor, Currency: "USD"}, At: at(hours)}
+	}
+	byHours := TipPoolRules{Split: TipSplitHours}
+
+	tests := []struct {
+		name           string
+		shifts         []TipShift
+		tips           []Tip
+		rules          TipPoolRules
+		want           []map[string]int64
+		wantUnassigned int64
+		wantTotal      int64
+	}{
+		{
+			name:      "split by hours",
+			shifts:    []TipShift{shift("alice", "waiter", 0, 4), shift("bob", "waiter", 2, 4)},
+			tips:      []Tip{tip(600, 1)},
+			rules:     byHours,
+			want:      []map[string]int64{{"alice": 400, "bob": 200}},
+			wantTotal: 600,
+		},
+		{
+			name:      "split equally",
+			shifts:    []TipShift{shift("alice", "waiter", 0, 4), shift("bob", "waiter", 2, 4)},
+			tips:      []Tip{tip(600, 1)},
+			rules:     TipPoolRules{Split: TipSplitEqual},
+			want:      []map[string]int64{{"alice": 300, "bob": 300}},
+			wantTotal: 600,
+		},
+		{
+			name:      "role weights scale the hours",
+			shifts:    []TipShift{shift("alice", "waiter", 0, 4), shift("bob", "busser", 0, 4)},
+			tips:      []Tip{tip(600, 1)},
+			rules:     TipPoolRules{Split: TipSplitHours, Role_weights: map[string]float64{"busser": 0.5}},
+			want:      []map[string]int64{{"alice": 400, "bob": 200}},
+			wantTotal: 600,
+		},
+		{
+			name:      "shifts that do not overlap pool separately",
+			shifts:    []TipShift{shift("bob", "waiter", 3, 5), shift("alice", "waiter", 0, 2)},
+			tips:      []Tip{tip(100, 1), tip(200, 4)},
+			rules:     byHours,
+			want:      []map[string]int64{{"alice": 100}, {"bob": 200}},
+			wantTotal: 300,
+		},
+		{
+			name:           "tips between pools are unassigned",
+			shifts:         []TipShift{shift("alice", "waiter", 0, 2), shift("bob", "waiter", 3, 5)},
+			tips:           []Tip{tip(100, 1), tip(50, 2.5), tip(25, 2)},
+			rules:          byHours,
+			want:           []map[string]int64{{"alice": 100}, {"bob": 0}},
+			wantUnassigned: 75,
+			wantTotal:      175,
+		},
+		{
+			name:      "one share for several shifts in a pool",
+			shifts:    []TipShift{shift("alice", "waiter", 0, 2), shift("bob", "waiter", 1, 4), shift("alice", "waiter", 3, 4)},
+			tips:      []Tip{tip(600, 1)},
+			rules:     byHours,
+			want:      []map[string]int64{{"alice": 300, "bob": 300}},
+			wantTotal: 600,
+		},
+		{
+			name:           "nobody on shift",
+			tips:           []Tip{tip(100, 1)},
+			rules:          byHours,
+			want:           []map[string]int64{},
+			wantUnassigned: 100,
+			wantTotal:      100,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report := PoolTips(tt.shifts, tt.tips, tt.rules)
+
+			got := []map[string]int64{}
+			for _, pool := range report.Pools {
+				shares := map[string]int64{}
+				for _, share := range pool.Shares {
+					shares[share.User_id] = share.Amount.Minor
+				}
+				got = append(got, shares)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("shares = %v, want %v", got, tt.want)
+			}
+			if report.Unassigned.Minor != tt.wantUnassigned {
+				t.Errorf("unassigned = %d, want %d", report.Unassigned.Minor, tt.wantUnassigned)
+			}
+			if report.Total.Minor != tt.wantTotal {
+				t.Errorf("total = %d, want %d", report.Total.Minor, tt.wantTotal)
+			}
+		})
+	}
+}
@@ -0,0 +1,39 @@
+package helpers
+
+import (
+	"os"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// DefaultTurnTime is used when there is no turn time history yet.
+func DefaultTurnTime() time.Duration {
+	minutes, err := strconv.Atoi(os.Getenv("DEFAULT_TURN_MINUTES"))
+	if err != nil || minutes <= 0 {
+		minutes = 60
+	}
+
+	return time.Duration(minutes) * time.Minute
+}
+
+// EstimateWait estimates how long a party waits for one of the tables that fit it.
+// remaining holds the time left until each fitting table turns over, ahead is the
+// number of waiting parties in front competing for the same tables.
+func EstimateWait(remaining []time.Duration, ahead int, turnTime time.Duration) time.Duration {
+	if len(remaining) == 0 {
+		return 0
+	}
+
+	sorted := make([]time.Duration, len(remaining))
+	copy(sorted, remaining)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	rounds := ahead / len(sorted)
+	wait := sorted[ahead%len(sorted)] + time.Duration(rounds)*turnTime
+	if wait < 0 {
+		return 0
+	}
+
+	return wait
+}
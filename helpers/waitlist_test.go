@@ -0,0 +1,61 @@
+package helpers
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestEstimateWait(t *testing.T) {
+	turn := 60 * time.Minute
+
+	tests := []struct {
+		name      string
+		remaining []time.Duration
+		ahead     int
+		want      time.Duration
+	}{
+		{"no fitting tables", nil, 0, 0},
+		{"free table", []time.Duration{0}, 0, 0},
+		{"first table to turn over", []time.Duration{30 * time.Minute, 10 * time.Minute}, 0, 10 * time.Minute},
+		{"party ahead takes the first table", []time.Duration{30 * time.Minute, 10 * time.Minute}, 1, 30 * time.Minute},
+		{"every table taken once", []time.Duration{30 * time.Minute, 10 * time.Minute}, 2, 70 * time.Minute},
+		{"several rounds at one table", []time.Duration{20 * time.Minute}, 3, 200 * time.Minute},
+		{"table past its turn time", []time.Duration{-5 * time.Minute}, 0, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			remaining := append([]time.Duration(nil), tt.remaining...)
+
+			if got := EstimateWait(tt.remaining, tt.ahead, turn); got != tt.want {
+				t.Errorf("EstimateWait() = %v, want %v", got, tt.want)
+			}
+			if !reflect.DeepEqual(tt.remaining, remaining) {
+				t.Errorf("EstimateWait reordered its input to %v", tt.remaining)
+			}
+		})
+	}
+}
+
+func TestDefaultTurnTime(t *testing.T) {
+	tests := []struct {
+		value string
+		want  time.Duration
+	}{
+		{"", 60 * time.Minute},
+		{"45", 45 * time.Minute},
+		{"0", 60 * time.Minute},
+		{"-10", 60 * time.Minute},
+		{"soon", 60 * time.Minute},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			t.Setenv("DEFAULT_TURN_MINUTES", tt.value)
+			if got := DefaultTurnTime(); got != tt.want {
+				t.Errorf("DefaultTurnTime() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
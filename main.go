@@ -2,9 +2,11 @@ package main
 
 import (
 	"fmt"
+	"log"
 	"os"
 
 	"github.com/Hdeee1/go-restaurant-management/database"
+	"github.com/Hdeee1/go-restaurant-management/helpers"
 	"github.com/Hdeee1/go-restaurant-management/middleware"
 	"github.com/Hdeee1/go-restaurant-management/routes"
 	"github.com/gin-gonic/gin"
@@ -36,21 +38,57 @@ import (
 //	@tag.name			Menus
 //	@tag.description	Menu Management
 
+//	@tag.name			Categories
+//	@tag.description	Menu Categories and Display Order
+
 //	@tag.name			Foods
 //	@tag.description	Manage Food Item
 
 //	@tag.name			Tables
 //	@tag.description	Restaurant Tables
 
+//	@tag.name			Sections
+//	@tag.description	Floor Plan Sections and Waiter Assignments
+
+//	@tag.name			Waitlist
+//	@tag.description	Walk-in Waitlist
+
 //	@tag.name			Orders
 //	@tag.description	Order Management
 
 //	@tag.name			OrderItems
 //	@tag.description	Order Item Details
 
+//	@tag.name			Kitchen
+//	@tag.description	Kitchen Feed of Fired Items
+
 //	@tag.name			Invoices
 //	@tag.description	Payment and Invoice
 
+//	@tag.name			Guests
+//	@tag.description	QR-code Table Ordering for Guests
+
+//	@tag.name			Printing
+//	@tag.description	Kitchen Tickets, Receipts and Printers
+
+//	@tag.name			Payments
+//	@tag.description	Card and Cash Payments
+
+//	@tag.name			Exchange Rates
+//	@tag.description	Currency Exchange Rates
+
+//	@tag.name			Taxes
+//	@tag.description	Tax Categories and Rates
+
+//	@tag.name			Business Days
+//	@tag.description	End-of-Day Close-Out and Z Reports
+
+//	@tag.name			Cash Drawers
+//	@tag.description	Cash Drawer Sessions and Reconciliation
+
+//	@tag.name			Reports
+//	@tag.description	Reporting and Analytics
+
 //	@tag.name			Notes
 //	@tag.description	Additional Notes for Orders
 
@@ -133,6 +171,23 @@ func contains(slice []string, item string) bool {
 
 func main() {
 	database.InitDB()
+	helpers.Mail = helpers.NewMailerFromEnv()
+
+	provider, err := helpers.NewPaymentProviderFromEnv()
+	if err != nil {
+		log.Fatal(err)
+	}
+	helpers.Payments = provider
+
+	images, err := helpers.NewImageStoreFromEnv()
+	if err != nil {
+		log.Fatal(err)
+	}
+	helpers.Images = images
+
+	helpers.StartPrintQueue(database.DB)
+	helpers.StartOverdueScheduler(database.DB)
+	helpers.StartPriceScheduler(database.DB)
 	port := os.Getenv("PORT")
 
 	if port == "" {
@@ -146,14 +201,28 @@ func main() {
 	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
 	routes.UserRouter(router)
+	routes.GuestRoutes(router)
+	routes.PaymentWebhookRoutes(router)
+	routes.ImageRoutes(router)
 	router.Use(middleware.Authentication())
 
 	routes.FoodRoutes(router)
 	routes.MenuRoutes(router)
+	routes.CategoryRoutes(router)
 	routes.TableRoutes(router)
+	routes.SectionRoutes(router)
+	routes.WaitlistRoutes(router)
 	routes.OrderRoutes(router)
 	routes.OrderItemRoutes(router)
+	routes.KitchenRoutes(router)
 	routes.InvoiceRoutes(router)
+	routes.PrinterRoutes(router)
+	routes.PaymentRoutes(router)
+	routes.ReportRoutes(router)
+	routes.ExchangeRateRoutes(router)
+	routes.TaxRoutes(router)
+	routes.BusinessDayRoutes(router)
+	routes.CashDrawerRoutes(router)
 
 	// Print all registered routes
 	printRoutes(router)
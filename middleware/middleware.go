@@ -4,7 +4,9 @@ import (
 	"net/http"
 	"strings"
 
+	"github.com/Hdeee1/go-restaurant-management/database"
 	"github.com/Hdeee1/go-restaurant-management/helpers"
+	"github.com/Hdeee1/go-restaurant-management/models"
 	"github.com/gin-gonic/gin"
 )
 
@@ -51,3 +53,33 @@ func CheckRole(allowedRoles ...string) gin.HandlerFunc {
 		ctx.Next()
 	}
 }
+
+// GuestAuthentication admits guests holding a table's guest token, passed as
+// a Bearer token, while the party it was issued to is still at the table.
+func GuestAuthentication() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		tokenString := strings.TrimPrefix(ctx.GetHeader("Authorization"), "Bearer ")
+
+		if tokenString == "" {
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "No guest token"})
+			return
+		}
+
+		claims, err := helpers.ValidateGuestToken(tokenString)
+		if err != nil {
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
+			return
+		}
+
+		var table models.Table
+		if err := database.DB.Where("table_id = ?", claims.Table_id).First(&table).Error; err != nil || table.Guest_token_version != claims.Version {
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "guest token has been revoked"})
+			return
+		}
+
+		ctx.Set("role", "guest")
+		ctx.Set("table_id", claims.Table_id)
+
+		ctx.Next()
+	}
+}
@@ -0,0 +1,27 @@
+package models
+
+import "gorm.io/gorm"
+
+// BundleSlot is one pick a bundle is made of, such as the main or the drink
+// of a set lunch. Bundle_id is the bundle food's Food_id.
+type BundleSlot struct {
+	gorm.Model
+	Slot_id    string         `json:"slot_id"`
+	Bundle_id  string         `json:"bundle_id" gorm:"index"`
+	Name       *string        `json:"name" validate:"required,min=1,max=50"`
+	Sort_order int            `json:"sort_order"`
+	Options    []BundleOption `gorm:"foreignKey:Slot_id;references:Slot_id" json:"options" validate:"required,min=1,dive"`
+}
+
+// BundleOption is a food that can fill a slot.
+type BundleOption struct {
+	gorm.Model
+	Slot_id string  `json:"slot_id" gorm:"index"`
+	Food_id *string `json:"food_id" validate:"required"`
+}
+
+// BundleChoice picks the food for one slot when a bundle is ordered.
+type BundleChoice struct {
+	Slot_id string `json:"slot_id" validate:"required"`
+	Food_id string `json:"food_id" validate:"required"`
+}
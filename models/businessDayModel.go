@@ -0,0 +1,53 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// BusinessDay is a closed trading day. Once a day is closed no more orders
+// can be placed on it and its Z report is kept as it was at closing.
+type BusinessDay struct {
+	gorm.Model
+	Business_day_id string    `json:"business_day_id"`
+	Date            string    `json:"date" gorm:"uniqueIndex;size:10"`
+	Closed_at       time.Time `json:"closed_at"`
+	Closed_by       string    `json:"closed_by"`
+	Report          []byte    `json:"-"`
+}
+
+type AmountTotal struct {
+	Label  string `json:"label"`
+	Amount Money  `json:"amount"`
+}
+
+type MethodTotal struct {
+	Method string `json:"method"`
+	Count  int    `json:"count"`
+	Amount Money  `json:"amount"`
+}
+
+// ZReport is the end-of-day summary of a business day. Amounts are in the
+// default currency; payments are also totalled per method and currency taken.
+type ZReport struct {
+	Date            string        `json:"date"`
+	Currency        string        `json:"currency"`
+	Generated_at    time.Time     `json:"generated_at"`
+	Orders          int           `json:"orders"`
+	Guest_orders    int           `json:"guest_orders"`
+	Rejected_orders int           `json:"rejected_orders"`
+	Invoices        int           `json:"invoices"`
+	Gross_sales     Money         `json:"gross_sales"`
+	Discounts       Money         `json:"discounts"`
+	Net_sales       Money         `json:"net_sales"`
+	Charges         Money         `json:"charges"`
+	Taxes           []AmountTotal `json:"taxes"`
+	Tax_collected   Money         `json:"tax_collected"`
+	Payments        []MethodTotal `json:"payments"`
+	Refund_count    int           `json:"refund_count"`
+	Refunds         Money         `json:"refunds"`
+	Void_count      int           `json:"void_count"`
+	Voids           Money         `json:"voids"`
+	Tips            Money         `json:"tips"`
+}
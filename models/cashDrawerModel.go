@@ -0,0 +1,53 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	DrawerOpen   = "OPEN"
+	DrawerClosed = "CLOSED"
+)
+
+const (
+	CashSale    = "SALE"
+	CashRefund  = "REFUND"
+	CashPaidOut = "PAID_OUT"
+)
+
+// CashDrawerSession is a cashier's shift on a cash drawer, from counting in the
+// opening float to counting out at close. Open_drawer and Open_user repeat the
+// drawer and cashier only while the session is open, so their unique indexes
+// allow one open session per drawer and per cashier.
+type CashDrawerSession struct {
+	gorm.Model
+	Session_id    string     `json:"session_id"`
+	Drawer        string     `json:"drawer" gorm:"index;size:40"`
+	Status        string     `json:"status"`
+	Opened_by     string     `json:"opened_by" gorm:"index"`
+	Opened_at     time.Time  `json:"opened_at"`
+	Opening_float Money      `json:"opening_float" gorm:"embedded;embeddedPrefix:float_"`
+	Closed_by     *string    `json:"closed_by"`
+	Closed_at     *time.Time `json:"closed_at"`
+	Expected_cash Money      `json:"expected_cash" gorm:"embedded;embeddedPrefix:expected_"`
+	Counted_cash  Money      `json:"counted_cash" gorm:"embedded;embeddedPrefix:counted_"`
+	Discrepancy   Money      `json:"discrepancy" gorm:"embedded;embeddedPrefix:discrepancy_"`
+	Note          *string    `json:"note"`
+	Open_drawer   *string    `json:"-" gorm:"uniqueIndex;size:40"`
+	Open_user     *string    `json:"-" gorm:"uniqueIndex;size:36"`
+}
+
+// CashMovement is cash going into or out of a drawer during a session.
+// Amounts are signed: sales are positive, refunds and paid-outs negative.
+type CashMovement struct {
+	gorm.Model
+	Movement_id string  `json:"movement_id"`
+	Session_id  string  `json:"session_id" gorm:"index"`
+	Type        string  `json:"type"`
+	Amount      Money   `json:"amount" gorm:"embedded;embeddedPrefix:amount_"`
+	Payment_id  *string `json:"payment_id"`
+	Reason      *string `json:"reason"`
+	Recorded_by string  `json:"recorded_by"`
+}
@@ -0,0 +1,15 @@
+package models
+
+import "gorm.io/gorm"
+
+// Category groups a menu's foods for display. Categories nest through
+// Parent_id and are shown in Sort_order, then by name.
+type Category struct {
+	gorm.Model
+	Category_id string  `json:"category_id"`
+	Menu_id     *string `json:"menu_id" gorm:"index" validate:"required"`
+	Parent_id   *string `json:"parent_id" gorm:"index"`
+	Name        *string `json:"name" validate:"required,min=1,max=100"`
+	Description *string `json:"description" validate:"omitempty,max=500"`
+	Sort_order  int     `json:"sort_order"`
+}
@@ -0,0 +1,18 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ExchangeRate says how many units of Quote_currency one unit of
+// Base_currency buys from Effective_from until the next rate for the pair.
+type ExchangeRate struct {
+	gorm.Model
+	Exchange_rate_id string     `json:"exchange_rate_id"`
+	Base_currency    *string    `json:"base_currency" gorm:"size:3" validate:"required,len=3,uppercase"`
+	Quote_currency   *string    `json:"quote_currency" gorm:"size:3" validate:"required,len=3,uppercase,nefield=Base_currency"`
+	Rate             *float64   `json:"rate" gorm:"type:decimal(20,10)" validate:"required,gt=0"`
+	Effective_from   *time.Time `json:"effective_from" validate:"required"`
+}
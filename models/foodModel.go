@@ -2,11 +2,58 @@ package models
 
 import "gorm.io/gorm"
 
+const (
+	FoodItem   = "ITEM"
+	FoodBundle = "BUNDLE"
+)
+
 type Food struct {
 	gorm.Model
-	Name       *string  `json:"name" validate:"required,min=2,max=100"`
-	Price      *float64 `json:"price" validate:"required"`
-	Food_image *string  `json:"food_image" validate:"required"`
-	Food_id    string   `json:"food_id"`
-	Menu_id    *string  `json:"menu_id" validate:"required"`
+	Name            *string      `json:"name" validate:"required,min=2,max=100"`
+	Price           *Money       `json:"price" gorm:"embedded;embeddedPrefix:price_" validate:"required"`
+	Food_image      *string      `json:"food_image" validate:"omitempty,uri"`
+	Food_id         string       `json:"food_id"`
+	Menu_id         *string      `json:"menu_id" validate:"required"`
+	Food_type       string       `json:"food_type" gorm:"size:10;default:ITEM" validate:"omitempty,eq=ITEM|eq=BUNDLE"`
+	Category_id     *string      `json:"category_id" gorm:"index"`
+	Sort_order      int          `json:"sort_order"`
+	Station         *string      `json:"station"`
+	Tax_category_id *string      `json:"tax_category_id"`
+	External_code   *string      `json:"external_code" gorm:"uniqueIndex;size:64" validate:"omitempty,max=64"`
+	Prices          []FoodPrice  `gorm:"foreignKey:Food_id;references:Food_id" json:"prices,omitempty"`
+	Images          []FoodImage  `gorm:"foreignKey:Food_id;references:Food_id" json:"images,omitempty"`
+	Slots           []BundleSlot `gorm:"foreignKey:Bundle_id;references:Food_id" json:"slots,omitempty"`
+}
+
+// FoodPrice is a food's price in a currency other than its base Price.
+type FoodPrice struct {
+	gorm.Model
+	Food_id string `json:"food_id"`
+	Price   *Money `json:"price" gorm:"embedded;embeddedPrefix:price_" validate:"required"`
+}
+
+// FoodImage is one size of a food's uploaded image. The largest size is also
+// the food's Food_image.
+type FoodImage struct {
+	gorm.Model
+	Food_id      string `json:"food_id" gorm:"index"`
+	Size         string `json:"size" gorm:"size:20"`
+	Url          string `json:"url"`
+	Storage_key  string `json:"-"`
+	Content_type string `json:"content_type"`
+	Width        int    `json:"width"`
+	Height       int    `json:"height"`
+}
+
+// PriceIn returns the food's price in a currency, if it has one.
+func (f Food) PriceIn(currency string) (Money, bool) {
+	if f.Price != nil && f.Price.Currency == currency {
+		return *f.Price, true
+	}
+	for _, price := range f.Prices {
+		if price.Price != nil && price.Price.Currency == currency {
+			return *price.Price, true
+		}
+	}
+	return Money{}, false
 }
@@ -1,16 +1,83 @@
 package models
 
 import (
+	"fmt"
+	"os"
+	"strings"
 	"time"
 
 	"gorm.io/gorm"
 )
 
+const (
+	ReceiptSent   = "SENT"
+	ReceiptFailed = "FAILED"
+
+	InvoicePending = "PENDING"
+	InvoicePaid    = "PAID"
+	InvoiceOverdue = "OVERDUE"
+)
+
 type Invoice struct {
 	gorm.Model
-	Invoice_id       string    `json:"invoice_id"`
-	Order_id         string    `json:"order_id"`
-	Payment_method   *string   `json:"payment_method" validate:"eq=CARD|eq=CASH|eq="`
-	Payment_status   *string   `json:"payment_status" validate:"required,eq=PENDING|eq=PAID"`
-	Payment_due_date time.Time `json:"payment_due_date"`
+	Invoice_id       string     `json:"invoice_id"`
+	Invoice_number   *string    `json:"invoice_number" gorm:"uniqueIndex;size:40"`
+	Branch           string     `json:"branch" gorm:"size:20" validate:"omitempty,alphanum,max=20"`
+	Order_id         string     `json:"order_id"`
+	Payment_method   *string    `json:"payment_method" validate:"eq=CARD|eq=CASH|eq="`
+	Payment_status   *string    `json:"payment_status" validate:"required,eq=PENDING|eq=PAID"`
+	Payment_due_date time.Time  `json:"payment_due_date"`
+	Currency         string     `json:"currency" gorm:"size:3" validate:"omitempty,len=3,uppercase"`
+	Gratuity_rate    *float64   `json:"gratuity_rate" validate:"omitempty,gte=0,lte=1"`
+	Receipt_email    *string    `json:"receipt_email"`
+	Receipt_status   *string    `json:"receipt_status"`
+	Receipt_sent_at  *time.Time `json:"receipt_sent_at"`
+	Receipt_error    *string    `json:"receipt_error"`
+	Overdue_at       *time.Time `json:"overdue_at"`
+	Reminders_sent   int        `json:"reminders_sent"`
+	Last_reminder_at *time.Time `json:"last_reminder_at"`
+
+	State string `gorm:"-" json:"state"`
+}
+
+// AfterFind derives the invoice state: PENDING invoices past their due date are OVERDUE.
+func (i *Invoice) AfterFind(tx *gorm.DB) error {
+	i.State = i.StateAt(time.Now())
+	return nil
+}
+
+func (i Invoice) StateAt(now time.Time) string {
+	if i.Payment_status == nil {
+		return ""
+	}
+	if *i.Payment_status == InvoicePending && !i.Payment_due_date.IsZero() && i.Payment_due_date.Before(now) {
+		return InvoiceOverdue
+	}
+	return *i.Payment_status
+}
+
+// InvoiceSequence holds the last invoice number used by a branch in a year.
+type InvoiceSequence struct {
+	gorm.Model
+	Branch      string `json:"branch" gorm:"uniqueIndex:idx_invoice_sequence;size:20"`
+	Year        int    `json:"year" gorm:"uniqueIndex:idx_invoice_sequence"`
+	Last_number int64  `json:"last_number"`
+}
+
+// DefaultBranch is the branch invoices are numbered for, taken from BRANCH_CODE.
+func DefaultBranch() string {
+	return strings.ToUpper(os.Getenv("BRANCH_CODE"))
+}
+
+// InvoiceNumber formats a sequence number, e.g. INV-2026-000123, or
+// INV-NORTH-2026-000123 for a named branch. The prefix comes from INVOICE_PREFIX.
+func InvoiceNumber(branch string, year int, number int64) string {
+	prefix := os.Getenv("INVOICE_PREFIX")
+	if prefix == "" {
+		prefix = "INV"
+	}
+	if branch != "" {
+		prefix += "-" + branch
+	}
+	return fmt.Sprintf("%s-%d-%06d", prefix, year, number)
 }
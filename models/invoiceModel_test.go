@@ -0,0 +1,29 @@
+package models
+
+import "testing"
+
+func TestInvoiceNumber(t *testing.T) {
+	tests := []struct {
+		name   string
+		prefix string
+		branch string
+		year   int
+		number int64
+		want   string
+	}{
+		{"default prefix", "", "", 2026, 123, "INV-2026-000123"},
+		{"branch", "", "NORTH", 2026, 123, "INV-NORTH-2026-000123"},
+		{"custom prefix", "BILL", "", 2026, 1, "BILL-2026-000001"},
+		{"custom prefix and branch", "BILL", "NORTH", 2027, 42, "BILL-NORTH-2027-000042"},
+		{"number wider than the padding", "", "", 2026, 1234567, "INV-2026-1234567"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("INVOICE_PREFIX", tt.prefix)
+			if got := InvoiceNumber(tt.branch, tt.year, tt.number); got != tt.want {
+				t.Errorf("InvoiceNumber(%q, %d, %d) = %q, want %q", tt.branch, tt.year, tt.number, got, tt.want)
+			}
+		})
+	}
+}
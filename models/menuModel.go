@@ -8,9 +8,10 @@ import (
 
 type Menu struct {
 	gorm.Model
-	Name       string     `json:"name" validate:"required"`
-	Category   string     `json:"category" validate:"required"`
-	Start_date *time.Time `json:"start_date"`
-	End_date   *time.Time `json:"end_date"`
-	Menu_id    string     `json:"menu_id" validate:"required"`
+	Name          string     `json:"name" validate:"required"`
+	Category      string     `json:"category" validate:"required"`
+	Start_date    *time.Time `json:"start_date"`
+	End_date      *time.Time `json:"end_date"`
+	Menu_id       string     `json:"menu_id" validate:"required"`
+	External_code *string    `json:"external_code" gorm:"uniqueIndex;size:64" validate:"omitempty,max=64"`
 }
@@ -0,0 +1,317 @@
+package models
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Money is an exact amount in the minor units of its currency (cents for USD).
+// It is stored as two columns, <prefix>minor and <prefix>currency, and
+// marshalled to JSON as {"amount": "12.50", "currency": "USD"} so no
+// precision is lost on the way to and from clients.
+type Money struct {
+	Minor    int64  `gorm:"column:minor"`
+	Currency string `gorm:"column:currency;size:3"`
+}
+
+// currencyExponents lists the currencies that do not have two decimals.
+var currencyExponents = map[string]int{
+	"BHD": 3,
+	"CLP": 0,
+	"IQD": 3,
+	"ISK": 0,
+	"JOD": 3,
+	"JPY": 0,
+	"KRW": 0,
+	"KWD": 3,
+	"OMR": 3,
+	"TND": 3,
+	"UGX": 0,
+	"VND": 0,
+}
+
+// DefaultCurrency is the restaurant's currency, taken from CURRENCY.
+func DefaultCurrency() string {
+	currency := strings.ToUpper(os.Getenv("CURRENCY"))
+	if currency == "" {
+		currency = "USD"
+	}
+	return currency
+}
+
+// CurrencyExponent is the number of decimals of a currency.
+func CurrencyExponent(currency string) int {
+	if exponent, ok := currencyExponents[strings.ToUpper(currency)]; ok {
+		return exponent
+	}
+	return 2
+}
+
+func Zero(currency string) Money {
+	return Money{Currency: currency}
+}
+
+// NewMoney converts a decimal amount to money, rounding its shortest decimal
+// form (1.005, not 1.00499...) to the currency's minor unit.
+func NewMoney(amount float64, currency string) Money {
+	money, err := ParseMoney(strconv.FormatFloat(amount, 'f', -1, 64), currency)
+	if err != nil {
+		return Money{Minor: RoundMinor(amount * math.Pow10(CurrencyExponent(currency))), Currency: currency}
+	}
+	return money
+}
+
+// RoundMinor is the rounding rule for every money calculation: to the nearest
+// minor unit, halves away from zero.
+func RoundMinor(minor float64) int64 {
+	return int64(math.Round(minor))
+}
+
+// ParseMoney reads a decimal amount such as "12.5" or "-3.005" exactly.
+// Digits beyond the currency's minor unit are rounded with RoundMinor's rule.
+func ParseMoney(amount string, currency string) (Money, error) {
+	if currency == "" {
+		currency = DefaultCurrency()
+	}
+	currency = strings.ToUpper(currency)
+	exponent := CurrencyExponent(currency)
+
+	value := strings.TrimSpace(amount)
+	negative := strings.HasPrefix(value, "-")
+	value = strings.TrimPrefix(strings.TrimPrefix(value, "-"), "+")
+
+	whole, fraction, _ := strings.Cut(value, ".")
+	if whole == "" && fraction == "" {
+		return Money{}, fmt.Errorf("invalid amount %q", amount)
+	}
+	if whole == "" {
+		whole = "0"
+	}
+
+	roundUp := false
+	if len(fraction) > exponent {
+		roundUp = fraction[exponent] >= '5'
+		for _, digit := range fraction[exponent:] {
+			if digit < '0' || digit > '9' {
+				return Money{}, fmt.Errorf("invalid amount %q", amount)
+			}
+		}
+		fraction = fraction[:exponent]
+	}
+	fraction += strings.Repeat("0", exponent-len(fraction))
+
+	minor, err := strconv.ParseInt(whole+fraction, 10, 64)
+	if err != nil || strings.ContainsAny(whole+fraction, "+-") {
+		return Money{}, fmt.Errorf("invalid amount %q", amount)
+	}
+	if roundUp {
+		minor++
+	}
+	if negative {
+		minor = -minor
+	}
+
+	return Money{Minor: minor, Currency: currency}, nil
+}
+
+func (m Money) IsZero() bool     { return m.Minor == 0 }
+func (m Money) IsPositive() bool { return m.Minor > 0 }
+func (m Money) IsNegative() bool { return m.Minor < 0 }
+
+func (m Money) Neg() Money {
+	return Money{Minor: -m.Minor, Currency: m.Currency}
+}
+
+// Add sums two amounts of the same currency. A zero amount without a currency
+// takes the other's, so totals can start from Money{}.
+//
+// Add, Sub and Cmp panic when the currencies differ: amounts are converted,
+// or checked with SameCurrency, before they are combined.
+func (m Money) Add(other Money) Money {
+	return Money{Minor: m.Minor + other.Minor, Currency: m.sameCurrency(other)}
+}
+
+func (m Money) Sub(other Money) Money {
+	return Money{Minor: m.Minor - other.Minor, Currency: m.sameCurrency(other)}
+}
+
+func (m Money) Cmp(other Money) int {
+	m.sameCurrency(other)
+	switch {
+	case m.Minor < other.Minor:
+		return -1
+	case m.Minor > other.Minor:
+		return 1
+	}
+	return 0
+}
+
+// Mul multiplies by a whole quantity.
+func (m Money) Mul(quantity int64) Money {
+	return Money{Minor: m.Minor * quantity, Currency: m.Currency}
+}
+
+// MulRate applies a rate such as 0.18 for 18%, rounding with RoundMinor.
+func (m Money) MulRate(rate float64) Money {
+	return Money{Minor: RoundMinor(float64(m.Minor) * rate), Currency: m.Currency}
+}
+
+// Allocate splits the amount in proportion to weights without losing minor
+// units: every part is rounded down and the remainder goes one unit at a time
+// to the parts with the largest weights first.
+func (m Money) Allocate(weights []float64) []Money {
+	if m.Minor < 0 {
+		parts := m.Neg().Allocate(weights)
+		for i := range parts {
+			parts[i] = parts[i].Neg()
+		}
+		return parts
+	}
+
+	parts := make([]Money, len(weights))
+	total := 0.0
+	for i, weight := range weights {
+		parts[i].Currency = m.Currency
+		if weight > 0 {
+			total += weight
+		}
+	}
+	if total == 0 {
+		return parts
+	}
+
+	given := int64(0)
+	for i, weight := range weights {
+		if weight <= 0 {
+			continue
+		}
+		parts[i].Minor = int64(math.Floor(float64(m.Minor) * weight / total))
+		given += parts[i].Minor
+	}
+
+	order := make([]int, 0, len(weights))
+	for i, weight := range weights {
+		if weight > 0 {
+			order = append(order, i)
+		}
+	}
+	for i := 1; i < len(order); i++ {
+		for j := i; j > 0 && weights[order[j]] > weights[order[j-1]]; j-- {
+			order[j], order[j-1] = order[j-1], order[j]
+		}
+	}
+	for i := 0; given < m.Minor; i = (i + 1) % len(order) {
+		parts[order[i]].Minor++
+		given++
+	}
+
+	return parts
+}
+
+// Convert exchanges the amount into another currency at rate units of that
+// currency per unit of this one, rounding with RoundMinor.
+func (m Money) Convert(rate float64, currency string) Money {
+	scale := math.Pow10(CurrencyExponent(currency) - CurrencyExponent(m.Currency))
+	return Money{Minor: RoundMinor(float64(m.Minor) * rate * scale), Currency: currency}
+}
+
+// Float is the decimal value, for display and charting only.
+func (m Money) Float() float64 {
+	return float64(m.Minor) / math.Pow10(CurrencyExponent(m.Currency))
+}
+
+// String formats the amount with the currency's decimals, e.g. "12.50".
+func (m Money) String() string {
+	exponent := CurrencyExponent(m.Currency)
+	minor := m.Minor
+	sign := ""
+	if minor < 0 {
+		sign = "-"
+		minor = -minor
+	}
+
+	if exponent == 0 {
+		return sign + strconv.FormatInt(minor, 10)
+	}
+
+	scale := int64(math.Pow10(exponent))
+	return fmt.Sprintf("%s%d.%0*d", sign, minor/scale, exponent, minor%scale)
+}
+
+// SameCurrency reports whether the two amounts can be combined.
+func (m Money) SameCurrency(other Money) bool {
+	return m.Currency == "" || other.Currency == "" || m.Currency == other.Currency
+}
+
+func (m Money) sameCurrency(other Money) string {
+	switch {
+	case other.Currency == "" || other.Currency == m.Currency:
+		return m.Currency
+	case m.Currency == "":
+		return other.Currency
+	}
+	panic("money: cannot combine " + m.Currency + " and " + other.Currency)
+}
+
+type moneyJSON struct {
+	Amount   string `json:"amount"`
+	Currency string `json:"currency"`
+}
+
+func (m Money) MarshalJSON() ([]byte, error) {
+	return json.Marshal(moneyJSON{Amount: m.String(), Currency: m.Currency})
+}
+
+// UnmarshalJSON accepts {"amount": "12.50", "currency": "USD"} as well as a bare
+// number or string, which is read in the default currency.
+func (m *Money) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if bytes.Equal(data, []byte("null")) {
+		return nil
+	}
+
+	var value moneyJSON
+	switch {
+	case bytes.HasPrefix(data, []byte("{")):
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.UseNumber()
+		var raw struct {
+			Amount   interface{} `json:"amount"`
+			Currency string      `json:"currency"`
+		}
+		if err := decoder.Decode(&raw); err != nil {
+			return err
+		}
+		switch amount := raw.Amount.(type) {
+		case json.Number:
+			value.Amount = amount.String()
+		case string:
+			value.Amount = amount
+		default:
+			return errors.New("money amount must be a number or string")
+		}
+		value.Currency = raw.Currency
+	case bytes.HasPrefix(data, []byte(`"`)):
+		var amount string
+		if err := json.Unmarshal(data, &amount); err != nil {
+			return err
+		}
+		value.Amount = amount
+	default:
+		value.Amount = string(data)
+	}
+
+	parsed, err := ParseMoney(value.Amount, value.Currency)
+	if err != nil {
+		return err
+	}
+
+	*m = parsed
+	return nil
+}
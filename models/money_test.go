@@ -0,0 +1,178 @@
+package models
+
+import "testing"
+
+func usd(minor int64) Money { return Money{Minor: minor, Currency: "USD"} }
+
+func TestMoneyArithmetic(t *testing.T) {
+	tests := []struct {
+		name string
+		got  Money
+		want Money
+	}{
+		{"add", usd(100).Add(usd(50)), usd(150)},
+		{"add to zero value", Money{}.Add(usd(50)), usd(50)},
+		{"add zero value", usd(100).Add(Money{}), usd(100)},
+		{"sub below zero", usd(100).Sub(usd(150)), usd(-50)},
+		{"neg", usd(25).Neg(), usd(-25)},
+		{"mul", usd(250).Mul(3), usd(750)},
+		{"mul rate", usd(1000).MulRate(0.18), usd(180)},
+		{"mul rate rounds half away from zero", usd(5).MulRate(0.5), usd(3)},
+		{"mul rate rounds negative half away from zero", usd(-5).MulRate(0.5), usd(-3)},
+		{"convert to two decimals", usd(1000).Convert(0.9, "EUR"), Money{Minor: 900, Currency: "EUR"}},
+		{"convert to no decimals", usd(1000).Convert(150, "JPY"), Money{Minor: 1500, Currency: "JPY"}},
+		{"convert to three decimals", usd(1000).Convert(0.31, "KWD"), Money{Minor: 3100, Currency: "KWD"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.got != tt.want {
+				t.Errorf("got %+v, want %+v", tt.got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMoneyCmp(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b Money
+		want int
+	}{
+		{"less", usd(1), usd(2), -1},
+		{"equal", usd(2), usd(2), 0},
+		{"greater", usd(3), usd(2), 1},
+		{"against zero value", usd(-1), Money{}, -1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.a.Cmp(tt.b); got != tt.want {
+				t.Errorf("Cmp() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMoneyCurrencyMismatch(t *testing.T) {
+	eur := Money{Minor: 100, Currency: "EUR"}
+
+	tests := []struct {
+		name string
+		op   func()
+	}{
+		{"add", func() { usd(100).Add(eur) }},
+		{"sub", func() { usd(100).Sub(eur) }},
+		{"cmp", func() { usd(100).Cmp(eur) }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if usd(100).SameCurrency(eur) {
+				t.Fatal("SameCurrency(USD, EUR) = true")
+			}
+			defer func() {
+				if recover() == nil {
+					t.Error("combining USD and EUR did not panic")
+				}
+			}()
+			tt.op()
+		})
+	}
+}
+
+func TestMoneyAllocate(t *testing.T) {
+	tests := []struct {
+		name    string
+		amount  Money
+		weights []float64
+		want    []int64
+	}{
+		{"even split gives the remainder to the first", usd(100), []float64{1, 1, 1}, []int64{34, 33, 33}},
+		{"remainder goes to the largest weight", usd(100), []float64{1, 2}, []int64{33, 67}},
+		{"zero weight gets nothing", usd(5), []float64{0, 1}, []int64{0, 5}},
+		{"negative weight gets nothing", usd(10), []float64{-1, 1}, []int64{0, 10}},
+		{"no positive weights", usd(10), []float64{0, 0}, []int64{0, 0}},
+		{"negative amount", usd(-100), []float64{1, 1, 1}, []int64{-34, -33, -33}},
+		{"more parts than minor units", usd(2), []float64{1, 1, 1}, []int64{1, 1, 0}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parts := tt.amount.Allocate(tt.weights)
+			if len(parts) != len(tt.want) {
+				t.Fatalf("got %d parts, want %d", len(parts), len(tt.want))
+			}
+			for i, part := range parts {
+				if part.Minor != tt.want[i] || part.Currency != tt.amount.Currency {
+					t.Errorf("part %d = %+v, want %d %s", i, part, tt.want[i], tt.amount.Currency)
+				}
+			}
+		})
+	}
+}
+
+func TestParseMoney(t *testing.T) {
+	tests := []struct {
+		amount   string
+		currency string
+		want     Money
+		wantErr  bool
+	}{
+		{amount: "12.5", currency: "USD", want: usd(1250)},
+		{amount: "12", currency: "usd", want: usd(1200)},
+		{amount: ".5", currency: "USD", want: usd(50)},
+		{amount: "+1", currency: "USD", want: usd(100)},
+		{amount: " 2.25 ", currency: "USD", want: usd(225)},
+		{amount: "1.005", currency: "USD", want: usd(101)},
+		{amount: "1.004", currency: "USD", want: usd(100)},
+		{amount: "-3.005", currency: "USD", want: usd(-301)},
+		{amount: "1.5", currency: "JPY", want: Money{Minor: 2, Currency: "JPY"}},
+		{amount: "1.2345", currency: "KWD", want: Money{Minor: 1235, Currency: "KWD"}},
+		{amount: "", currency: "USD", wantErr: true},
+		{amount: ".", currency: "USD", wantErr: true},
+		{amount: "abc", currency: "USD", wantErr: true},
+		{amount: "1.2x", currency: "USD", wantErr: true},
+		{amount: "1.234x", currency: "USD", wantErr: true},
+		{amount: "--1", currency: "USD", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.amount+" "+tt.currency, func(t *testing.T) {
+			got, err := ParseMoney(tt.amount, tt.currency)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("ParseMoney(%q) = %+v, want an error", tt.amount, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseMoney(%q): %v", tt.amount, err)
+			}
+			if got != tt.want {
+				t.Errorf("ParseMoney(%q) = %+v, want %+v", tt.amount, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMoneyString(t *testing.T) {
+	tests := []struct {
+		money Money
+		want  string
+	}{
+		{usd(1250), "12.50"},
+		{usd(-5), "-0.05"},
+		{usd(0), "0.00"},
+		{Money{Minor: 1000, Currency: "JPY"}, "1000"},
+		{Money{Minor: 1234, Currency: "KWD"}, "1.234"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.want+" "+tt.money.Currency, func(t *testing.T) {
+			if got := tt.money.String(); got != tt.want {
+				t.Errorf("String() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,12 +1,46 @@
 package models
 
-import "gorm.io/gorm"
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	CourseStarter = "STARTER"
+	CourseMain    = "MAIN"
+	CourseDessert = "DESSERT"
+
+	ItemHeld  = "HELD"
+	ItemFired = "FIRED"
+)
+
+// Courses lists the courses in the order they are served.
+var Courses = []string{CourseStarter, CourseMain, CourseDessert}
 
 type OrderItem struct {
 	gorm.Model
-	Quantity      *string  `json:"quantity" validate:"required,eq=S|eq=M|eq=L"`
-	Unit_price    *float64 `json:"unit_price" validate:"required"`
-	Food_id       *string  `json:"food_id" validate:"required"`
-	Order_item_id string   `json:"order_item_id"`
-	Order_id      string   `json:"order_id" validate:"required"`
+	Quantity      *string    `json:"quantity" validate:"required,eq=S|eq=M|eq=L"`
+	Unit_price    *Money     `json:"unit_price" gorm:"embedded;embeddedPrefix:unit_price_" validate:"required"`
+	Food_id       *string    `json:"food_id" validate:"required"`
+	Order_item_id string     `json:"order_item_id"`
+	Order_id      string     `json:"order_id" validate:"required"`
+	Course        *string    `json:"course" validate:"omitempty,eq=STARTER|eq=MAIN|eq=DESSERT"`
+	Fire_status   string     `json:"fire_status" gorm:"default:FIRED"`
+	Fired_at      *time.Time `json:"fired_at"`
+
+	// Bundles are stored as one item per component. Items of the same
+	// bundle share a Bundle_item_id, and their prices are the bundle's
+	// price split across them.
+	Bundle_id      *string        `json:"bundle_id" gorm:"index"`
+	Bundle_item_id *string        `json:"bundle_item_id" gorm:"index"`
+	Slot_id        *string        `json:"slot_id"`
+	Components     []BundleChoice `json:"components,omitempty" gorm:"-" validate:"dive"`
+}
+
+// FiresImmediately reports whether the item goes to the kitchen as soon as the
+// order is placed. Starters and items without a course do; later courses are
+// held until the waiter fires them.
+func (i OrderItem) FiresImmediately() bool {
+	return i.Course == nil || *i.Course == CourseStarter
 }
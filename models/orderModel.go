@@ -6,10 +6,57 @@ import (
 	"gorm.io/gorm"
 )
 
+const (
+	OrderOpen            = "OPEN"
+	OrderPendingApproval = "PENDING_APPROVAL"
+	OrderRejected        = "REJECTED"
+
+	OrderSourceStaff = "STAFF"
+	OrderSourceGuest = "GUEST"
+
+	KitchenHeld           = "HELD"
+	KitchenPartiallyFired = "PARTIALLY_FIRED"
+	KitchenFired          = "FIRED"
+)
+
 type Order struct {
 	gorm.Model
-	Order_date time.Time   `json:"order_date" validate:"required"`
-	Order_id   string      `json:"order_id"`
-	Table_id   *string     `json:"table_id"`
-	OrderItems []OrderItem `gorm:"foreignKey:Order_id;references:Order_id" json:"order_items"`
+	Order_date   time.Time   `json:"order_date" validate:"required"`
+	Order_id     string      `json:"order_id"`
+	Table_id     *string     `json:"table_id"`
+	Status       string      `json:"status" gorm:"default:OPEN"`
+	Source       string      `json:"source" gorm:"default:STAFF"`
+	Service_type string      `json:"service_type" gorm:"default:DINE_IN" validate:"omitempty,eq=DINE_IN|eq=TAKEOUT"`
+	OrderItems   []OrderItem `gorm:"foreignKey:Order_id;references:Order_id" json:"order_items"`
+
+	Kitchen_status string `gorm:"-" json:"kitchen_status,omitempty"`
+}
+
+// AfterFind derives the kitchen status from the order's items when they were loaded.
+func (o *Order) AfterFind(tx *gorm.DB) error {
+	o.Kitchen_status = KitchenStatus(o.OrderItems)
+	return nil
+}
+
+// KitchenStatus summarises whether the items have been held or fired to the kitchen.
+func KitchenStatus(items []OrderItem) string {
+	if len(items) == 0 {
+		return ""
+	}
+
+	fired := 0
+	for _, item := range items {
+		if item.Fire_status == ItemFired {
+			fired++
+		}
+	}
+
+	switch fired {
+	case 0:
+		return KitchenHeld
+	case len(items):
+		return KitchenFired
+	default:
+		return KitchenPartiallyFired
+	}
 }
@@ -0,0 +1,79 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	PaymentPending    = "PENDING"
+	PaymentAuthorized = "AUTHORIZED"
+	PaymentCaptured   = "CAPTURED"
+	PaymentVoided     = "VOIDED"
+	PaymentRefunded   = "REFUNDED"
+	PaymentFailed     = "FAILED"
+)
+
+type Payment struct {
+	gorm.Model
+	Payment_id              string     `json:"payment_id"`
+	Invoice_id              string     `json:"invoice_id"`
+	Amount                  Money      `json:"amount" gorm:"embedded;embeddedPrefix:amount_"`
+	Refunded_amount         Money      `json:"refunded_amount" gorm:"embedded;embeddedPrefix:refunded_"`
+	Tip_amount              Money      `json:"tip_amount" gorm:"embedded;embeddedPrefix:tip_"`
+	Settled_amount          Money      `json:"settled_amount" gorm:"embedded;embeddedPrefix:settled_"`
+	Exchange_rate           *float64   `json:"exchange_rate" gorm:"type:decimal(20,10)"`
+	Method                  string     `json:"method"`
+	Status                  string     `json:"status"`
+	Provider                *string    `json:"provider"`
+	Provider_transaction_id *string    `json:"provider_transaction_id" gorm:"index"`
+	Failure_reason          *string    `json:"failure_reason"`
+	Cash_session_id         *string    `json:"cash_session_id" gorm:"index"`
+	Voided_at               *time.Time `json:"voided_at" gorm:"index"`
+}
+
+// Settled is what the payment counts towards its invoice, in the invoice's
+// currency, after refunds. Payments in another currency are converted at the
+// rate applied when they were taken.
+func (p Payment) Settled() Money {
+	if p.Exchange_rate == nil {
+		return p.Amount.Sub(p.Refunded_amount)
+	}
+	settled := p.Settled_amount.Sub(p.Refunded_amount.Convert(*p.Exchange_rate, p.Settled_amount.Currency))
+	if settled.IsNegative() || p.Refunded_amount.Cmp(p.Amount) >= 0 {
+		return Zero(settled.Currency)
+	}
+	return settled
+}
+
+const (
+	RefundPending   = "PENDING"
+	RefundCompleted = "COMPLETED"
+	RefundFailed    = "FAILED"
+)
+
+// PaymentRefund records each refund taken from a payment. Provider_refund_id
+// is the provider's ID for a card refund, so a refund it reports again is
+// not counted twice. A card refund is PENDING while the provider is called
+// and holds its amount of the payment until it is COMPLETED or FAILED.
+type PaymentRefund struct {
+	gorm.Model
+	Refund_id          string  `json:"refund_id"`
+	Payment_id         string  `json:"payment_id" gorm:"index"`
+	Invoice_id         string  `json:"invoice_id"`
+	Method             string  `json:"method"`
+	Amount             Money   `json:"amount" gorm:"embedded;embeddedPrefix:amount_"`
+	Provider_refund_id *string `json:"provider_refund_id" gorm:"uniqueIndex;size:100"`
+	Status             string  `json:"status" gorm:"size:10;default:COMPLETED"`
+}
+
+// PaymentEvent is a provider webhook event that has been applied. Providers
+// retry webhooks, and an event already recorded here is ignored.
+type PaymentEvent struct {
+	gorm.Model
+	Provider       string `json:"provider" gorm:"uniqueIndex:idx_payment_event;size:40"`
+	Event_id       string `json:"event_id" gorm:"uniqueIndex:idx_payment_event;size:100"`
+	Type           string `json:"type"`
+	Transaction_id string `json:"transaction_id"`
+}
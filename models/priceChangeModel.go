@@ -0,0 +1,32 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	PriceChangeScheduled = "SCHEDULED"
+	PriceChangeApplied   = "APPLIED"
+	PriceChangeCancelled = "CANCELLED"
+)
+
+// PriceChange is a change to a food's base price (Base) or to its price in
+// another currency. Changes are recorded as they are made, or scheduled to
+// take effect later; Old_price is filled in when a change is applied, and a
+// nil New_price removes a currency price.
+type PriceChange struct {
+	gorm.Model
+	Price_change_id string     `json:"price_change_id"`
+	Food_id         string     `json:"food_id" gorm:"index"`
+	Base            bool       `json:"base"`
+	Currency        string     `json:"currency" gorm:"size:3"`
+	Old_price       *Money     `json:"old_price" gorm:"embedded;embeddedPrefix:old_price_"`
+	New_price       *Money     `json:"new_price" gorm:"embedded;embeddedPrefix:new_price_"`
+	Effective_at    time.Time  `json:"effective_at" gorm:"index"`
+	Status          string     `json:"status"`
+	Changed_by      string     `json:"changed_by"`
+	Reason          *string    `json:"reason"`
+	Applied_at      *time.Time `json:"applied_at"`
+}
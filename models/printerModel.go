@@ -0,0 +1,39 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	StationKitchen = "KITCHEN"
+	StationReceipt = "RECEIPT"
+
+	PrintQueued   = "QUEUED"
+	PrintPrinting = "PRINTING"
+	PrintPrinted  = "PRINTED"
+	PrintFailed   = "FAILED"
+)
+
+type Printer struct {
+	gorm.Model
+	Printer_id string  `json:"printer_id"`
+	Name       *string `json:"name" validate:"required,min=2,max=100"`
+	Station    *string `json:"station" validate:"required"`
+	Address    *string `json:"address" validate:"required,hostname_port"`
+	Enabled    *bool   `json:"enabled" gorm:"default:true"`
+}
+
+type PrintJob struct {
+	gorm.Model
+	Print_job_id    string    `json:"print_job_id"`
+	Printer_id      string    `json:"printer_id"`
+	Kind            string    `json:"kind"`
+	Reference_id    string    `json:"reference_id"`
+	Payload         []byte    `json:"-"`
+	Status          string    `json:"status" gorm:"default:QUEUED"`
+	Attempts        int       `json:"attempts"`
+	Last_error      *string   `json:"last_error"`
+	Next_attempt_at time.Time `json:"next_attempt_at"`
+}
@@ -0,0 +1,24 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type Section struct {
+	gorm.Model
+	Name        *string `json:"name" validate:"required,min=2,max=100"`
+	Description *string `json:"description"`
+	Section_id  string  `json:"section_id"`
+	Tables      []Table `gorm:"foreignKey:Section_id;references:Section_id" json:"tables"`
+}
+
+type SectionAssignment struct {
+	gorm.Model
+	Assignment_id string    `json:"assignment_id"`
+	Section_id    *string   `json:"section_id" validate:"required"`
+	User_id       *string   `json:"user_id" validate:"required"`
+	Shift_start   time.Time `json:"shift_start" validate:"required"`
+	Shift_end     time.Time `json:"shift_end" validate:"required,gtfield=Shift_start"`
+}
@@ -1,10 +1,37 @@
 package models
 
-import "gorm.io/gorm"
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	TableAvailable       = "AVAILABLE"
+	TableReserved        = "RESERVED"
+	TableSeated          = "SEATED"
+	TableOrdering        = "ORDERING"
+	TableAwaitingPayment = "AWAITING_PAYMENT"
+	TableNeedsCleaning   = "NEEDS_CLEANING"
+	TableMerged          = "MERGED"
+)
 
 type Table struct {
 	gorm.Model
-	Number_of_guest *int   `json:"number_of_guest" validate:"required"`
-	Table_number    *int   `json:"table_number" validate:"required"`
-	Table_id        string `json:"table_id"`
+	Number_of_guest *int       `json:"number_of_guest" validate:"required"`
+	Table_number    *int       `json:"table_number" validate:"required"`
+	Table_id        string     `json:"table_id"`
+	Status          string     `json:"status" gorm:"default:AVAILABLE" validate:"omitempty,eq=AVAILABLE|eq=RESERVED|eq=SEATED|eq=ORDERING|eq=AWAITING_PAYMENT|eq=NEEDS_CLEANING|eq=MERGED"`
+	Party_size      *int       `json:"party_size"`
+	Seated_at       *time.Time `json:"seated_at"`
+	Section_id      *string    `json:"section_id"`
+	Pos_x           *float64   `json:"pos_x"`
+	Pos_y           *float64   `json:"pos_y"`
+	Shape           *string    `json:"shape" validate:"omitempty,eq=ROUND|eq=SQUARE|eq=RECTANGLE"`
+	Is_combined     bool       `json:"is_combined"`
+	Merged_into     *string    `json:"merged_into"`
+
+	// Guest_token_version moves on whenever the table is released, which
+	// revokes the guest tokens handed to the party that sat there.
+	Guest_token_version int `json:"-"`
 }
@@ -0,0 +1,35 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	ServiceDineIn  = "DINE_IN"
+	ServiceTakeout = "TAKEOUT"
+)
+
+// TaxCategory groups foods taxed alike, e.g. food or alcohol.
+type TaxCategory struct {
+	gorm.Model
+	Tax_category_id string  `json:"tax_category_id"`
+	Name            *string `json:"name" validate:"required,min=2,max=100"`
+}
+
+// TaxRate is a rate charged on a tax category between two dates. Inclusive
+// rates are already part of the menu price; exclusive rates are added on top.
+// A rate with a Service_type only applies to that service and replaces the
+// category's rates without one.
+type TaxRate struct {
+	gorm.Model
+	Tax_rate_id     string     `json:"tax_rate_id"`
+	Tax_category_id *string    `json:"tax_category_id" validate:"required"`
+	Name            *string    `json:"name" validate:"required,min=2,max=100"`
+	Rate            *float64   `json:"rate" gorm:"type:decimal(10,6)" validate:"required,gte=0,lte=1"`
+	Inclusive       bool       `json:"inclusive"`
+	Service_type    *string    `json:"service_type" validate:"omitempty,eq=DINE_IN|eq=TAKEOUT"`
+	Effective_from  *time.Time `json:"effective_from" validate:"required"`
+	Effective_to    *time.Time `json:"effective_to" validate:"omitempty,gtfield=Effective_from"`
+}
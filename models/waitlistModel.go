@@ -0,0 +1,27 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	WaitlistWaiting   = "WAITING"
+	WaitlistSeated    = "SEATED"
+	WaitlistCancelled = "CANCELLED"
+	WaitlistNoShow    = "NO_SHOW"
+)
+
+type WaitlistEntry struct {
+	gorm.Model
+	Waitlist_id    string     `json:"waitlist_id"`
+	Party_name     *string    `json:"party_name" validate:"required,min=1,max=100"`
+	Party_size     *int       `json:"party_size" validate:"required,min=1"`
+	Phone          *string    `json:"phone" validate:"required"`
+	Arrival_time   time.Time  `json:"arrival_time"`
+	Status         string     `json:"status" gorm:"default:WAITING" validate:"omitempty,eq=WAITING|eq=SEATED|eq=CANCELLED|eq=NO_SHOW"`
+	Quoted_minutes int        `json:"quoted_minutes"`
+	Table_id       *string    `json:"table_id"`
+	Seated_at      *time.Time `json:"seated_at"`
+}
@@ -0,0 +1,13 @@
+package routes
+
+import (
+	"github.com/Hdeee1/go-restaurant-management/controllers"
+	"github.com/Hdeee1/go-restaurant-management/middleware"
+	"github.com/gin-gonic/gin"
+)
+
+func BusinessDayRoutes(incomingRoutes *gin.Engine) {
+	incomingRoutes.GET("/business-days", middleware.Authentication(), middleware.CheckRole("admin"), controllers.GetBusinessDays())
+	incomingRoutes.POST("/business-days/close", middleware.Authentication(), middleware.CheckRole("admin"), controllers.CloseBusinessDay())
+	incomingRoutes.GET("/business-days/:date", middleware.Authentication(), middleware.CheckRole("admin"), controllers.GetBusinessDay())
+}
@@ -0,0 +1,15 @@
+package routes
+
+import (
+	"github.com/Hdeee1/go-restaurant-management/controllers"
+	"github.com/Hdeee1/go-restaurant-management/middleware"
+	"github.com/gin-gonic/gin"
+)
+
+func CashDrawerRoutes(incomingRoutes *gin.Engine) {
+	incomingRoutes.GET("/cash-drawers/sessions", middleware.Authentication(), controllers.GetCashSessions())
+	incomingRoutes.GET("/cash-drawers/sessions/:session_id", middleware.Authentication(), controllers.GetCashSession())
+	incomingRoutes.POST("/cash-drawers/sessions", middleware.Authentication(), controllers.OpenCashSession())
+	incomingRoutes.POST("/cash-drawers/sessions/:session_id/paid-outs", middleware.Authentication(), controllers.CreatePaidOut())
+	incomingRoutes.POST("/cash-drawers/sessions/:session_id/close", middleware.Authentication(), controllers.CloseCashSession())
+}
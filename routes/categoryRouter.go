@@ -0,0 +1,14 @@
+package routes
+
+import (
+	"github.com/Hdeee1/go-restaurant-management/controllers"
+	"github.com/Hdeee1/go-restaurant-management/middleware"
+	"github.com/gin-gonic/gin"
+)
+
+func CategoryRoutes(incomingRoutes *gin.Engine) {
+	incomingRoutes.GET("/menus/:menu_id/categories", controllers.GetCategories())
+	incomingRoutes.POST("/menus/:menu_id/categories", middleware.Authentication(), middleware.CheckRole("admin"), controllers.CreateCategory())
+	incomingRoutes.PATCH("/categories/:category_id", middleware.Authentication(), middleware.CheckRole("admin"), controllers.UpdateCategory())
+	incomingRoutes.DELETE("/categories/:category_id", middleware.Authentication(), middleware.CheckRole("admin"), controllers.DeleteCategory())
+}
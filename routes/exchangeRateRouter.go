@@ -0,0 +1,12 @@
+package routes
+
+import (
+	"github.com/Hdeee1/go-restaurant-management/controllers"
+	"github.com/Hdeee1/go-restaurant-management/middleware"
+	"github.com/gin-gonic/gin"
+)
+
+func ExchangeRateRoutes(incomingRoutes *gin.Engine) {
+	incomingRoutes.GET("/exchange-rates", middleware.Authentication(), controllers.GetExchangeRates())
+	incomingRoutes.POST("/exchange-rates", middleware.Authentication(), middleware.CheckRole("admin"), controllers.CreateExchangeRate())
+}
@@ -11,4 +11,12 @@ func FoodRoutes(incomingRoutes *gin.Engine) {
 	incomingRoutes.PATCH("/foods/:food_id", middleware.Authentication(), middleware.CheckRole("admin"), controllers.UpdateFood())
 	incomingRoutes.GET("/foods", controllers.GetFoods())
 	incomingRoutes.GET("/foods/:food_id", controllers.GetFood())
+	incomingRoutes.PUT("/foods/:food_id/prices", middleware.Authentication(), middleware.CheckRole("admin"), controllers.SetFoodPrice())
+	incomingRoutes.GET("/foods/:food_id/price-changes", middleware.Authentication(), middleware.CheckRole("admin"), controllers.GetPriceChanges())
+	incomingRoutes.POST("/foods/:food_id/price-changes", middleware.Authentication(), middleware.CheckRole("admin"), controllers.SchedulePriceChange())
+	incomingRoutes.DELETE("/foods/:food_id/price-changes/:price_change_id", middleware.Authentication(), middleware.CheckRole("admin"), controllers.CancelPriceChange())
+	incomingRoutes.PUT("/foods/:food_id/slots", middleware.Authentication(), middleware.CheckRole("admin"), controllers.SetBundleSlots())
+	incomingRoutes.PUT("/foods/:food_id/image", middleware.Authentication(), middleware.CheckRole("admin"), controllers.UploadFoodImage())
+	incomingRoutes.DELETE("/foods/:food_id/image", middleware.Authentication(), middleware.CheckRole("admin"), controllers.DeleteFoodImage())
+	incomingRoutes.DELETE("/foods/:food_id/prices/:currency", middleware.Authentication(), middleware.CheckRole("admin"), controllers.DeleteFoodPrice())
 }
@@ -0,0 +1,14 @@
+package routes
+
+import (
+	"github.com/Hdeee1/go-restaurant-management/controllers"
+	"github.com/Hdeee1/go-restaurant-management/middleware"
+	"github.com/gin-gonic/gin"
+)
+
+func GuestRoutes(incomingRoutes *gin.Engine) {
+	incomingRoutes.POST("/guest/tables/:table_id/session", controllers.CreateGuestSession())
+	incomingRoutes.GET("/guest/menus", middleware.GuestAuthentication(), controllers.GetGuestMenus())
+	incomingRoutes.GET("/guest/foods", middleware.GuestAuthentication(), controllers.GetGuestFoods())
+	incomingRoutes.POST("/guest/orders", middleware.GuestAuthentication(), controllers.CreateGuestOrder())
+}
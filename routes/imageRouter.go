@@ -0,0 +1,14 @@
+package routes
+
+import (
+	"github.com/Hdeee1/go-restaurant-management/helpers"
+	"github.com/gin-gonic/gin"
+)
+
+// ImageRoutes serves uploaded images when they are stored on local disk.
+// Images in S3 are served by the bucket itself.
+func ImageRoutes(incomingRoutes *gin.Engine) {
+	if store, ok := helpers.Images.(helpers.LocalImageStore); ok {
+		incomingRoutes.Static(store.Mount_path, store.Dir)
+	}
+}
@@ -11,4 +11,10 @@ func InvoiceRoutes(incomingRoutes *gin.Engine) {
 	incomingRoutes.GET("/invoices", middleware.Authentication(), controllers.GetInvoices())
 	incomingRoutes.GET("/invoices/:invoice_id", middleware.Authentication(), controllers.GetInvoice())
 	incomingRoutes.PATCH("/invoices/:invoice_id", middleware.Authentication(), middleware.CheckRole("admin"), controllers.UpdateInvoice())
+	incomingRoutes.GET("/invoices/:invoice_id/pdf", middleware.Authentication(), controllers.GetInvoicePDF())
+	incomingRoutes.GET("/invoices/:invoice_id/payments", middleware.Authentication(), controllers.GetInvoicePayments())
+	incomingRoutes.POST("/invoices/:invoice_id/payments", middleware.Authentication(), controllers.CreatePayment())
+	incomingRoutes.POST("/invoices/:invoice_id/email", middleware.Authentication(), controllers.EmailInvoiceReceipt())
+	incomingRoutes.GET("/invoices/:invoice_id/receipt", middleware.Authentication(), controllers.GetInvoiceReceipt())
+	incomingRoutes.POST("/invoices/:invoice_id/print", middleware.Authentication(), controllers.PrintInvoiceReceipt())
 }
@@ -8,7 +8,10 @@ import (
 
 func MenuRoutes(incomingRoutes *gin.Engine) {
 	incomingRoutes.POST("/menus", middleware.Authentication(), middleware.CheckRole("admin"), controllers.CreateMenu())
+	incomingRoutes.POST("/menus/import", middleware.Authentication(), middleware.CheckRole("admin"), controllers.ImportMenus())
 	incomingRoutes.GET("/menus", controllers.GetMenus())
 	incomingRoutes.GET("/menus/:menu_id", controllers.GetMenu())
 	incomingRoutes.PATCH("/menus/:menu_id", middleware.Authentication(), middleware.CheckRole("admin"), controllers.UpdateMenu())
+	incomingRoutes.GET("/menus/:menu_id/tree", controllers.GetMenuTree())
+	incomingRoutes.PUT("/menus/:menu_id/order", middleware.Authentication(), middleware.CheckRole("admin"), controllers.SetMenuOrder())
 }
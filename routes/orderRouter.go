@@ -11,4 +11,10 @@ func OrderRoutes(incomingRoutes *gin.Engine) {
 	incomingRoutes.GET("/orders", middleware.Authentication(), controllers.GetOrders())
 	incomingRoutes.GET("/orders/:order_id", middleware.Authentication(), controllers.GetOrder())
 	incomingRoutes.PATCH("/orders/:order_id", middleware.Authentication(), middleware.CheckRole("admin"), controllers.UpdateOrder())
+	incomingRoutes.POST("/orders/:order_id/transfer", middleware.Authentication(), controllers.TransferOrder())
+	incomingRoutes.POST("/orders/:order_id/approve", middleware.Authentication(), controllers.ApproveOrder())
+	incomingRoutes.POST("/orders/:order_id/reject", middleware.Authentication(), controllers.RejectOrder())
+	incomingRoutes.POST("/orders/:order_id/fire", middleware.Authentication(), controllers.FireCourse())
+	incomingRoutes.GET("/orders/:order_id/ticket", middleware.Authentication(), controllers.GetOrderTicket())
+	incomingRoutes.POST("/orders/:order_id/print", middleware.Authentication(), controllers.PrintOrderTicket())
 }
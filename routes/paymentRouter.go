@@ -0,0 +1,18 @@
+package routes
+
+import (
+	"github.com/Hdeee1/go-restaurant-management/controllers"
+	"github.com/Hdeee1/go-restaurant-management/middleware"
+	"github.com/gin-gonic/gin"
+)
+
+func PaymentRoutes(incomingRoutes *gin.Engine) {
+	incomingRoutes.POST("/payments/:payment_id/capture", middleware.Authentication(), controllers.CapturePayment())
+	incomingRoutes.POST("/payments/:payment_id/void", middleware.Authentication(), controllers.VoidPayment())
+	incomingRoutes.POST("/payments/:payment_id/refund", middleware.Authentication(), middleware.CheckRole("admin"), controllers.RefundPayment())
+}
+
+// PaymentWebhookRoutes are called by the payment provider and authenticated by signature, not by token.
+func PaymentWebhookRoutes(incomingRoutes *gin.Engine) {
+	incomingRoutes.POST("/payments/webhook", controllers.PaymentWebhook())
+}
@@ -0,0 +1,15 @@
+package routes
+
+import (
+	"github.com/Hdeee1/go-restaurant-management/controllers"
+	"github.com/Hdeee1/go-restaurant-management/middleware"
+	"github.com/gin-gonic/gin"
+)
+
+func PrinterRoutes(incomingRoutes *gin.Engine) {
+	incomingRoutes.POST("/printers", middleware.Authentication(), middleware.CheckRole("admin"), controllers.CreatePrinter())
+	incomingRoutes.GET("/printers", middleware.Authentication(), controllers.GetPrinters())
+	incomingRoutes.PATCH("/printers/:printer_id", middleware.Authentication(), middleware.CheckRole("admin"), controllers.UpdatePrinter())
+	incomingRoutes.GET("/print-jobs", middleware.Authentication(), controllers.GetPrintJobs())
+	incomingRoutes.POST("/print-jobs/:print_job_id/retry", middleware.Authentication(), controllers.RetryPrintJob())
+}
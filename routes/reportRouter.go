@@ -0,0 +1,18 @@
+package routes
+
+import (
+	"github.com/Hdeee1/go-restaurant-management/controllers"
+	"github.com/Hdeee1/go-restaurant-management/middleware"
+	"github.com/gin-gonic/gin"
+)
+
+func ReportRoutes(incomingRoutes *gin.Engine) {
+	incomingRoutes.GET("/reports/aging", middleware.Authentication(), middleware.CheckRole("admin"), controllers.GetAgingReport())
+	incomingRoutes.GET("/reports/sales", middleware.Authentication(), middleware.CheckRole("admin"), controllers.GetSalesReport())
+	incomingRoutes.GET("/reports/average-order-value", middleware.Authentication(), middleware.CheckRole("admin"), controllers.GetAverageOrderReport())
+	incomingRoutes.GET("/reports/top-foods", middleware.Authentication(), middleware.CheckRole("admin"), controllers.GetTopFoodsReport())
+	incomingRoutes.GET("/reports/categories", middleware.Authentication(), middleware.CheckRole("admin"), controllers.GetCategoryReport())
+	incomingRoutes.GET("/reports/tables", middleware.Authentication(), middleware.CheckRole("admin"), controllers.GetTableSalesReport())
+	incomingRoutes.GET("/reports/waiters", middleware.Authentication(), middleware.CheckRole("admin"), controllers.GetWaiterSalesReport())
+	incomingRoutes.GET("/reports/tips", middleware.Authentication(), middleware.CheckRole("admin"), controllers.GetTipReport())
+}
@@ -0,0 +1,17 @@
+package routes
+
+import (
+	"github.com/Hdeee1/go-restaurant-management/controllers"
+	"github.com/Hdeee1/go-restaurant-management/middleware"
+	"github.com/gin-gonic/gin"
+)
+
+func SectionRoutes(incomingRoutes *gin.Engine) {
+	incomingRoutes.POST("/sections", middleware.Authentication(), middleware.CheckRole("admin"), controllers.CreateSection())
+	incomingRoutes.GET("/sections", middleware.Authentication(), controllers.GetSections())
+	incomingRoutes.GET("/sections/:section_id", middleware.Authentication(), controllers.GetSection())
+	incomingRoutes.PATCH("/sections/:section_id", middleware.Authentication(), middleware.CheckRole("admin"), controllers.UpdateSection())
+	incomingRoutes.POST("/sections/assignments", middleware.Authentication(), middleware.CheckRole("admin"), controllers.CreateSectionAssignment())
+	incomingRoutes.GET("/sections/assignments", middleware.Authentication(), controllers.GetSectionAssignments())
+	incomingRoutes.DELETE("/sections/assignments/:assignment_id", middleware.Authentication(), middleware.CheckRole("admin"), controllers.DeleteSectionAssignment())
+}
@@ -11,4 +11,12 @@ func TableRoutes(incomingRoutes *gin.Engine) {
 	incomingRoutes.GET("/table", middleware.Authentication(), controllers.GetTables())
 	incomingRoutes.GET("/table/:table_id", middleware.Authentication(), controllers.GetTable())
 	incomingRoutes.PATCH("/table/:table_id", middleware.Authentication(), middleware.CheckRole("admin"), controllers.UpdateTable())
+	incomingRoutes.GET("/tables/board", middleware.Authentication(), controllers.GetTableBoard())
+	incomingRoutes.POST("/tables/merge", middleware.Authentication(), controllers.MergeTables())
+	incomingRoutes.POST("/tables/:table_id/seat", middleware.Authentication(), controllers.SeatTable())
+	incomingRoutes.POST("/tables/:table_id/reserve", middleware.Authentication(), controllers.ReserveTable())
+	incomingRoutes.POST("/tables/:table_id/clean", middleware.Authentication(), controllers.CleanTable())
+	incomingRoutes.POST("/tables/:table_id/split", middleware.Authentication(), controllers.SplitTable())
+	incomingRoutes.GET("/tables/:table_id/guest-token", middleware.Authentication(), middleware.CheckRole("admin"), controllers.GetTableGuestToken())
+	incomingRoutes.GET("/tables/:table_id/qr", middleware.Authentication(), middleware.CheckRole("admin"), controllers.GetTableQRCode())
 }
@@ -0,0 +1,15 @@
+package routes
+
+import (
+	"github.com/Hdeee1/go-restaurant-management/controllers"
+	"github.com/Hdeee1/go-restaurant-management/middleware"
+	"github.com/gin-gonic/gin"
+)
+
+func TaxRoutes(incomingRoutes *gin.Engine) {
+	incomingRoutes.GET("/tax-categories", middleware.Authentication(), controllers.GetTaxCategories())
+	incomingRoutes.POST("/tax-categories", middleware.Authentication(), middleware.CheckRole("admin"), controllers.CreateTaxCategory())
+	incomingRoutes.GET("/tax-rates", middleware.Authentication(), controllers.GetTaxRates())
+	incomingRoutes.POST("/tax-rates", middleware.Authentication(), middleware.CheckRole("admin"), controllers.CreateTaxRate())
+	incomingRoutes.PATCH("/tax-rates/:tax_rate_id", middleware.Authentication(), middleware.CheckRole("admin"), controllers.UpdateTaxRate())
+}
@@ -0,0 +1,15 @@
+package routes
+
+import (
+	"github.com/Hdeee1/go-restaurant-management/controllers"
+	"github.com/Hdeee1/go-restaurant-management/middleware"
+	"github.com/gin-gonic/gin"
+)
+
+func WaitlistRoutes(incomingRoutes *gin.Engine) {
+	incomingRoutes.POST("/waitlist", middleware.Authentication(), controllers.CreateWaitlistEntry())
+	incomingRoutes.GET("/waitlist", middleware.Authentication(), controllers.GetWaitlist())
+	incomingRoutes.POST("/waitlist/seat-next", middleware.Authentication(), controllers.SeatNextFromWaitlist())
+	incomingRoutes.GET("/waitlist/:waitlist_id", middleware.Authentication(), controllers.GetWaitlistEntry())
+	incomingRoutes.PATCH("/waitlist/:waitlist_id", middleware.Authentication(), controllers.UpdateWaitlistEntry())
+}